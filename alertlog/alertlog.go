@@ -0,0 +1,351 @@
+// Package alertlog keeps a history of the alerts that fired, and when they
+// resolved, across restarts of the monitor.
+//
+// The history is a JSON lines file of alert events appended as they
+// happen. Alerts still firing when the monitor stops are closed by a stop
+// event, written on Close or, after a crash, when the file is next opened.
+package alertlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+// Event statuses in the history file
+const (
+	statusFiring   = "firing"
+	statusResolved = "resolved"
+	statusStopped  = "stopped"
+)
+
+// ErrLocked is returned by Open when another monitor is recording to the
+// history
+var ErrLocked = errors.New("another monitor is recording to the alert history")
+
+// line is one event in the history file
+type line struct {
+	Status  string    `json:"status"`
+	Time    time.Time `json:"time"`
+	ID      string    `json:"id,omitempty"`
+	Source  string    `json:"source,omitempty"`
+	Level   string    `json:"level,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Entry is one alert in the history
+type Entry struct {
+	ID       string // the alert's ID; empty for alerts recorded before IDs
+	Source   string
+	Level    system.AlertLevel
+	Message  string
+	Fired    time.Time
+	Resolved time.Time // zero while the alert is active
+	// Interrupted means the monitor stopped while the alert was firing, so
+	// Resolved is when it stopped rather than when the alert resolved
+	Interrupted bool
+}
+
+// Active reports whether the alert is still firing
+func (e Entry) Active() bool {
+	return e.Resolved.IsZero()
+}
+
+// Status returns "active", "resolved" or "interrupted"
+func (e Entry) Status() string {
+	switch {
+	case e.Active():
+		return "active"
+	case e.Interrupted:
+		return "interrupted"
+	}
+	return "resolved"
+}
+
+// Duration returns how long the alert fired, up to now if it is active
+func (e Entry) Duration(now time.Time) time.Duration {
+	if e.Active() {
+		return now.Sub(e.Fired)
+	}
+	return e.Resolved.Sub(e.Fired)
+}
+
+// Store is an alert history backed by a file. Its methods are safe for
+// concurrent use.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	lock    *os.File       // holds the advisory lock while the store is open
+	entries []Entry        // in the order the alerts fired
+	active  map[string]int // index of the active entry by alert source
+}
+
+// DefaultPath returns the history file in the XDG state directory,
+// $XDG_STATE_HOME/sysmon/alerts.jsonl or ~/.local/state/sysmon/alerts.jsonl
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("couldn't get home directory: %v", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "sysmon", "alerts.jsonl"), nil
+}
+
+// Open loads the history at path, creating the file and its directory if
+// needed, and drops alerts that resolved more than retention ago. A
+// retention of 0 keeps every alert. Lines that do not parse, such as one
+// cut off by a crash, are skipped. It fails with ErrLocked if another
+// monitor has the history open.
+func Open(path string, retention time.Duration, now time.Time) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// The lock is taken on a file of its own: rewriting the history
+	// replaces its file, which would drop a lock held on it
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s := &Store{path: path, lock: lock, active: make(map[string]int)}
+	if err := s.open(retention, now); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// open loads the history and opens its file for appending
+func (s *Store) open(retention time.Duration, now time.Time) error {
+	last, err := s.load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if retention > 0 && s.prune(now.Add(-retention)) {
+		if err := s.rewrite(); err != nil {
+			return err
+		}
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	// Close the alerts left firing by a monitor that did not stop cleanly
+	if len(s.active) > 0 {
+		if err := s.stop(last); err != nil {
+			s.file.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// Load reads the history at path without opening it for writing, for
+// looking at the history while a monitor may be recording to it
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, active: make(map[string]int)}
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the history file and returns the time of its last event
+func (s *Store) load() (time.Time, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	var last time.Time
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil || l.Time.IsZero() {
+			continue
+		}
+		s.apply(l)
+		last = l.Time
+	}
+	return last, scanner.Err()
+}
+
+// apply adds an event to the entries
+func (s *Store) apply(l line) {
+	switch l.Status {
+	case statusFiring:
+		if _, ok := s.active[l.Source]; ok {
+			return
+		}
+		s.active[l.Source] = len(s.entries)
+		s.entries = append(s.entries, Entry{
+			ID:      l.ID,
+			Source:  l.Source,
+			Level:   system.AlertLevel(l.Level),
+			Message: l.Message,
+			Fired:   l.Time,
+		})
+	case statusResolved:
+		if i, ok := s.active[l.Source]; ok {
+			s.entries[i].Resolved = l.Time
+			delete(s.active, l.Source)
+		}
+	case statusStopped:
+		for source, i := range s.active {
+			s.entries[i].Resolved = l.Time
+			s.entries[i].Interrupted = true
+			delete(s.active, source)
+		}
+	}
+}
+
+// prune drops the alerts that resolved before cutoff and reports whether
+// there were any
+func (s *Store) prune(cutoff time.Time) bool {
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if e.Active() || !e.Resolved.Before(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(s.entries) {
+		return false
+	}
+	s.entries = kept
+	s.active = make(map[string]int)
+	for i, e := range s.entries {
+		if e.Active() {
+			s.active[e.Source] = i
+		}
+	}
+	return true
+}
+
+// rewrite replaces the history file with the current entries
+func (s *Store) rewrite() error {
+	var events []line
+	for _, e := range s.entries {
+		events = append(events, line{Status: statusFiring, Time: e.Fired, ID: e.ID, Source: e.Source, Level: string(e.Level), Message: e.Message})
+		switch {
+		case e.Interrupted:
+			events = append(events, line{Status: statusStopped, Time: e.Resolved})
+		case !e.Active():
+			events = append(events, line{Status: statusResolved, Time: e.Resolved, ID: e.ID, Source: e.Source})
+		}
+	}
+	// Replay in time order so each stop event closes the same alerts again
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".alerts-*.jsonl")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	for _, l := range events {
+		if err := enc.Encode(l); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// write appends an event to the file and the entries; the caller must
+// hold s.mu
+func (s *Store) write(l line) error {
+	s.apply(l)
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// stop closes the active alerts as interrupted; the caller must hold s.mu
+func (s *Store) stop(at time.Time) error {
+	return s.write(line{Status: statusStopped, Time: at})
+}
+
+// Record adds an alert that fired or resolved to the history
+func (s *Store) Record(ev system.AlertEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	l := line{Status: statusFiring, Time: ev.Time, ID: ev.Alert.ID, Source: ev.Alert.Source, Level: string(ev.Alert.Level), Message: ev.Alert.Message}
+	if ev.Alert.Resolved {
+		l = line{Status: statusResolved, Time: ev.Time, ID: ev.Alert.ID, Source: ev.Alert.Source}
+	}
+	return s.write(l)
+}
+
+// Query returns the alerts that were active at any time between from and
+// to, most recent first. A zero from or to leaves that end open.
+func (s *Store) Query(from, to time.Time) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found []Entry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		if !to.IsZero() && !e.Fired.Before(to) {
+			continue
+		}
+		if !from.IsZero() && !e.Active() && e.Resolved.Before(from) {
+			continue
+		}
+		found = append(found, e)
+	}
+	return found
+}
+
+// Path returns the history file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Close marks the alerts that are still firing as interrupted at now,
+// closes the file and releases the lock
+func (s *Store) Close(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	var err error
+	if len(s.active) > 0 {
+		err = s.stop(now)
+	}
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.lock.Close()
+	s.file, s.lock = nil, nil
+	return err
+}
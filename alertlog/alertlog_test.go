@@ -0,0 +1,255 @@
+package alertlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+var start = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// event returns an alert event at start plus offset
+func event(source string, resolved bool, offset time.Duration) system.AlertEvent {
+	return system.AlertEvent{
+		Alert: system.Alert{Source: source, Level: system.WarningLevel, Message: source + " is high", Resolved: resolved},
+		Time:  start.Add(offset),
+	}
+}
+
+// withID sets the alert ID of an event
+func withID(ev system.AlertEvent, id string) system.AlertEvent {
+	ev.Alert.ID = id
+	return ev
+}
+
+// summarize lists entries as source:status:duration
+func summarize(entries []Entry, now time.Time) string {
+	var parts []string
+	for _, e := range entries {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s", e.Source, e.Status(), e.Duration(now)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestStorePersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sysmon", "alerts.jsonl")
+	s, err := Open(path, 0, start)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, ev := range []system.AlertEvent{
+		withID(event("cpu_usage", false, 0), "c1"),
+		withID(event("disk_usage{mount=/var}", false, time.Minute), "d1"),
+		withID(event("cpu_usage", true, 5*time.Minute), "c1"),
+		withID(event("cpu_usage", false, 10*time.Minute), "c2"),
+	} {
+		if err := s.Record(ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	now := start.Add(15 * time.Minute)
+	want := "cpu_usage:active:5m0s disk_usage{mount=/var}:active:14m0s cpu_usage:resolved:5m0s"
+	if got := summarize(s.Query(time.Time{}, time.Time{}), now); got != want {
+		t.Errorf("before restart: %s, want %s", got, want)
+	}
+	if err := s.Close(now); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The alerts firing at exit are interrupted when the monitor stopped
+	s, err = Open(path, 0, now)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	if err := s.Record(event("memory_usage", false, 20*time.Minute)); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	want = "memory_usage:active:10m0s cpu_usage:interrupted:5m0s disk_usage{mount=/var}:interrupted:14m0s cpu_usage:resolved:5m0s"
+	entries := s.Query(time.Time{}, time.Time{})
+	if got := summarize(entries, start.Add(30*time.Minute)); got != want {
+		t.Errorf("after restart: %s, want %s", got, want)
+	}
+	if ids := strings.Join([]string{entries[1].ID, entries[2].ID, entries[3].ID}, " "); ids != "c2 d1 c1" {
+		t.Errorf("IDs after restart = %s, want c2 d1 c1", ids)
+	}
+
+	// Without Close, as after a crash, the alerts end at the last event. The
+	// crash releases the lock.
+	s.file.Close()
+	s.lock.Close()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"status":"firing","time":"2024-01-02T03:2`) // cut off mid-write
+	f.Close()
+	s, err = Open(path, 0, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("reopening after a crash: %v", err)
+	}
+	defer s.Close(start.Add(time.Hour))
+	if got := s.Query(time.Time{}, time.Time{})[0]; got.Status() != "interrupted" || !got.Resolved.Equal(start.Add(20*time.Minute)) {
+		t.Errorf("alert left firing by a crash = %+v", got)
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	s, err := Open(path, 0, start)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, ev := range []system.AlertEvent{
+		event("old", false, 0),
+		event("long", false, time.Hour),
+		event("old", true, 2*time.Hour),
+		event("recent", false, 47*time.Hour),
+		event("recent", true, 48*time.Hour),
+	} {
+		s.Record(ev)
+	}
+	s.Close(start.Add(48 * time.Hour))
+
+	s, err = Open(path, 24*time.Hour, start.Add(50*time.Hour))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close(start.Add(50 * time.Hour))
+	want := "recent:resolved:1h0m0s long:interrupted:47h0m0s"
+	if got := summarize(s.Query(time.Time{}, time.Time{}), start); got != want {
+		t.Errorf("after pruning: %s, want %s", got, want)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), `"old"`) {
+		t.Errorf("pruned alert still in the file:\n%s", data)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "alerts.jsonl"), 0, start)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close(start)
+	s.Record(event("a", false, 0))
+	s.Record(event("a", true, time.Hour))
+	s.Record(event("b", false, 2*time.Hour))
+	s.Record(event("b", true, 3*time.Hour))
+	s.Record(event("c", false, 4*time.Hour))
+
+	tests := []struct {
+		from, to time.Duration
+		want     string
+	}{
+		{-time.Hour, 0, "c b a"},
+		{30 * time.Minute, 90 * time.Minute, "a"},
+		{90 * time.Minute, 150 * time.Minute, "b"},
+		{5 * time.Hour, 0, "c"},
+		{-time.Hour, time.Hour, "a"},
+	}
+	for _, tt := range tests {
+		from, to := start.Add(tt.from), time.Time{}
+		if tt.to != 0 {
+			to = start.Add(tt.to)
+		}
+		var got []string
+		for _, e := range s.Query(from, to) {
+			got = append(got, e.Source)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("Query(%s, %s) = %v, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in       string
+		from, to string
+	}{
+		{"", "", ""},
+		{"all", "", ""},
+		{"24h", "2024-01-09T12:00:00Z", ""},
+		{"7d", "2024-01-03T12:00:00Z", ""},
+		{"2w..1d", "2023-12-27T12:00:00Z", "2024-01-09T12:00:00Z"},
+		{"2024-01-02", "2024-01-02T00:00:00Z", "2024-01-03T00:00:00Z"},
+		{"2024-01-02..2024-01-05", "2024-01-02T00:00:00Z", "2024-01-06T00:00:00Z"},
+		{"2024-01-02 08:30..", "2024-01-02T08:30:00Z", ""},
+		{"..2024-01-05T10:00", "", "2024-01-05T10:00:00Z"},
+	}
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	for _, tt := range tests {
+		from, to, err := ParseRange(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.in, err)
+			continue
+		}
+		if format(from) != tt.from || format(to) != tt.to {
+			t.Errorf("ParseRange(%q) = %s..%s, want %s..%s", tt.in, format(from), format(to), tt.from, tt.to)
+		}
+	}
+
+	for _, bad := range []string{"yesterday", "-3d", "2024-13-01", "2024-01-05..2024-01-02", "1d..7d"} {
+		if _, _, err := ParseRange(bad, now); err == nil {
+			t.Errorf("ParseRange(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := []Entry{
+		{ID: "5e0f31c2a9b84d76", Source: "cpu_usage", Level: system.CriticalLevel, Message: "CPU usage is 97, \"sustained\"", Fired: start.Add(time.Hour)},
+		{Source: "disk_usage{mount=/var}", Level: system.WarningLevel, Message: "/var is full", Fired: start, Resolved: start.Add(90 * time.Second)},
+		{Source: "memory_usage", Level: system.WarningLevel, Message: "Memory is high", Fired: start, Resolved: start.Add(26 * time.Hour), Interrupted: true},
+	}
+	now := start.Add(65 * time.Minute)
+
+	var b bytes.Buffer
+	if err := Write(&b, FormatCSV, entries, now); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := `fired,resolved,duration_seconds,status,level,source,message,id
+2024-01-02T04:04:05Z,,300,active,critical,cpu_usage,"CPU usage is 97, ""sustained""",5e0f31c2a9b84d76
+2024-01-02T03:04:05Z,2024-01-02T03:05:35Z,90,resolved,warning,disk_usage{mount=/var},/var is full,
+2024-01-02T03:04:05Z,2024-01-03T05:04:05Z,93600,interrupted,warning,memory_usage,Memory is high,
+`
+	if b.String() != wantCSV {
+		t.Errorf("CSV:\n%s\nwant:\n%s", b.String(), wantCSV)
+	}
+
+	b.Reset()
+	if err := Write(&b, FormatJSON, entries[:2], now); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"id": "5e0f31c2a9b84d76"`, `"resolved": null`, `"duration_seconds": 300`, `"resolved": "2024-01-02T03:05:35Z"`, `"status": "resolved"`} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("JSON does not contain %s:\n%s", want, b.String())
+		}
+	}
+
+	b.Reset()
+	if err := Write(&b, FormatText, entries, now); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"active", "5m00s", "2024-01-02 03:05:35", "1m30s", "(stopped)", "1d02h"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text does not contain %q:\n%s", want, b.String())
+		}
+	}
+
+	if err := Write(&b, "xml", entries, now); err == nil {
+		t.Error("Write accepted an unknown format")
+	}
+}
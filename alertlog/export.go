@@ -0,0 +1,123 @@
+package alertlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Export formats accepted by Write
+const (
+	FormatText = "text"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// exportEntry is the JSON form of an entry
+type exportEntry struct {
+	ID              string     `json:"id,omitempty"`
+	Source          string     `json:"source"`
+	Level           string     `json:"level"`
+	Message         string     `json:"message"`
+	Status          string     `json:"status"`
+	Fired           time.Time  `json:"fired"`
+	Resolved        *time.Time `json:"resolved"`
+	DurationSeconds float64    `json:"duration_seconds"`
+}
+
+// Write exports entries in the given format. Durations of active alerts
+// run up to now.
+func Write(w io.Writer, format string, entries []Entry, now time.Time) error {
+	switch format {
+	case FormatText:
+		return writeText(w, entries, now)
+	case FormatCSV:
+		return writeCSV(w, entries, now)
+	case FormatJSON:
+		return writeJSON(w, entries, now)
+	}
+	return fmt.Errorf("unknown format %q: use text, csv or json", format)
+}
+
+// writeText writes entries as an aligned table
+func writeText(w io.Writer, entries []Entry, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIRED\tRESOLVED\tDURATION\tLEVEL\tSOURCE\tMESSAGE")
+	for _, e := range entries {
+		resolved := e.Status()
+		if !e.Active() {
+			resolved = e.Resolved.Format("2006-01-02 15:04:05")
+			if e.Interrupted {
+				resolved += " (stopped)"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Fired.Format("2006-01-02 15:04:05"), resolved, formatDuration(e.Duration(now)), e.Level, e.Source, e.Message)
+	}
+	return tw.Flush()
+}
+
+// writeCSV writes entries with a header row and RFC 3339 times
+func writeCSV(w io.Writer, entries []Entry, now time.Time) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"fired", "resolved", "duration_seconds", "status", "level", "source", "message", "id"})
+	for _, e := range entries {
+		var resolved string
+		if !e.Active() {
+			resolved = e.Resolved.Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			e.Fired.Format(time.RFC3339),
+			resolved,
+			strconv.FormatFloat(e.Duration(now).Seconds(), 'f', 0, 64),
+			e.Status(),
+			string(e.Level),
+			e.Source,
+			e.Message,
+			e.ID,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes entries as an indented JSON array
+func writeJSON(w io.Writer, entries []Entry, now time.Time) error {
+	out := make([]exportEntry, len(entries))
+	for i, e := range entries {
+		out[i] = exportEntry{
+			ID:              e.ID,
+			Source:          e.Source,
+			Level:           string(e.Level),
+			Message:         e.Message,
+			Status:          e.Status(),
+			Fired:           e.Fired,
+			DurationSeconds: e.Duration(now).Round(time.Second).Seconds(),
+		}
+		if !e.Active() {
+			resolved := e.Resolved
+			out[i].Resolved = &resolved
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// formatDuration formats a duration compactly, e.g. 45s, 12m30s or 3h05m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+}
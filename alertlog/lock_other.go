@@ -0,0 +1,11 @@
+//go:build !unix
+
+package alertlog
+
+import "os"
+
+// lockFile does nothing: advisory locks are only taken on Unix. Elsewhere,
+// keeping a second monitor off the same history is left to the user.
+func lockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package alertlog
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenLocksHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	s, err := Open(path, 0, start)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := s.Record(event("cpu_usage", false, 0)); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	// A second monitor fails to open the history and leaves the alerts of
+	// the first one active
+	if _, err := Open(path, 0, start.Add(time.Minute)); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second Open = %v, want %v", err, ErrLocked)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := summarize(loaded.Query(time.Time{}, time.Time{}), start.Add(time.Minute)); got != "cpu_usage:active:1m0s" {
+		t.Errorf("history while locked: %s, want cpu_usage:active:1m0s", got)
+	}
+
+	// Closing releases the lock
+	if err := s.Close(start.Add(2 * time.Minute)); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	s, err = Open(path, 0, start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("Open after Close: %v", err)
+	}
+	s.Close(start.Add(3 * time.Minute))
+}
@@ -0,0 +1,97 @@
+package alertlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the absolute times accepted in ranges, in local time
+// unless they carry a zone
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseRange parses a time range for Query. A range is either a
+// duration back from now ("90m", "24h", "7d", "2w"), a single day
+// ("2024-01-02") or time to now, or two of these separated by ".." where
+// either side may be left out ("2024-01-01..2024-01-07", "7d..1d"). An end
+// given as a day includes that day. "" and "all" select everything.
+// Absolute times are read in the location of now.
+func ParseRange(s string, now time.Time) (from, to time.Time, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "all" {
+		return time.Time{}, time.Time{}, nil
+	}
+
+	start, end, isRange := strings.Cut(s, "..")
+	if !isRange {
+		from, dayEnd, err := parsePoint(s, now)
+		if err != nil {
+			return from, to, err
+		}
+		// A single day selects that day only
+		return from, dayEnd, nil
+	}
+
+	if start = strings.TrimSpace(start); start != "" {
+		if from, _, err = parsePoint(start, now); err != nil {
+			return from, to, err
+		}
+	}
+	if end = strings.TrimSpace(end); end != "" {
+		point, dayEnd, err := parsePoint(end, now)
+		if err != nil {
+			return from, to, err
+		}
+		to = point
+		if !dayEnd.IsZero() {
+			to = dayEnd
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("range %q ends before it starts", s)
+	}
+	return from, to, nil
+}
+
+// parsePoint parses one end of a range. For a day it also returns the
+// start of the next day.
+func parsePoint(s string, now time.Time) (t, dayEnd time.Time, err error) {
+	if d, ok := ParseDuration(s); ok {
+		return now.Add(-d), time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			return t, t.AddDate(0, 0, 1), nil
+		}
+		return t, time.Time{}, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid time %q: use a duration such as 24h or 7d, or a date such as 2024-01-02", s)
+}
+
+// ParseDuration parses a non-negative duration, allowing days (d) and
+// weeks (w) as units in addition to those of time.ParseDuration
+func ParseDuration(s string) (time.Duration, bool) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil || count < 0 {
+				return 0, false
+			}
+			return time.Duration(count * float64(unit)), true
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d >= 0
+}
@@ -0,0 +1,195 @@
+// Package batch prints snapshots as plain text, one report per collection
+// cycle, in the spirit of `top -b`.
+package batch
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+	"go_system_monitor/ui"
+)
+
+// Printer writes snapshot reports to an output. Styling is only applied
+// when the output is a terminal.
+type Printer struct {
+	w      io.Writer
+	header lipgloss.Style
+	table  lipgloss.Style
+	warn   lipgloss.Style
+	crit   lipgloss.Style
+}
+
+// NewPrinter creates a printer writing to w
+func NewPrinter(w io.Writer) *Printer {
+	return NewPrinterWithRenderer(w, lipgloss.NewRenderer(w))
+}
+
+// NewPrinterWithRenderer creates a printer that styles its output with r
+func NewPrinterWithRenderer(w io.Writer, r *lipgloss.Renderer) *Printer {
+	return &Printer{
+		w:      w,
+		header: r.NewStyle().Bold(true),
+		table:  r.NewStyle().Reverse(true),
+		warn:   r.NewStyle().Foreground(lipgloss.Color("#F59E0B")),
+		crit:   r.NewStyle().Foreground(lipgloss.Color("#EF4444")).Bold(true),
+	}
+}
+
+// Print writes the system header and the process table of snap. The
+// processes are printed in snapshot order, limited to MaxProcesses.
+func (p *Printer) Print(snap *system.Snapshot) error {
+	var b strings.Builder
+
+	// Summary lines, modelled on top
+	load := "load average: n/a"
+	if l := snap.CPU.LoadAvg; l != nil {
+		load = fmt.Sprintf("load average: %.2f, %.2f, %.2f", l.Load1, l.Load5, l.Load15)
+	}
+	b.WriteString(p.header.Render(fmt.Sprintf("sysmon - %s %s up %s, %s",
+		snap.System.Hostname,
+		snap.System.LastUpdated.Format("2006-01-02 15:04:05"),
+		formatUptime(snap.System.Uptime),
+		load,
+	)))
+	b.WriteString("\n")
+
+	states := make(map[string]int)
+	for _, proc := range snap.Process.Processes {
+		states[stateLetter(proc.Status)]++
+	}
+	fmt.Fprintf(&b, "Tasks: %d total, %d running, %d sleeping, %d stopped, %d zombie\n",
+		snap.Process.Total, states["R"], states["S"]+states["D"]+states["I"], states["T"], states["Z"])
+
+	fmt.Fprintf(&b, "%%Cpu(s): %s", p.percent(snap.CPU.Usage))
+	if len(snap.CPU.UsagePerCPU) > 0 {
+		cores := make([]string, len(snap.CPU.UsagePerCPU))
+		for i, usage := range snap.CPU.UsagePerCPU {
+			cores[i] = fmt.Sprintf("cpu%d %.1f", i, usage)
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(cores, ", "))
+	}
+	b.WriteString("\n")
+
+	mem := snap.Memory
+	fmt.Fprintf(&b, "MiB Mem : %9.1f total, %9.1f free, %9.1f used, %s\n",
+		mib(mem.Total), mib(mem.Free), mib(mem.Used), p.percent(mem.UsedPercent))
+	fmt.Fprintf(&b, "MiB Swap: %9.1f total, %9.1f free, %9.1f used, %s\n",
+		mib(mem.SwapTotal), mib(mem.SwapFree), mib(mem.SwapUsed), p.percent(mem.SwapPercent))
+
+	for _, alert := range snap.Alerts {
+		if !alert.Firing() {
+			continue
+		}
+		line := fmt.Sprintf("ALERT %s %s: %s", strings.ToUpper(string(alert.Level)), alert.Source, alert.Message)
+		if alert.SuppressedBy != "" {
+			line += " (" + alert.SuppressedBy + ")"
+		}
+		if alert.Level == system.CriticalLevel {
+			line = p.crit.Render(line)
+		} else {
+			line = p.warn.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	// Process table
+	b.WriteString("\n")
+	b.WriteString(p.table.Render(fmt.Sprintf("%7s %-10s %3s %1s %6s %6s %9s %9s %4s %s",
+		"PID", "USER", "NI", "S", "%CPU", "%MEM", "VIRT", "RES", "THR", "COMMAND")))
+	b.WriteString("\n")
+
+	processes := snap.Process.Processes
+	if snap.MaxProcesses > 0 && len(processes) > snap.MaxProcesses {
+		processes = processes[:snap.MaxProcesses]
+	}
+	for _, proc := range processes {
+		user := ui.Truncate(proc.Username, 10)
+		fmt.Fprintf(&b, "%7d %-10s %3d %1s %6.1f %6.1f %9s %9s %4d %s\n",
+			proc.PID, user, proc.Nice, stateLetter(proc.Status),
+			proc.CPUPercent, proc.MemPercent,
+			formatKiB(proc.MemVMS), formatKiB(proc.MemRSS),
+			proc.NumThreads, proc.Name)
+	}
+
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// percent formats a usage percentage, highlighted when it is high
+func (p *Printer) percent(value float64) string {
+	s := fmt.Sprintf("%.1f%%", value)
+	switch {
+	case value >= 90:
+		return p.crit.Render(s)
+	case value >= 75:
+		return p.warn.Render(s)
+	}
+	return s
+}
+
+// stateLetter returns the ps state letter of a process. gopsutil reports
+// states as words ("running"); letters are passed through.
+func stateLetter(status []string) string {
+	if len(status) == 0 {
+		return "?"
+	}
+	switch status[0] {
+	case "running":
+		return "R"
+	case "sleep":
+		return "S"
+	case "blocked":
+		return "D"
+	case "idle":
+		return "I"
+	case "stop":
+		return "T"
+	case "zombie":
+		return "Z"
+	case "wait":
+		return "W"
+	case "lock":
+		return "L"
+	}
+	if len(status[0]) == 1 {
+		return status[0]
+	}
+	return "?"
+}
+
+// mib converts bytes to mebibytes
+func mib(bytes uint64) float64 {
+	return float64(bytes) / (1 << 20)
+}
+
+// formatKiB formats a byte count in KiB, switching to larger units like top
+// when the value does not fit the column
+func formatKiB(bytes uint64) string {
+	kib := bytes >> 10
+	switch {
+	case kib < 1000000:
+		return fmt.Sprintf("%d", kib)
+	case kib < 1000000<<10:
+		return fmt.Sprintf("%.1fm", float64(kib)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1fg", float64(kib)/(1<<20))
+	}
+}
+
+// formatUptime formats an uptime the way top does, e.g. "3 days, 04:05"
+func formatUptime(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	clock := fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	switch days {
+	case 0:
+		return clock
+	case 1:
+		return "1 day, " + clock
+	}
+	return fmt.Sprintf("%d days, %s", days, clock)
+}
@@ -0,0 +1,51 @@
+package batch
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"go_system_monitor/internal/testutil"
+	"go_system_monitor/system"
+)
+
+// testSnapshot returns the shared fixture with more processes than
+// MaxProcesses and user names wider than their column
+func testSnapshot() *system.Snapshot {
+	snap := testutil.Snapshot()
+	snap.Process.Processes = append(snap.Process.Processes,
+		system.ProcessDetail{PID: 4244, Name: "worker", Username: "applicationuser", Status: []string{"sleep"}, Nice: 10, CPUPercent: 7, MemPercent: 1, MemRSS: 64 << 20, MemVMS: 2 << 40, NumThreads: 40},
+		system.ProcessDetail{PID: 4245, Name: "cron", Username: "ñuñez-garcía", Status: []string{"sleep"}, CPUPercent: 0.2, NumThreads: 1},
+		system.ProcessDetail{PID: 666, Name: "defunct", Username: "root", Status: []string{"zombie"}})
+	system.SortProcessList(snap.Process.Processes, snap.Process.SortBy)
+	snap.Process.Total = len(snap.Process.Processes)
+	snap.MaxProcesses = 5
+	return snap
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewPrinter(&buf).Print(testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("output to a non-terminal contains ANSI escapes:\n%q", buf.String())
+	}
+
+	testutil.AssertGolden(t, filepath.Join("testdata", "batch.golden"), buf.Bytes())
+}
+
+func TestPrintStyledOnTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	r := lipgloss.NewRenderer(&buf)
+	r.SetColorProfile(termenv.ANSI256)
+	if err := NewPrinterWithRenderer(&buf, r).Print(testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\x1b[") {
+		t.Error("styled output contains no ANSI escapes")
+	}
+}
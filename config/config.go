@@ -17,6 +17,108 @@ type AppConfig struct {
 	MaxProcesses       int     `json:"max_processes"`
 	MaxAlertsToKeep    int     `json:"max_alerts_to_keep"`
 	DefaultSortingMode string  `json:"default_sorting_mode"`
+	// Pressure thresholds alert when tasks stalled on a resource for this
+	// percentage of the last 10 seconds (Linux PSI); 0 disables the alert
+	CPUPressureThreshold    float64 `json:"cpu_pressure_threshold"`
+	MemoryPressureThreshold float64 `json:"memory_pressure_threshold"`
+	IOPressureThreshold     float64 `json:"io_pressure_threshold"`
+	// SourceTimeouts overrides how long collection waits for individual
+	// metric sources, in milliseconds keyed by source name
+	SourceTimeouts map[string]int `json:"source_timeouts_ms,omitempty"`
+	// HostRoot makes the collector read proc, sys and etc below this
+	// directory instead of the live system, e.g. a recorded fixture tree
+	HostRoot string `json:"host_root,omitempty"`
+	// ListenAddress serves Prometheus metrics on this address when set,
+	// e.g. ":9101"
+	ListenAddress string `json:"listen_address,omitempty"`
+	// SavedFilters are process filter queries offered in the filter bar
+	SavedFilters []SavedFilter `json:"saved_filters,omitempty"`
+	// ProcessColumns selects, orders and sizes the process table columns;
+	// empty shows the default columns
+	ProcessColumns []ColumnConfig `json:"process_columns,omitempty"`
+	// AlertRules are added to the rules built from the thresholds above; a
+	// rule with the name of a built-in one replaces or disables it
+	AlertRules []AlertRuleConfig `json:"alert_rules,omitempty"`
+	// Notifications send alerts that fire or resolve to external systems
+	Notifications *NotificationConfig `json:"notifications,omitempty"`
+	// AlertHistoryDays keeps resolved alerts in the alert history for this
+	// many days; 0 keeps them all and a negative value turns it off
+	AlertHistoryDays int `json:"alert_history_days"`
+	// AlertHistoryFile replaces the default history file,
+	// $XDG_STATE_HOME/sysmon/alerts.jsonl
+	AlertHistoryFile string `json:"alert_history_file,omitempty"`
+	// MaintenanceWindows suppress matching alerts at recurring times
+	MaintenanceWindows []MaintenanceWindowConfig `json:"maintenance_windows,omitempty"`
+}
+
+// MaintenanceWindowConfig is a recurring time during which matching alerts
+// are recorded but not notified
+type MaintenanceWindowConfig struct {
+	Name     string   `json:"name"`
+	Days     []string `json:"days,omitempty"` // mon to sun; every day when empty
+	Start    string   `json:"start"`          // local time of day such as "02:30"
+	Duration string   `json:"duration"`       // such as "2h"
+	// Alerts are rule names or alert sources, where * matches any text;
+	// every alert when empty
+	Alerts []string `json:"alerts,omitempty"`
+}
+
+// NotificationConfig lists the destinations notified of alerts
+type NotificationConfig struct {
+	// RateLimit is the minimum time between notifications of one alert,
+	// e.g. "5m"; changes in between are coalesced. Defaults to 1m.
+	RateLimit string          `json:"rate_limit,omitempty"`
+	Retries   int             `json:"retries,omitempty"` // attempts after a failure, 3 by default
+	Webhooks  []WebhookConfig `json:"webhooks,omitempty"`
+	Commands  []CommandConfig `json:"commands,omitempty"`
+	Syslog    *SyslogConfig   `json:"syslog,omitempty"`
+}
+
+// WebhookConfig posts alerts as JSON to a URL
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// CommandConfig runs a program for every alert, passing the alert in
+// SYSMON_* environment variables
+type CommandConfig struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// SyslogConfig logs alerts to the local syslog or journald, or to a
+// remote syslog server when Address is set
+type SyslogConfig struct {
+	Tag     string `json:"tag,omitempty"`
+	Network string `json:"network,omitempty"` // udp or tcp for a remote server
+	Address string `json:"address,omitempty"`
+}
+
+// AlertRuleConfig raises an alert when a metric crosses a threshold
+type AlertRuleConfig struct {
+	Name       string  `json:"name"`
+	Metric     string  `json:"metric"` // selector such as net.recv_rate{iface=eth0}
+	Op         string  `json:"op"`     // >, >=, < or <=
+	Threshold  float64 `json:"threshold"`
+	For        string  `json:"for,omitempty"`      // duration such as "2m"
+	Severity   string  `json:"severity,omitempty"` // info, warning (default) or critical
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+	Message    string  `json:"message,omitempty"` // Go template
+	Disabled   bool    `json:"disabled,omitempty"`
+}
+
+// ColumnConfig is a process table column and its width; 0 keeps the
+// default width
+type ColumnConfig struct {
+	ID    string `json:"id"`
+	Width int    `json:"width,omitempty"`
+}
+
+// SavedFilter is a named process filter query
+type SavedFilter struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
 }
 
 // DefaultConfig returns the default configuration
@@ -30,6 +132,12 @@ func DefaultConfig() AppConfig {
 		MaxProcesses:       15,
 		MaxAlertsToKeep:    100,
 		DefaultSortingMode: "cpu",
+
+		CPUPressureThreshold:    50.0,
+		MemoryPressureThreshold: 20.0,
+		IOPressureThreshold:     30.0,
+
+		AlertHistoryDays: 30,
 	}
 }
 
@@ -0,0 +1,279 @@
+// Package exporter serves collected snapshots in the Prometheus text
+// exposition format.
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"go_system_monitor/system"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter holds the latest snapshot and serves it on /metrics
+type Exporter struct {
+	mu   sync.RWMutex
+	snap *system.Snapshot
+}
+
+// New creates an exporter with no snapshot yet
+func New() *Exporter {
+	return &Exporter{}
+}
+
+// Update replaces the snapshot served by the exporter
+func (e *Exporter) Update(snap *system.Snapshot) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.snap = snap
+}
+
+// Snapshot returns the snapshot currently being served
+func (e *Exporter) Snapshot() *system.Snapshot {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.snap
+}
+
+// ServeHTTP writes the current snapshot in the Prometheus text format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snap := e.Snapshot()
+	if snap == nil {
+		http.Error(w, "no metrics collected yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, snap); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+// Handler returns an HTTP handler serving the exporter on /metrics
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `<html><body><a href="/metrics">Metrics</a></body></html>`)
+	})
+	return mux
+}
+
+// WriteMetrics writes snap in the Prometheus text format. Only the first
+// MaxProcesses processes by CPU usage are exported.
+func WriteMetrics(w io.Writer, snap *system.Snapshot) error {
+	m := &metricWriter{w: w}
+
+	m.family("sysmon_last_collect_timestamp_seconds", "gauge", "Time of the last collection cycle.")
+	m.sample("sysmon_last_collect_timestamp_seconds", float64(snap.System.LastUpdated.UnixMilli())/1000)
+	m.family("sysmon_collect_duration_seconds", "gauge", "Duration of the last collection cycle.")
+	m.sample("sysmon_collect_duration_seconds", snap.Report.Duration.Seconds())
+	m.family("sysmon_source_timed_out", "gauge", "Metric sources that missed the last collection deadline.")
+	for _, name := range snap.Report.TimedOut {
+		m.sample("sysmon_source_timed_out", 1, "source", name)
+	}
+	m.family("sysmon_uptime_seconds", "gauge", "Host uptime.")
+	m.sample("sysmon_uptime_seconds", snap.System.Uptime.Seconds())
+
+	// CPU
+	m.family("sysmon_cpu_usage_percent", "gauge", "Total CPU usage.")
+	m.sample("sysmon_cpu_usage_percent", snap.CPU.Usage)
+	m.family("sysmon_cpu_core_usage_percent", "gauge", "CPU usage per core.")
+	for i, usage := range snap.CPU.UsagePerCPU {
+		m.sample("sysmon_cpu_core_usage_percent", usage, "cpu", strconv.Itoa(i))
+	}
+	if load := snap.CPU.LoadAvg; load != nil {
+		m.family("sysmon_load1", "gauge", "1 minute load average.")
+		m.sample("sysmon_load1", load.Load1)
+		m.family("sysmon_load5", "gauge", "5 minute load average.")
+		m.sample("sysmon_load5", load.Load5)
+		m.family("sysmon_load15", "gauge", "15 minute load average.")
+		m.sample("sysmon_load15", load.Load15)
+	}
+
+	// Memory and swap
+	mem := snap.Memory
+	m.family("sysmon_memory_total_bytes", "gauge", "Total physical memory.")
+	m.sample("sysmon_memory_total_bytes", float64(mem.Total))
+	m.family("sysmon_memory_used_bytes", "gauge", "Used physical memory.")
+	m.sample("sysmon_memory_used_bytes", float64(mem.Used))
+	m.family("sysmon_memory_free_bytes", "gauge", "Free physical memory.")
+	m.sample("sysmon_memory_free_bytes", float64(mem.Free))
+	m.family("sysmon_memory_used_percent", "gauge", "Used physical memory in percent.")
+	m.sample("sysmon_memory_used_percent", mem.UsedPercent)
+	m.family("sysmon_swap_total_bytes", "gauge", "Total swap space.")
+	m.sample("sysmon_swap_total_bytes", float64(mem.SwapTotal))
+	m.family("sysmon_swap_used_bytes", "gauge", "Used swap space.")
+	m.sample("sysmon_swap_used_bytes", float64(mem.SwapUsed))
+	m.family("sysmon_swap_free_bytes", "gauge", "Free swap space.")
+	m.sample("sysmon_swap_free_bytes", float64(mem.SwapFree))
+	m.family("sysmon_swap_used_percent", "gauge", "Used swap space in percent.")
+	m.sample("sysmon_swap_used_percent", mem.SwapPercent)
+
+	// Disk usage per mount point
+	mounts := make([]string, 0, len(snap.Disk.UsageStats))
+	for mount := range snap.Disk.UsageStats {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+	diskGauges := []struct {
+		name, help string
+		value      func(u *disk.UsageStat) float64
+	}{
+		{"sysmon_disk_total_bytes", "Size of the filesystem.", func(u *disk.UsageStat) float64 { return float64(u.Total) }},
+		{"sysmon_disk_used_bytes", "Used space on the filesystem.", func(u *disk.UsageStat) float64 { return float64(u.Used) }},
+		{"sysmon_disk_free_bytes", "Free space on the filesystem.", func(u *disk.UsageStat) float64 { return float64(u.Free) }},
+		{"sysmon_disk_used_percent", "Used space on the filesystem in percent.", func(u *disk.UsageStat) float64 { return u.UsedPercent }},
+	}
+	for _, g := range diskGauges {
+		m.family(g.name, "gauge", g.help)
+		for _, mount := range mounts {
+			if u := snap.Disk.UsageStats[mount]; u != nil {
+				m.sample(g.name, g.value(u), "mountpoint", mount, "fstype", u.Fstype)
+			}
+		}
+	}
+
+	// Disk and network throughput
+	m.rates("sysmon_disk_read_bytes_per_second", "Disk read throughput.", "device", snap.Disk.ReadRate)
+	m.rates("sysmon_disk_write_bytes_per_second", "Disk write throughput.", "device", snap.Disk.WriteRate)
+	m.rates("sysmon_network_receive_bytes_per_second", "Network receive throughput.", "interface", snap.Network.RecvRate)
+	m.rates("sysmon_network_transmit_bytes_per_second", "Network transmit throughput.", "interface", snap.Network.SentRate)
+
+	// Firing alerts, counted by source and level
+	type alertKey struct{ source, level string }
+	active := make(map[alertKey]int)
+	for _, alert := range snap.Alerts {
+		if alert.Firing() {
+			active[alertKey{alert.Source, string(alert.Level)}]++
+		}
+	}
+	keys := make([]alertKey, 0, len(active))
+	for k := range active {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].source != keys[j].source {
+			return keys[i].source < keys[j].source
+		}
+		return keys[i].level < keys[j].level
+	})
+	m.family("sysmon_alerts_active", "gauge", "Number of active alerts.")
+	for _, k := range keys {
+		m.sample("sysmon_alerts_active", float64(active[k]), "source", k.source, "level", k.level)
+	}
+
+	// Top processes by CPU usage
+	processes := append([]system.ProcessDetail(nil), snap.Process.Processes...)
+	system.SortProcessList(processes, system.SortByCPU)
+	if snap.MaxProcesses > 0 && len(processes) > snap.MaxProcesses {
+		processes = processes[:snap.MaxProcesses]
+	}
+	m.family("sysmon_processes_total", "gauge", "Number of processes on the host.")
+	m.sample("sysmon_processes_total", float64(snap.Process.Total))
+	stats := snap.Process.Stats
+	m.family("sysmon_process_collect_duration_seconds", "gauge", "Time spent reading the process table in the last cycle.")
+	m.sample("sysmon_process_collect_duration_seconds", stats.Duration.Seconds())
+	m.family("sysmon_process_cache_hits", "gauge", "Processes whose static attributes came from the cache in the last cycle.")
+	m.sample("sysmon_process_cache_hits", float64(stats.Cached))
+	m.family("sysmon_process_cache_loads", "gauge", "Processes whose static attributes were read in the last cycle.")
+	m.sample("sysmon_process_cache_loads", float64(stats.Loaded))
+	m.family("sysmon_process_cache_evictions", "gauge", "Cached processes dropped after exiting in the last cycle.")
+	m.sample("sysmon_process_cache_evictions", float64(stats.Evicted))
+	processGauges := []struct {
+		name, help string
+		value      func(p system.ProcessDetail) float64
+	}{
+		{"sysmon_process_cpu_percent", "CPU usage of the top processes.", func(p system.ProcessDetail) float64 { return p.CPUPercent }},
+		{"sysmon_process_memory_percent", "Memory usage of the top processes in percent.", func(p system.ProcessDetail) float64 { return float64(p.MemPercent) }},
+		{"sysmon_process_resident_memory_bytes", "Resident memory of the top processes.", func(p system.ProcessDetail) float64 { return float64(p.MemRSS) }},
+		{"sysmon_process_threads", "Number of threads of the top processes.", func(p system.ProcessDetail) float64 { return float64(p.NumThreads) }},
+	}
+	for _, g := range processGauges {
+		m.family(g.name, "gauge", g.help)
+		for _, p := range processes {
+			m.sample(g.name, g.value(p), "pid", strconv.Itoa(int(p.PID)), "name", p.Name, "user", p.Username)
+		}
+	}
+
+	return m.err
+}
+
+// metricWriter writes metric families and samples, keeping the first error
+type metricWriter struct {
+	w   io.Writer
+	err error
+}
+
+// family writes the HELP and TYPE lines of a metric family
+func (m *metricWriter) family(name, typ, help string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+// sample writes one sample; labels are given as name, value pairs
+func (m *metricWriter) sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	m.printf("%s %s\n", b.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// rates writes a gauge family with one sample per key of values
+func (m *metricWriter) rates(name, help, label string, values map[string]float64) {
+	m.family(name, "gauge", help)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m.sample(name, values[k], label, k)
+	}
+}
+
+func (m *metricWriter) printf(format string, args ...interface{}) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, format, args...)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes a HELP text
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// escapeLabel escapes a label value
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
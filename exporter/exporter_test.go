@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go_system_monitor/internal/testutil"
+	"go_system_monitor/system"
+)
+
+// testSnapshot returns the shared fixture with collection stats and a
+// process name that needs escaping
+func testSnapshot() *system.Snapshot {
+	snap := testutil.Snapshot()
+	snap.Memory.SwapTotal, snap.Memory.SwapUsed, snap.Memory.SwapFree, snap.Memory.SwapPercent = 2<<30, 1<<30, 1<<30, 50
+	snap.Process.Processes = append(snap.Process.Processes,
+		system.ProcessDetail{PID: 4244, Name: `weird "name"`, Username: "app", CPUPercent: 7, MemPercent: 1, MemRSS: 64 << 20, NumThreads: 2})
+	snap.Process.Total = 180
+	snap.Process.Stats = system.ProcessCollectStats{Duration: 12 * time.Millisecond, Scanned: 180, Cached: 178, Loaded: 2, Evicted: 1}
+	snap.MaxProcesses = 2
+	snap.Report.Started = testutil.Time
+	snap.Report.Duration = 250 * time.Millisecond
+	return snap
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.AssertGolden(t, filepath.Join("testdata", "metrics.golden"), buf.Bytes())
+}
+
+func TestHandler(t *testing.T) {
+	e := New()
+	srv := httptest.NewServer(e.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status before first snapshot = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	e.Update(testSnapshot())
+	resp, err = http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if !bytes.Contains(body, []byte("sysmon_cpu_usage_percent 61.5\n")) {
+		t.Errorf("response does not contain the cpu usage:\n%s", body)
+	}
+}
@@ -0,0 +1,145 @@
+// Package testutil holds the fixtures and helpers shared by the tests of
+// several packages
+package testutil
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/net"
+	"go_system_monitor/system"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// Time is the collection time of Snapshot
+var Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Snapshot returns a fully populated snapshot with fixed values. Each call
+// returns a new copy, so tests may adjust it to the cases they cover.
+func Snapshot() *system.Snapshot {
+	return &system.Snapshot{
+		System: system.SystemInfo{
+			Hostname:    "fixture-host",
+			Platform:    "fixture",
+			OS:          "linux",
+			KernelVer:   "6.1.0",
+			Uptime:      36 * time.Hour,
+			LastUpdated: Time,
+		},
+		CPU: system.CPUInfo{
+			Usage:       61.5,
+			UsagePerCPU: []float64{83.3, 42.9},
+			Cores:       2,
+			LoadAvg:     &load.AvgStat{Load1: 0.52, Load5: 0.58, Load15: 0.59},
+		},
+		Memory: system.MemoryInfo{
+			Total:       8 << 30,
+			Used:        4 << 30,
+			Free:        2 << 30,
+			UsedPercent: 50,
+		},
+		Disk: system.DiskInfo{
+			Partitions: []disk.PartitionStat{
+				{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+				{Device: "/dev/sdb", Mountpoint: "/var", Fstype: "xfs"},
+			},
+			UsageStats: map[string]*disk.UsageStat{
+				"/":    {Path: "/", Fstype: "ext4", Total: 100 << 30, Used: 40 << 30, Free: 60 << 30, UsedPercent: 40},
+				"/var": {Path: "/var", Fstype: "xfs", Total: 500 << 30, Used: 475 << 30, Free: 25 << 30, UsedPercent: 95},
+			},
+			ReadRate:  map[string]float64{"sda": 1228800},
+			WriteRate: map[string]float64{"sda": 524288},
+		},
+		Network: system.NetworkInfo{
+			IOCounters: map[string]net.IOCountersStat{
+				"eth0": {Name: "eth0", BytesRecv: 6048576, BytesSent: 2524288},
+				"lo":   {Name: "lo", BytesRecv: 100000, BytesSent: 100000},
+			},
+			RecvRate:    map[string]float64{"eth0": 524288, "wlan0": 2048, "lo": 10},
+			SentRate:    map[string]float64{"eth0": 262144, "wlan0": 1024, "lo": 10},
+			Connections: make([]net.ConnectionStat, 12),
+		},
+		Process: system.ProcessInfo{
+			Total:  3,
+			SortBy: system.SortByCPU,
+			Processes: []system.ProcessDetail{
+				{PID: 4243, PPID: 4242, Name: "java", Username: "root", Status: []string{"running"}, CPUPercent: 55.2, CPULifetime: 20.4, MemPercent: 12.5, MemRSS: 1 << 30, MemVMS: 4 << 30, NumThreads: 40, CmdLine: "/usr/bin/java -jar app.jar", Cgroup: "/system.slice/app.service"},
+				{PID: 4242, PPID: 1, Name: "postgres", Username: "postgres", Status: []string{"sleep"}, CPUPercent: 3.1, CPULifetime: 9.8, MemPercent: 3, MemRSS: 240 << 20, MemVMS: 1 << 30, NumThreads: 8, CmdLine: "postgres -D /var/lib/postgresql", Cgroup: "/system.slice/postgresql.service"},
+				{PID: 1, Name: "systemd", Username: "root", Status: []string{"sleep"}, CPUPercent: 0.1, CPULifetime: 0.3, MemPercent: 0.15, MemRSS: 12 << 20, MemVMS: 160 << 20, NumThreads: 1, CmdLine: "/sbin/init splash", Cgroup: "/init.scope"},
+			},
+		},
+		Cgroups: system.CgroupInfo{
+			Available: true,
+			Groups: []system.CgroupStats{
+				{Path: "/", CPUPercent: 120},
+				{Path: "/init.scope", Depth: 1, CPUPercent: 0.1, MemoryCurrent: 12 << 20, PidsCurrent: 1},
+				{Path: "/system.slice", Depth: 1, CPUPercent: 58.3, MemoryCurrent: 1300 << 20, PidsCurrent: 48},
+				{Path: "/system.slice/app.service", Depth: 2, CPUPercent: 55.2, CPUQuota: 2, ThrottledPercent: 25,
+					MemoryCurrent: 1000 << 20, MemoryMax: 2 << 30, OOMKills: 1, PidsCurrent: 40, PidsMax: 100, IOReadRate: 524288,
+					MemoryPressure: system.Pressure{Some: system.PressureStats{Avg10: 26.9}, Full: system.PressureStats{Avg10: 15.1}}},
+				{Path: "/system.slice/postgresql.service", Depth: 2, CPUPercent: 3.1, MemoryCurrent: 240 << 20, PidsCurrent: 8},
+				{Path: "/user.slice", Depth: 1},
+			},
+		},
+		Pressure: system.PressureInfo{
+			Available: true,
+			CPU:       system.Pressure{Some: system.PressureStats{Avg10: 12.5, Avg60: 8.2, Avg300: 3.1}},
+			Memory: system.Pressure{
+				Some: system.PressureStats{Avg10: 27.6, Avg60: 7.9, Avg300: 2.1},
+				Full: system.PressureStats{Avg10: 15.4, Avg60: 4.3, Avg300: 1.1},
+			},
+			IO:            system.Pressure{Some: system.PressureStats{Avg10: 1.3, Avg60: 1.45, Avg300: 1.21}},
+			CPUHistory:    Series(4.5, 8, 12.5),
+			MemoryHistory: Series(2.1, 14.8, 27.6),
+			IOHistory:     Series(0.9, 1.1, 1.3),
+		},
+		Alerts: []system.Alert{
+			{ID: "8d2c41f0b7a3e915", State: system.AlertFiring, Timestamp: Time.Add(-5 * time.Minute), Message: "CPU usage is high (61.5%)",
+				Level: system.CriticalLevel, Source: "cpu_usage", FirstSeen: Time.Add(-5 * time.Minute), LastSeen: Time, Count: 60, Value: 61.5, Peak: 97},
+			{ID: "1b6e90c4d25fa387", State: system.AlertFiring, Timestamp: Time.Add(-2 * time.Hour), Message: "Disk usage on /var is high (95.0%)",
+				Level: system.WarningLevel, Source: "disk_usage_/var", FirstSeen: Time.Add(-2 * time.Hour), LastSeen: Time, Count: 1440, Value: 95, Peak: 95},
+			{ID: "f47a0d3e6c18b952", State: system.AlertResolved, Timestamp: Time.Add(-3 * time.Hour), Message: "Memory usage is high (91.2%)",
+				Level: system.CriticalLevel, Source: "memory_usage", Resolved: true, FirstSeen: Time.Add(-3 * time.Hour), LastSeen: Time.Add(-150 * time.Minute),
+				ResolvedAt: Time.Add(-150 * time.Minute), Count: 360, Value: 93, Peak: 96.4},
+		},
+		MaxProcesses: 15,
+		Report:       system.CollectReport{TimedOut: []string{"network"}},
+	}
+}
+
+// Series returns a time series of values one second apart ending at Time
+func Series(values ...float64) system.TimeSeries {
+	var ts system.TimeSeries
+	for i, v := range values {
+		ts.Points = append(ts.Points, system.TimeSeriesPoint{
+			Timestamp: Time.Add(time.Duration(i-len(values)+1) * time.Second),
+			Value:     v,
+		})
+	}
+	return ts
+}
+
+// AssertGolden compares got with the golden file at path, or rewrites the
+// file when the tests run with -update
+func AssertGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v (run with -update to create it)", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match golden file\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
@@ -1,27 +1,63 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/alertlog"
+	"go_system_monitor/batch"
 	"go_system_monitor/config"
+	"go_system_monitor/exporter"
+	"go_system_monitor/notify"
+	"go_system_monitor/record"
+	"go_system_monitor/schema"
 	"go_system_monitor/system"
 	"go_system_monitor/ui"
 )
 
 // Define message types
 type tickMsg time.Time
+type replayTickMsg time.Time
 type errMsg error
 
+// signalResultMsg reports the outcome of sending a signal to a process
+type signalResultMsg struct {
+	err error
+}
+
+// tuneResultMsg reports the outcome of a renice or affinity change
+type tuneResultMsg struct {
+	err error
+}
+
+// inspectMsg delivers the details read for the process in the detail view
+type inspectMsg *system.ProcessInspection
+
+// metricsMsg delivers the snapshot produced by a collection cycle
+type metricsMsg *system.Snapshot
+
 // MonitorModel is our application model
 type MonitorModel struct {
 	dashboard ui.Dashboard
 	metrics   *system.Collector
+	snapshot  *system.Snapshot // latest collected state; the UI only reads this
+	sinks     snapshotSinks    // recorder and exporter fed with every snapshot
+	replay    *record.Player   // drives the UI from a recording instead of metrics
 	width     int
 	height    int
 	err       error
@@ -34,9 +70,61 @@ const (
 	AppVersion = "1.0.0"
 )
 
-// initialModel creates the starting state of our application
-func initialModel(cfg config.AppConfig) MonitorModel {
-	// Create metrics collector with configured values
+// snapshotSinks receive every collected snapshot in addition to the UI
+type snapshotSinks struct {
+	recorder *record.Recorder    // appends snapshots to a session file
+	exporter *exporter.Exporter // serves the latest snapshot to Prometheus
+	notifier *notify.Dispatcher // sends alerts that fire or resolve
+	history  *alertlog.Store    // keeps the alerts across restarts
+}
+
+// watch subscribes the notifier and the alert history to the alerts of a
+// collector
+func (s snapshotSinks) watch(metrics *system.Collector) {
+	if s.notifier != nil {
+		metrics.AlertManager.Subscribe(s.notifier.Send)
+	}
+	if s.history != nil {
+		failed := false
+		metrics.AlertManager.Subscribe(func(ev system.AlertEvent) {
+			if err := s.history.Record(ev); err != nil && !failed {
+				// Report the first failure only; the disk may be full
+				failed = true
+				metrics.AlertManager.RecordEvent(fmt.Sprintf("Could not save alert history: %v", err), system.WarningLevel, "alert_history")
+			}
+		})
+	}
+}
+
+// close flushes the recording, the pending notifications and the alert
+// history
+func (s snapshotSinks) close() {
+	if s.recorder != nil {
+		s.recorder.Close()
+	}
+	if s.notifier != nil {
+		s.notifier.Close()
+	}
+	if s.history != nil {
+		s.history.Close(time.Now())
+	}
+}
+
+// publish hands a snapshot to the configured sinks
+func (s snapshotSinks) publish(snapshot *system.Snapshot) error {
+	if s.exporter != nil {
+		s.exporter.Update(snapshot)
+	}
+	if s.recorder != nil {
+		if err := s.recorder.Write(snapshot); err != nil {
+			return fmt.Errorf("recording snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// newCollector creates a metrics collector with the configured values
+func newCollector(cfg config.AppConfig) *system.Collector {
 	metrics := system.NewCollector(
 		cfg.CPUThreshold,
 		cfg.MemoryThreshold,
@@ -47,26 +135,250 @@ func initialModel(cfg config.AppConfig) MonitorModel {
 		cfg.MaxProcesses,
 		cfg.MaxAlertsToKeep,
 	)
+	for name, ms := range cfg.SourceTimeouts {
+		if metrics.SourceTimeouts == nil {
+			metrics.SourceTimeouts = make(map[string]time.Duration)
+		}
+		metrics.SourceTimeouts[name] = time.Duration(ms) * time.Millisecond
+	}
+	if err := metrics.AlertManager.SetRules(alertRules(cfg)); err != nil {
+		log.Printf("Warning: ignoring invalid alert rules: %v", err)
+	}
+	metrics.AlertManager.SetMaintenanceWindows(maintenanceWindows(cfg.MaintenanceWindows))
+	metrics.Root = cfg.HostRoot
+	return metrics
+}
+
+// alertRules returns the built-in alert rules for the configured thresholds
+// merged with the configured rules
+func alertRules(cfg config.AppConfig) []system.AlertRule {
+	defaults := system.DefaultAlertRules(system.AlertThresholds{
+		CPU:            cfg.CPUThreshold,
+		Memory:         cfg.MemoryThreshold,
+		Disk:           cfg.DiskThreshold,
+		Swap:           cfg.SwapThreshold,
+		CPUPressure:    cfg.CPUPressureThreshold,
+		MemoryPressure: cfg.MemoryPressureThreshold,
+		IOPressure:     cfg.IOPressureThreshold,
+	})
+	rules := make([]system.AlertRule, 0, len(cfg.AlertRules))
+	for _, r := range cfg.AlertRules {
+		var duration time.Duration
+		if r.For != "" {
+			d, err := time.ParseDuration(r.For)
+			if err != nil {
+				log.Printf("Warning: alert rule %s: invalid duration %q. The rule is ignored.", r.Name, r.For)
+				continue
+			}
+			duration = d
+		}
+		rules = append(rules, system.AlertRule{
+			Name:       r.Name,
+			Metric:     r.Metric,
+			Op:         r.Op,
+			Threshold:  r.Threshold,
+			For:        duration,
+			Level:      system.AlertLevel(r.Severity),
+			Hysteresis: r.Hysteresis,
+			Message:    r.Message,
+			Disabled:   r.Disabled,
+		})
+	}
+	return system.MergeAlertRules(defaults, rules)
+}
+
+// weekdays maps the day names of maintenance windows to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// maintenanceWindows converts the configured maintenance windows, skipping
+// those that do not parse
+func maintenanceWindows(configs []config.MaintenanceWindowConfig) []system.MaintenanceWindow {
+	var windows []system.MaintenanceWindow
+	for _, c := range configs {
+		start, err := time.Parse("15:04", c.Start)
+		if err != nil {
+			log.Printf("Warning: maintenance window %s: invalid start %q. The window is ignored.", c.Name, c.Start)
+			continue
+		}
+		duration, err := time.ParseDuration(c.Duration)
+		if err != nil || duration <= 0 {
+			log.Printf("Warning: maintenance window %s: invalid duration %q. The window is ignored.", c.Name, c.Duration)
+			continue
+		}
+		w := system.MaintenanceWindow{
+			Name:     c.Name,
+			Start:    time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute,
+			Duration: duration,
+			Alerts:   c.Alerts,
+		}
+		valid := true
+		for _, name := range c.Days {
+			day, ok := weekdays[strings.ToLower(name)]
+			if !ok {
+				log.Printf("Warning: maintenance window %s: invalid day %q. The window is ignored.", c.Name, name)
+				valid = false
+				break
+			}
+			w.Days = append(w.Days, day)
+		}
+		if valid {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// newNotifier creates a dispatcher for the configured notification
+// destinations, or returns nil when there are none
+func newNotifier(cfg *config.NotificationConfig) *notify.Dispatcher {
+	if cfg == nil {
+		return nil
+	}
+	var notifiers []notify.Notifier
+	for _, w := range cfg.Webhooks {
+		notifiers = append(notifiers, &notify.Webhook{URL: w.URL, Headers: w.Headers})
+	}
+	for _, c := range cfg.Commands {
+		notifiers = append(notifiers, &notify.Command{Path: c.Command, Args: c.Args})
+	}
+	if cfg.Syslog != nil {
+		notifiers = append(notifiers, &notify.Syslog{Tag: cfg.Syslog.Tag, Network: cfg.Syslog.Network, Address: cfg.Syslog.Address})
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+
+	opts := notify.Options{Retries: cfg.Retries}
+	opts.Host, _ = os.Hostname()
+	if cfg.RateLimit != "" {
+		d, err := time.ParseDuration(cfg.RateLimit)
+		if err != nil {
+			log.Printf("Warning: invalid notification rate limit %q. Using %s.", cfg.RateLimit, notify.DefaultRateLimit)
+		}
+		opts.RateLimit = d
+	}
+	return notify.NewDispatcher(opts, notifiers...)
+}
+
+// alertHistoryPath returns the configured alert history file
+func alertHistoryPath(cfg config.AppConfig) (string, error) {
+	if cfg.AlertHistoryFile != "" {
+		return cfg.AlertHistoryFile, nil
+	}
+	return alertlog.DefaultPath()
+}
+
+// openAlertHistory opens the alert history, or returns nil when it is
+// turned off or cannot be opened
+func openAlertHistory(cfg config.AppConfig) *alertlog.Store {
+	if cfg.AlertHistoryDays < 0 {
+		return nil
+	}
+	path, err := alertHistoryPath(cfg)
+	if err == nil {
+		retention := time.Duration(cfg.AlertHistoryDays) * 24 * time.Hour
+		var store *alertlog.Store
+		if store, err = alertlog.Open(path, retention, time.Now()); err == nil {
+			return store
+		}
+	}
+	log.Printf("Warning: alert history is not kept: %v", err)
+	return nil
+}
+
+// printAlertHistory prints the alerts of the history in a time range
+func printAlertHistory(cfg config.AppConfig, format, timeRange string) error {
+	now := time.Now()
+	from, to, err := alertlog.ParseRange(timeRange, now)
+	if err != nil {
+		return err
+	}
+	path, err := alertHistoryPath(cfg)
+	if err != nil {
+		return err
+	}
+	store, err := alertlog.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no alert history at %s", path)
+	} else if err != nil {
+		return err
+	}
+	return alertlog.Write(os.Stdout, format, store.Query(from, to), now)
+}
+
+// newDashboard creates the dashboard with the configured saved filters and
+// process columns
+func newDashboard(cfg config.AppConfig) ui.Dashboard {
+	dashboard := ui.NewDashboard()
+	saved := make([]ui.SavedFilter, 0, len(cfg.SavedFilters))
+	for _, f := range cfg.SavedFilters {
+		saved = append(saved, ui.SavedFilter{Name: f.Name, Query: f.Query})
+	}
+	dashboard.FilterBar().SetSaved(saved)
+	columns := make([]ui.ColumnSpec, 0, len(cfg.ProcessColumns))
+	for _, c := range cfg.ProcessColumns {
+		columns = append(columns, ui.ColumnSpec{ID: c.ID, Width: c.Width})
+	}
+	if err := dashboard.SetProcessColumns(columns); err != nil {
+		log.Printf("Warning: %v. Using the default process columns.", err)
+	}
+	return dashboard
+}
+
+// initialModel creates the starting state of our application. Failed
+// notifications are shown in the alert log since the TUI owns the terminal.
+func initialModel(cfg config.AppConfig, sinks snapshotSinks) MonitorModel {
+	metrics := newCollector(cfg)
+	sinks.watch(metrics)
+	if sinks.notifier != nil {
+		sinks.notifier.SetWarnf(func(format string, args ...any) {
+			metrics.AlertManager.RecordEvent(fmt.Sprintf(format, args...), system.WarningLevel, "notifications")
+		})
+	}
+	dashboard := newDashboard(cfg)
+	dashboard.AlertHistory().SetAvailable(sinks.history != nil)
 	
 	// Initial metrics collection
-	if err := metrics.Collect(); err != nil {
+	snapshot, err := metrics.Collect()
+	if err != nil {
 		return MonitorModel{
-			dashboard: ui.NewDashboard(),
+			dashboard: dashboard,
 			metrics:   metrics,
+			sinks:     sinks,
 			err:       err,
 			config:    cfg,
 		}
 	}
 	
 	return MonitorModel{
-		dashboard: ui.NewDashboard(),
+		dashboard: dashboard,
 		metrics:   metrics,
+		snapshot:  snapshot,
+		sinks:     sinks,
 		config:    cfg,
 	}
 }
 
+// replayModel creates an application state that plays back a recording
+func replayModel(cfg config.AppConfig, player *record.Player) MonitorModel {
+	m := MonitorModel{
+		dashboard: newDashboard(cfg),
+		replay:    player,
+		config:    cfg,
+	}
+	m.dashboard.SetClock(player.Time)
+	m.loadReplayFrame()
+	return m
+}
+
 // Init initializes the application
 func (m MonitorModel) Init() tea.Cmd {
+	if m.replay != nil {
+		return tea.Batch(replayTick(), tea.EnterAltScreen)
+	}
 	return tea.Batch(
 		tick(),           // Start the timer
 		tea.EnterAltScreen, // Use alternate screen buffer
@@ -85,12 +397,40 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 	// Handle key presses
 	case tea.KeyMsg:
+		// The signal dialog takes all keys while it is open
+		if dialog := m.dashboard.SignalDialog(); dialog.Active() {
+			return m, m.updateSignalDialog(msg)
+		}
+		if dialog := m.dashboard.TuneDialog(); dialog.Active() {
+			return m, m.updateTuneDialog(msg)
+		}
+		if detail := m.dashboard.ProcessDetail(); detail.Active() {
+			return m, m.updateProcessDetail(msg)
+		}
+		if bar := m.dashboard.FilterBar(); bar.Active() {
+			return m, m.updateFilterBar(msg)
+		}
+		if picker := m.dashboard.ColumnPicker(); picker.Active() {
+			return m, m.updateColumnPicker(msg)
+		}
+		if bar := m.dashboard.AlertHistory().RangeBar(); bar.Active() {
+			return m, m.updateRangeBar(msg)
+		}
+		if bar := m.dashboard.AlertList().SilenceBar(); bar.Active() {
+			return m, m.updateSilenceBar(msg)
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
 		
 		case "esc":
+			// Clear the process filter before quitting
+			if m.dashboard.ActiveTab() == 5 && m.dashboard.ProcessFilter() != "" {
+				m.dashboard.SetProcessFilter("")
+				return m, nil
+			}
 			m.quitting = true
 			return m, tea.Quit
 			
@@ -104,6 +444,9 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			
 		case "r":
 			// Force refresh metrics
+			if m.replay != nil {
+				return m, nil
+			}
 			return m, collectMetricsCmd(m.metrics)
 			
 		case "c":
@@ -129,26 +472,37 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Process sorting options (only apply when on the Processes tab)
 		case "1":
 			if m.dashboard.ActiveTab() == 5 { // Processes tab index
-				m.metrics.Process.SortBy = system.SortByCPU
-				return m, collectMetricsCmd(m.metrics)
+				return m, m.setSortBy(system.SortByCPU)
 			}
 			
 		case "2":
 			if m.dashboard.ActiveTab() == 5 { // Processes tab index
-				m.metrics.Process.SortBy = system.SortByMemory
-				return m, collectMetricsCmd(m.metrics)
+				return m, m.setSortBy(system.SortByMemory)
 			}
 			
 		case "3":
 			if m.dashboard.ActiveTab() == 5 { // Processes tab index
-				m.metrics.Process.SortBy = system.SortByPID
-				return m, collectMetricsCmd(m.metrics)
+				return m, m.setSortBy(system.SortByPID)
 			}
 			
 		case "4":
 			if m.dashboard.ActiveTab() == 5 { // Processes tab index
-				m.metrics.Process.SortBy = system.SortByName
-				return m, collectMetricsCmd(m.metrics)
+				return m, m.setSortBy(system.SortByName)
+			}
+			
+		case "5":
+			if m.dashboard.ActiveTab() == 5 { // Processes tab index
+				return m, m.setSortBy(system.SortByCPULifetime)
+			}
+		
+		case "<", ">":
+			// Sort by the column left or right of the sorted one
+			if m.dashboard.ActiveTab() == 5 {
+				dir := 1
+				if msg.String() == "<" {
+					dir = -1
+				}
+				return m, m.setSortBy(m.dashboard.ProcessSortColumn(dir))
 			}
 		
 		// Process table scrolling (only when on Processes tab)
@@ -157,10 +511,34 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.dashboard.ScrollProcessUp()
 				return m, nil
 			}
+			if m.dashboard.ActiveTab() == 6 { // Alerts tab index
+				if m.dashboard.AlertHistory().Active() {
+					m.dashboard.AlertHistory().ScrollUp()
+				} else {
+					m.dashboard.AlertList().ScrollUp()
+				}
+				return m, nil
+			}
+			if m.dashboard.ActiveTab() == 7 { // Cgroups tab index
+				m.dashboard.CgroupView().ScrollUp()
+				return m, nil
+			}
 		
 		case "down", "j":
 			if m.dashboard.ActiveTab() == 5 {
-				m.dashboard.ScrollProcessDown(len(m.metrics.Process.Processes))
+				m.dashboard.ScrollProcessDown(m.processCount())
+				return m, nil
+			}
+			if m.dashboard.ActiveTab() == 6 {
+				if m.dashboard.AlertHistory().Active() {
+					m.dashboard.AlertHistory().ScrollDown()
+				} else {
+					m.dashboard.AlertList().ScrollDown()
+				}
+				return m, nil
+			}
+			if m.dashboard.ActiveTab() == 7 {
+				m.dashboard.CgroupView().ScrollDown()
 				return m, nil
 			}
 		
@@ -169,10 +547,18 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.dashboard.PageUpProcess()
 				return m, nil
 			}
+			if m.dashboard.ActiveTab() == 7 {
+				m.dashboard.CgroupView().PageUp()
+				return m, nil
+			}
 		
 		case "pagedown", "ctrl+d":
 			if m.dashboard.ActiveTab() == 5 {
-				m.dashboard.PageDownProcess(len(m.metrics.Process.Processes))
+				m.dashboard.PageDownProcess(m.processCount())
+				return m, nil
+			}
+			if m.dashboard.ActiveTab() == 7 {
+				m.dashboard.CgroupView().PageDown()
 				return m, nil
 			}
 		
@@ -184,11 +570,197 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 		case "end", "G":
 			if m.dashboard.ActiveTab() == 5 {
-				m.dashboard.EndProcess(len(m.metrics.Process.Processes))
+				m.dashboard.EndProcess(m.processCount())
+				return m, nil
+			}
+
+		case "t":
+			// Switch between the flat process list and the process tree
+			if m.dashboard.ActiveTab() == 5 {
+				m.dashboard.ToggleProcessTree()
+				return m, nil
+			}
+
+		case "b":
+			// Group processes by user, name or cgroup
+			if m.dashboard.ActiveTab() == 5 {
+				m.dashboard.CycleProcessGrouping()
+				return m, nil
+			}
+
+		case "e":
+			if m.dashboard.ActiveTab() == 5 {
+				m.dashboard.ToggleProcessCollapsed()
+				return m, nil
+			}
+
+		case "E":
+			if m.dashboard.ActiveTab() == 5 {
+				m.dashboard.ExpandAllProcesses()
+				return m, nil
+			}
+
+		case "enter":
+			// Show the details of the selected process, or the members of
+			// the selected group
+			if m.dashboard.ActiveTab() == 5 {
+				if proc, ok := m.dashboard.SelectedProcess(); ok {
+					detail := m.dashboard.ProcessDetail()
+					detail.Open(proc)
+					m.refreshProcessDetail()
+					if m.replay != nil {
+						detail.SetNote("Live details are not available while replaying a recording")
+						return m, nil
+					}
+					return m, inspectCmd(m.metrics, proc.PID)
+				}
+				// Drill into the selected group
+				m.dashboard.ToggleProcessCollapsed()
+				return m, nil
+			}
+			if m.dashboard.ActiveTab() == 7 {
+				// List the processes of the selected cgroup
+				m.dashboard.ShowCgroupProcesses()
+				return m, nil
+			}
+
+		case "/":
+			// Edit the process filter
+			if m.dashboard.ActiveTab() == 5 {
+				m.dashboard.FilterBar().Open(m.dashboard.ProcessFilter())
+				return m, nil
+			}
+			// Type the time range of the alert history
+			if history := m.dashboard.AlertHistory(); m.dashboard.ActiveTab() == 6 && history.Active() {
+				history.RangeBar().Open(history.Range())
+				return m, nil
+			}
+
+		case "H":
+			// Switch the Alerts tab between the current alerts and the history
+			if m.dashboard.ActiveTab() == 6 {
+				m.dashboard.AlertHistory().Toggle()
+				m.refreshAlertHistory()
+				return m, nil
+			}
+
+		case "[", "]":
+			// Step through the time ranges of the alert history
+			if history := m.dashboard.AlertHistory(); m.dashboard.ActiveTab() == 6 && history.Active() {
+				if msg.String() == "[" {
+					history.PrevRange()
+				} else {
+					history.NextRange()
+				}
+				m.refreshAlertHistory()
+				return m, nil
+			}
+
+		case "o":
+			// Choose the process table columns
+			if m.dashboard.ActiveTab() == 5 {
+				m.dashboard.ColumnPicker().Open(m.dashboard.ProcessColumns())
+				return m, nil
+			}
+
+		case "x":
+			// Open the signal picker for the selected process
+			if m.processActionsEnabled() {
+				if proc, ok := m.dashboard.SelectedProcess(); ok {
+					m.dashboard.SignalDialog().Open(proc)
+				}
+				return m, nil
+			}
+			// Export the alert history shown
+			if m.dashboard.ActiveTab() == 6 && m.dashboard.AlertHistory().Active() {
+				m.exportAlertHistory()
+				return m, nil
+			}
+
+		case "n":
+			// Renice the selected process
+			if m.processActionsEnabled() {
+				if proc, ok := m.dashboard.SelectedProcess(); ok {
+					m.dashboard.TuneDialog().Open(ui.TuneNice, proc, strconv.Itoa(int(proc.Nice)))
+				}
+				return m, nil
+			}
+
+		case "a":
+			// Edit the CPU affinity of the selected process
+			if m.processActionsEnabled() {
+				if proc, ok := m.dashboard.SelectedProcess(); ok {
+					dialog := m.dashboard.TuneDialog()
+					cpus, err := system.Affinity(proc.PID)
+					dialog.Open(ui.TuneAffinity, proc, system.FormatCPUList(cpus))
+					if err != nil {
+						dialog.SetResult(err)
+					}
+				}
+				return m, nil
+			}
+			// Acknowledge the selected alert
+			if m.alertActionsEnabled() {
+				if alert, ok := m.dashboard.AlertList().Selected(); ok {
+					if m.metrics.AlertManager.Acknowledge(alert.Source) {
+						m.dashboard.AlertList().SetNote("Acknowledged " + alert.Source)
+					} else {
+						m.dashboard.AlertList().SetNote(alert.Source + " is no longer firing")
+					}
+					return m, collectMetricsCmd(m.metrics)
+				}
+				return m, nil
+			}
+
+		case "m":
+			// Silence the source of the selected alert for a while
+			if m.alertActionsEnabled() {
+				if alert, ok := m.dashboard.AlertList().Selected(); ok {
+					m.dashboard.AlertList().OpenSilence(alert.Source)
+				}
+				return m, nil
+			}
+
+		case "M":
+			// Lift the silence of the selected alert's source
+			if m.alertActionsEnabled() {
+				if alert, ok := m.dashboard.AlertList().Selected(); ok {
+					if _, silenced := m.metrics.AlertManager.Silenced(alert.Source); silenced {
+						m.metrics.AlertManager.Unsilence(alert.Source)
+						m.dashboard.AlertList().SetNote("Unsilenced " + alert.Source)
+					} else {
+						m.dashboard.AlertList().SetNote(alert.Source + " is not silenced")
+					}
+					return m, collectMetricsCmd(m.metrics)
+				}
 				return m, nil
 			}
 		}
 
+		// Playback controls when replaying a recording
+		if m.replay != nil {
+			switch msg.String() {
+			case " ":
+				m.replay.TogglePause()
+			case "[":
+				m.replay.Seek(-10 * time.Second)
+			case "]":
+				m.replay.Seek(10 * time.Second)
+			case "{":
+				m.replay.Seek(-time.Minute)
+			case "}":
+				m.replay.Seek(time.Minute)
+			case "+", "=":
+				m.replay.Faster()
+			case "-":
+				m.replay.Slower()
+			default:
+				return m, nil
+			}
+			m.loadReplayFrame()
+			return m, nil
+		}
+
 	// Handle mouse events
 	case tea.MouseMsg:
 		switch msg.Type {
@@ -198,8 +770,8 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if msg.Y == 1 { // Assuming tabs are on line 1
 				tabWidth := 20
 				clickedTab := msg.X / tabWidth
-				// Navigate to clicked tab (tabs: Overview=0, CPU=1, Memory=2, Disk=3, Network=4, Processes=5, Alerts=6)
-				if clickedTab >= 0 && clickedTab < 7 {
+				// Navigate to clicked tab (tabs: Overview=0, CPU=1, Memory=2, Disk=3, Network=4, Processes=5, Alerts=6, Cgroups=7)
+				if clickedTab >= 0 && clickedTab < 8 {
 					// Use the existing NextTab/PrevTab methods to navigate
 					current := m.dashboard.ActiveTab()
 					if clickedTab > current {
@@ -223,7 +795,7 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.MouseWheelDown:
 			// Scroll down in process table
 			if m.dashboard.ActiveTab() == 5 {
-				m.dashboard.ScrollProcessDown(len(m.metrics.Process.Processes))
+				m.dashboard.ScrollProcessDown(m.processCount())
 				return m, nil
 			}
 		}
@@ -235,6 +807,60 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			collectMetricsCmd(m.metrics), // Collect metrics
 		)
 		
+	// Show the outcome of a signal and log it as an event
+	case signalResultMsg:
+		dialog := m.dashboard.SignalDialog()
+		dialog.SetResult(msg.err)
+		target := dialog.Target()
+		if msg.err != nil {
+			m.metrics.AlertManager.RecordEvent(
+				fmt.Sprintf("Failed to send SIG%s to %d (%s): %v", dialog.Signal(), target.PID, target.Name, msg.err),
+				system.WarningLevel, system.SourceSignal)
+		} else {
+			m.metrics.AlertManager.RecordEvent(
+				fmt.Sprintf("Sent SIG%s to %d (%s)", dialog.Signal(), target.PID, target.Name),
+				system.InfoLevel, system.SourceSignal)
+		}
+		return m, collectMetricsCmd(m.metrics)
+
+	// Show the outcome of a renice or affinity change and log it as an event
+	case tuneResultMsg:
+		dialog := m.dashboard.TuneDialog()
+		dialog.SetResult(msg.err)
+		source := system.SourceRenice
+		if dialog.Kind() == ui.TuneAffinity {
+			source = system.SourceAffinity
+		}
+		if msg.err != nil {
+			m.metrics.AlertManager.RecordEvent(
+				fmt.Sprintf("Failed: %s: %v", dialog.Summary(), msg.err),
+				system.WarningLevel, source)
+		} else {
+			m.metrics.AlertManager.RecordEvent(dialog.Summary(), system.InfoLevel, source)
+		}
+		return m, collectMetricsCmd(m.metrics)
+
+	// Move playback forward
+	case replayTickMsg:
+		m.replay.Advance(replayTickInterval)
+		m.loadReplayFrame()
+		return m, replayTick()
+
+	// Show the details read for a process
+	case inspectMsg:
+		m.dashboard.ProcessDetail().SetInspection(msg)
+		return m, nil
+
+	// Store the latest collected snapshot
+	case metricsMsg:
+		m.snapshot = msg
+		m.refreshProcessDetail()
+		m.refreshAlertHistory()
+		if err := m.sinks.publish(msg); err != nil {
+			m.err = err
+		}
+		return m, nil
+
 	// Handle errors
 	case errMsg:
 		m.err = msg
@@ -245,6 +871,397 @@ func (m MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateSignalDialog handles a key press while the signal dialog is open
+func (m *MonitorModel) updateSignalDialog(msg tea.KeyMsg) tea.Cmd {
+	dialog := m.dashboard.SignalDialog()
+	key := msg.String()
+
+	switch {
+	case dialog.Picking():
+		switch key {
+		case "up", "k":
+			dialog.Up()
+		case "down", "j":
+			dialog.Down()
+		case "enter":
+			dialog.Choose()
+		case "esc", "q":
+			dialog.Close()
+		case "ctrl+c":
+			m.quitting = true
+			return tea.Quit
+		default:
+			if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+				dialog.ChooseIndex(int(key[0] - '1'))
+			}
+		}
+
+	case dialog.Confirming():
+		switch key {
+		case "y", "Y":
+			return sendSignalCmd(dialog.Target().Key(), dialog.Signal())
+		case "n", "N", "esc":
+			dialog.Back()
+		case "ctrl+c":
+			m.quitting = true
+			return tea.Quit
+		}
+
+	case dialog.Done():
+		dialog.Close()
+	}
+	return nil
+}
+
+// updateProcessDetail handles a key press while the process detail view is
+// open
+func (m *MonitorModel) updateProcessDetail(msg tea.KeyMsg) tea.Cmd {
+	detail := m.dashboard.ProcessDetail()
+	switch msg.String() {
+	case "esc", "enter", "q":
+		detail.Close()
+	case "ctrl+c":
+		m.quitting = true
+		return tea.Quit
+	case "up", "k":
+		detail.ScrollUp(1)
+	case "down", "j":
+		detail.ScrollDown(1)
+	case "pageup", "ctrl+u":
+		detail.ScrollUp(detail.PageSize())
+	case "pagedown", "ctrl+d":
+		detail.ScrollDown(detail.PageSize())
+	case "home", "g":
+		detail.Home()
+	case "r":
+		if m.replay == nil {
+			return inspectCmd(m.metrics, detail.Process().PID)
+		}
+	}
+	return nil
+}
+
+// refreshProcessDetail updates the collected fields and history shown in
+// the process detail view from the latest snapshot
+func (m *MonitorModel) refreshProcessDetail() {
+	detail := m.dashboard.ProcessDetail()
+	if !detail.Active() || m.snapshot == nil {
+		return
+	}
+	for _, proc := range m.snapshot.Process.Processes {
+		if proc.PID == detail.Process().PID {
+			detail.SetProcess(proc)
+			if history, ok := m.snapshot.Process.HistoryOf(proc); ok {
+				detail.SetHistory(history)
+			}
+			return
+		}
+	}
+}
+
+// updateTuneDialog handles a key press while the renice or affinity
+// dialog is open
+func (m *MonitorModel) updateTuneDialog(msg tea.KeyMsg) tea.Cmd {
+	dialog := m.dashboard.TuneDialog()
+	if dialog.Done() {
+		dialog.Close()
+		return nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		dialog.Close()
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return tea.Quit
+	case tea.KeyTab:
+		dialog.ToggleAllThreads()
+	case tea.KeyBackspace:
+		dialog.Backspace()
+	case tea.KeyRunes:
+		dialog.Insert(string(msg.Runes))
+	case tea.KeyEnter:
+		return applyTune(dialog)
+	}
+	return nil
+}
+
+// updateFilterBar handles keys while the filter bar is open. The table
+// follows the query as it is typed; a query that does not parse keeps the
+// last valid filter and shows why.
+func (m *MonitorModel) updateFilterBar(msg tea.KeyMsg) tea.Cmd {
+	bar := m.dashboard.FilterBar()
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.dashboard.SetProcessFilter(bar.Previous())
+		bar.Close()
+		return nil
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return tea.Quit
+	case tea.KeyEnter:
+		if bar.Valid() {
+			bar.Close()
+		}
+		return nil
+	case tea.KeyCtrlU:
+		bar.Clear()
+	case tea.KeyBackspace:
+		bar.Backspace()
+	case tea.KeySpace:
+		bar.Insert(" ")
+	case tea.KeyRunes:
+		bar.Insert(string(msg.Runes))
+	case tea.KeyUp:
+		if !bar.PrevSaved() {
+			return nil
+		}
+	case tea.KeyDown:
+		if !bar.NextSaved() {
+			return nil
+		}
+	default:
+		return nil
+	}
+	bar.SetError(m.dashboard.SetProcessFilter(bar.Value()))
+	return nil
+}
+
+// updateRangeBar handles keys while the alert history range is typed. The
+// range applies on Enter once it parses.
+func (m *MonitorModel) updateRangeBar(msg tea.KeyMsg) tea.Cmd {
+	history := m.dashboard.AlertHistory()
+	bar := history.RangeBar()
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		bar.Close()
+		return nil
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return tea.Quit
+	case tea.KeyEnter:
+		if bar.Valid() {
+			bar.Close()
+			history.SetCustomRange(bar.Value())
+			m.refreshAlertHistory()
+		}
+		return nil
+	case tea.KeyCtrlU:
+		bar.Clear()
+	case tea.KeyBackspace:
+		bar.Backspace()
+	case tea.KeySpace:
+		bar.Insert(" ")
+	case tea.KeyRunes:
+		bar.Insert(string(msg.Runes))
+	default:
+		return nil
+	}
+	_, _, err := alertlog.ParseRange(bar.Value(), time.Now())
+	bar.SetError(err)
+	return nil
+}
+
+// processActionsEnabled reports whether the process list is shown and its
+// processes can be acted on, which needs the live system: the processes of
+// a recording or of a host root given with -root are not the ones that
+// run under their PIDs here
+func (m *MonitorModel) processActionsEnabled() bool {
+	return m.dashboard.ActiveTab() == 5 && m.replay == nil && (m.metrics.Root == "" || m.metrics.Root == "/")
+}
+
+// alertActionsEnabled reports whether the current alerts are shown and can
+// be acknowledged or silenced, which needs live metrics
+func (m *MonitorModel) alertActionsEnabled() bool {
+	return m.dashboard.ActiveTab() == 6 && !m.dashboard.AlertHistory().Active() && m.replay == nil
+}
+
+// updateSilenceBar handles keys while the duration of a silence is typed.
+// The silence applies on Enter once the duration parses.
+func (m *MonitorModel) updateSilenceBar(msg tea.KeyMsg) tea.Cmd {
+	list := m.dashboard.AlertList()
+	bar := list.SilenceBar()
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		bar.Close()
+		return nil
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return tea.Quit
+	case tea.KeyEnter:
+		if d, ok := alertlog.ParseDuration(bar.Value()); ok && d > 0 {
+			bar.Close()
+			now := time.Now()
+			m.metrics.AlertManager.Silence(list.SilenceSource(), now.Add(d))
+			list.SetNote(ui.FormatSilence(list.SilenceSource(), now.Add(d), now))
+			return collectMetricsCmd(m.metrics)
+		}
+		return nil
+	case tea.KeyCtrlU:
+		bar.Clear()
+	case tea.KeyBackspace:
+		bar.Backspace()
+	case tea.KeyRunes:
+		bar.Insert(string(msg.Runes))
+	default:
+		return nil
+	}
+	if d, ok := alertlog.ParseDuration(bar.Value()); !ok || d <= 0 {
+		bar.SetError(fmt.Errorf("invalid duration %q: use e.g. 30m, 2h or 1d", bar.Value()))
+	} else {
+		bar.SetError(nil)
+	}
+	return nil
+}
+
+// refreshAlertHistory queries the alert history for the range shown
+func (m *MonitorModel) refreshAlertHistory() {
+	history := m.dashboard.AlertHistory()
+	if !history.Active() || m.sinks.history == nil {
+		return
+	}
+	now := time.Now()
+	from, to, err := alertlog.ParseRange(history.Range(), now)
+	if err != nil {
+		history.SetNote(err.Error())
+		return
+	}
+	history.SetEntries(m.sinks.history.Query(from, to), now)
+}
+
+// exportAlertHistory writes the alerts shown in the history to a CSV file
+// next to the history file
+func (m *MonitorModel) exportAlertHistory() {
+	history := m.dashboard.AlertHistory()
+	now := time.Now()
+	path := filepath.Join(filepath.Dir(m.sinks.history.Path()), "alerts-"+now.Format("20060102-150405")+".csv")
+	err := func() error {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := alertlog.Write(f, alertlog.FormatCSV, history.Entries(), now); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}()
+	if err != nil {
+		history.SetNote(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	history.SetNote(fmt.Sprintf("Exported %d alerts to %s", len(history.Entries()), path))
+}
+
+// updateColumnPicker handles keys while the column picker is open. Changes
+// apply to the process table straight away.
+func (m *MonitorModel) updateColumnPicker(msg tea.KeyMsg) tea.Cmd {
+	picker := m.dashboard.ColumnPicker()
+
+	switch msg.String() {
+	case "esc", "enter", "o", "q":
+		picker.Close()
+		return nil
+	case "ctrl+c":
+		m.quitting = true
+		return tea.Quit
+	case "up", "k":
+		picker.Up()
+		return nil
+	case "down", "j":
+		picker.Down()
+		return nil
+	case " ":
+		picker.Toggle()
+	case "K", "shift+up":
+		picker.MoveUp()
+	case "J", "shift+down":
+		picker.MoveDown()
+	case "+", "=", "right", "l":
+		picker.Resize(1)
+	case "-", "left", "h":
+		picker.Resize(-1)
+	case "s":
+		if sortBy, ok := picker.SortType(); ok {
+			return m.setSortBy(sortBy)
+		}
+		return nil
+	default:
+		return nil
+	}
+	m.dashboard.SetProcessColumns(picker.Columns())
+	return nil
+}
+
+// applyTune validates the value entered in the tune dialog and returns the
+// command that applies it. Invalid values are reported in the dialog.
+func applyTune(dialog *ui.TuneDialog) tea.Cmd {
+	target := dialog.Target()
+	allThreads := dialog.AllThreads()
+
+	if dialog.Kind() == ui.TuneAffinity {
+		cpus, err := system.ParseCPUList(dialog.Value())
+		if err == nil {
+			err = system.ValidateAffinity(target.PID, cpus)
+		}
+		if err != nil {
+			dialog.SetInvalid(err)
+			return nil
+		}
+		return func() tea.Msg {
+			return tuneResultMsg{err: system.SetAffinity(target.Key(), cpus, allThreads)}
+		}
+	}
+
+	nice, err := strconv.Atoi(dialog.Value())
+	if err != nil {
+		dialog.SetInvalid(fmt.Errorf("%q is not a nice value", dialog.Value()))
+		return nil
+	}
+	if err := system.ValidateRenice(target.PID, int(target.Nice), nice); err != nil {
+		dialog.SetInvalid(err)
+		return nil
+	}
+	return func() tea.Msg {
+		return tuneResultMsg{err: system.Renice(target.Key(), nice, allThreads)}
+	}
+}
+
+// setSortBy changes the process sort order and returns the command that
+// refreshes the process list
+func (m *MonitorModel) setSortBy(sortBy system.SortType) tea.Cmd {
+	if m.replay != nil {
+		m.replay.SetSortBy(sortBy)
+		m.loadReplayFrame()
+		return nil
+	}
+	m.metrics.SetSortBy(sortBy)
+	return collectMetricsCmd(m.metrics)
+}
+
+// loadReplayFrame shows the recorded snapshot at the playback position
+func (m *MonitorModel) loadReplayFrame() {
+	snapshot, err := m.replay.Snapshot()
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.snapshot = snapshot
+	m.refreshProcessDetail()
+	m.dashboard.SetReplayStatus(m.replay.Status())
+}
+
+// processCount returns the number of processes in the latest snapshot
+func (m MonitorModel) processCount() int {
+	if m.snapshot == nil {
+		return 0
+	}
+	return len(m.snapshot.Process.Processes)
+}
+
 // View renders the UI
 func (m MonitorModel) View() string {
 	if m.quitting {
@@ -255,8 +1272,12 @@ func (m MonitorModel) View() string {
 		return fmt.Sprintf("Error: %v\n", m.err)
 	}
 	
+	if m.snapshot == nil {
+		return "Collecting metrics...\n"
+	}
+	
 	// Let the dashboard handle all rendering
-	s := m.dashboard.Render(m.metrics)
+	s := m.dashboard.Render(m.snapshot)
 
 	return s
 }
@@ -268,13 +1289,163 @@ func tick() tea.Cmd {
 	})
 }
 
-// collectMetricsCmd returns a command that collects system metrics
+// sendSignalCmd returns a command that sends a signal to a process
+func sendSignalCmd(key system.ProcessKey, name string) tea.Cmd {
+	return func() tea.Msg {
+		return signalResultMsg{err: system.SendSignal(key, name)}
+	}
+}
+
+// inspectCmd returns a command that reads the details of a process
+func inspectCmd(collector *system.Collector, pid int32) tea.Cmd {
+	return func() tea.Msg {
+		return inspectMsg(collector.InspectProcess(context.Background(), pid))
+	}
+}
+
+// replayTickInterval is how often playback moves forward
+const replayTickInterval = 200 * time.Millisecond
+
+// replayTick returns a command that advances playback after a short delay
+func replayTick() tea.Cmd {
+	return tea.Tick(replayTickInterval, func(t time.Time) tea.Msg {
+		return replayTickMsg(t)
+	})
+}
+
+// collectMetricsCmd returns a command that collects system metrics and
+// delivers the resulting snapshot to the model
 func collectMetricsCmd(collector *system.Collector) tea.Cmd {
 	return func() tea.Msg {
-		if err := collector.Collect(); err != nil {
+		snapshot, err := collector.Collect()
+		if err != nil {
 			return errMsg(err)
 		}
-		return nil
+		return metricsMsg(snapshot)
+	}
+}
+
+// serveMetrics starts serving the exporter on addr in the background
+func serveMetrics(addr string, exp *exporter.Exporter) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := http.Serve(listener, exp.Handler()); err != nil {
+			log.Printf("Warning: metrics server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// runHeadless collects metrics without the TUI until interrupted and hands
+// every snapshot to the sinks
+func runHeadless(cfg config.AppConfig, sinks snapshotSinks) error {
+	metrics := newCollector(cfg)
+	sinks.watch(metrics)
+	interval := metrics.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snapshot, err := metrics.Collect()
+		if err != nil {
+			return err
+		}
+		if err := sinks.publish(snapshot); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runBatch collects without the TUI and hands every cycle to emit. It stops
+// after iterations cycles, or when interrupted if iterations is 0.
+func runBatch(cfg config.AppConfig, sinks snapshotSinks, iterations int, interval time.Duration, emit func(*system.Snapshot) error) error {
+	metrics := newCollector(cfg)
+	sinks.watch(metrics)
+	if interval <= 0 {
+		interval = metrics.Interval
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 0; iterations <= 0 || i < iterations; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+
+		snapshot, err := metrics.Collect()
+		if err != nil {
+			return err
+		}
+		if err := sinks.publish(snapshot); err != nil {
+			return err
+		}
+		if err := emit(snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textOutput prints snapshots as plain-text reports separated by blank lines
+func textOutput() func(*system.Snapshot) error {
+	printer := batch.NewPrinter(os.Stdout)
+	first := true
+	return func(snapshot *system.Snapshot) error {
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		return printer.Print(snapshot)
+	}
+}
+
+// ndjsonOutput writes every snapshot as one line of JSON
+func ndjsonOutput() func(*system.Snapshot) error {
+	enc := json.NewEncoder(os.Stdout)
+	return func(snapshot *system.Snapshot) error {
+		return enc.Encode(schema.FromSnapshot(snapshot))
+	}
+}
+
+// jsonOutput writes the second snapshot as an indented JSON document. The
+// first cycle only primes the CPU, disk and network rates.
+func jsonOutput() func(*system.Snapshot) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	primed := false
+	return func(snapshot *system.Snapshot) error {
+		if !primed {
+			primed = true
+			return nil
+		}
+		return enc.Encode(schema.FromSnapshot(snapshot))
 	}
 }
 
@@ -286,6 +1457,18 @@ func main() {
 	memThreshold := flag.Float64("mem", 0, "Memory usage threshold percentage (0-100)")
 	diskThreshold := flag.Float64("disk", 0, "Disk usage threshold percentage (0-100)")
 	swapThreshold := flag.Float64("swap", 0, "Swap usage threshold percentage (0-100)")
+	hostRoot := flag.String("root", "", "Read host metrics from a recorded directory tree (proc, sys, etc) instead of the live system")
+	recordFile := flag.String("record", "", "Append every collected snapshot to a compressed session file")
+	replayFile := flag.String("replay", "", "Replay a recorded session file instead of collecting live metrics")
+	listenAddr := flag.String("listen", "", "Serve Prometheus metrics on this address, e.g. :9101")
+	headless := flag.Bool("headless", false, "Collect without the terminal UI (use with -listen or -record)")
+	batchMode := flag.Bool("batch", false, "Print each collection cycle as plain text instead of running the terminal UI")
+	iterations := flag.Int("iterations", 0, "Number of cycles to print in batch mode (0 runs until interrupted)")
+	interval := flag.Duration("interval", 0, "Delay between cycles in batch mode (defaults to the refresh interval)")
+	jsonMode := flag.Bool("json", false, "Print a single snapshot as JSON and exit")
+	ndjsonMode := flag.Bool("ndjson", false, "Print one JSON snapshot per line every interval (see -iterations)")
+	alertHistory := flag.String("alert-history", "", "Print the alert history as text, csv or json and exit (see -range)")
+	historyRange := flag.String("range", "", "Time range for -alert-history, e.g. 24h, 7d..1d or 2024-01-02..2024-01-05 (default: all)")
 
 	// Parse the command-line arguments
 	flag.Parse()
@@ -321,6 +1504,116 @@ func main() {
 	if *swapThreshold > 0 {
 		cfg.SwapThreshold = *swapThreshold
 	}
+	if *hostRoot != "" {
+		cfg.HostRoot = *hostRoot
+	}
+	if *listenAddr != "" {
+		cfg.ListenAddress = *listenAddr
+	}
+	if *alertHistory != "" {
+		if err := printAlertHistory(cfg, *alertHistory, *historyRange); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	outputModes := 0
+	for _, set := range []bool{*batchMode, *jsonMode, *ndjsonMode, *headless} {
+		if set {
+			outputModes++
+		}
+	}
+	if outputModes > 1 {
+		fmt.Fprintln(os.Stderr, "Error: only one of -batch, -json, -ndjson and -headless can be used")
+		os.Exit(1)
+	}
+	if *replayFile != "" && (*recordFile != "" || outputModes > 0 || cfg.ListenAddress != "") {
+		fmt.Fprintln(os.Stderr, "Error: -replay cannot be combined with -record, -listen, -headless, -batch, -json or -ndjson")
+		os.Exit(1)
+	}
+	if *headless && *recordFile == "" && cfg.ListenAddress == "" {
+		fmt.Fprintln(os.Stderr, "Error: -headless needs -listen or -record")
+		os.Exit(1)
+	}
+
+	var sinks snapshotSinks
+	if cfg.ListenAddress != "" {
+		sinks.exporter = exporter.New()
+		if err := serveMetrics(cfg.ListenAddress, sinks.exporter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting metrics server: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *recordFile != "" {
+		recorder, err := record.Create(*recordFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening recording: %v\n", err)
+			os.Exit(1)
+		}
+		sinks.recorder = recorder
+	}
+	if *replayFile == "" {
+		sinks.notifier = newNotifier(cfg.Notifications)
+		sinks.history = openAlertHistory(cfg)
+	}
+	defer sinks.close()
+
+	var emit func(*system.Snapshot) error
+	switch {
+	case *jsonMode:
+		emit = jsonOutput()
+		*iterations = 2
+	case *ndjsonMode:
+		emit = ndjsonOutput()
+	case *batchMode:
+		emit = textOutput()
+	}
+	if emit != nil {
+		if err := runBatch(cfg, sinks, *iterations, *interval, emit); err != nil {
+			log.Printf("Error: %v", err)
+			sinks.close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *headless {
+		log.Printf("Go System Monitor running headless")
+		if sinks.exporter != nil {
+			log.Printf("Serving metrics on http://%s/metrics", cfg.ListenAddress)
+		}
+		if err := runHeadless(cfg, sinks); err != nil {
+			log.Printf("Error: %v", err)
+			sinks.close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	var model MonitorModel
+	if *replayFile != "" {
+		recording, err := record.Load(*replayFile)
+		if errors.Is(err, record.ErrTruncated) && recording.Len() > 0 {
+			log.Printf("Warning: %s: %v; replaying the %d complete frames", *replayFile, err, recording.Len())
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading recording: %v\n", err)
+			os.Exit(1)
+		} else if recording.Len() == 0 {
+			fmt.Fprintf(os.Stderr, "Error: %s contains no snapshots\n", *replayFile)
+			os.Exit(1)
+		}
+		model = replayModel(cfg, record.NewPlayer(recording))
+	} else {
+		model = initialModel(cfg, sinks)
+	}
+	if model.snapshot != nil && model.replay == nil {
+		if err := sinks.publish(model.snapshot); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			sinks.close()
+			os.Exit(1)
+		}
+	}
 
 	fmt.Println("Go System Monitor Starting...")
 	
@@ -329,13 +1622,14 @@ func main() {
 	
 	// Run the Bubble Tea program
 	p := tea.NewProgram(
-		initialModel(cfg),
+		model,
 		tea.WithAltScreen(),       // Use the full terminal window
 		tea.WithMouseCellMotion(), // Enable mouse support
 	)
 	
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		sinks.close()
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Command runs a program for every event. The alert is passed in
+// SYSMON_* environment variables and as JSON on standard input; a non-zero
+// exit status is an error.
+type Command struct {
+	Path string
+	Args []string
+}
+
+// Name returns the program path
+func (c *Command) Name() string {
+	return "command " + c.Path
+}
+
+// Notify runs the program and waits for it to exit
+func (c *Command) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Env = append(os.Environ(), commandEnv(ev)...)
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if out = bytes.TrimSpace(out); len(out) > 0 {
+			return fmt.Errorf("%v: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// commandEnv returns the environment variables describing an event
+func commandEnv(ev Event) []string {
+	return []string{
+		"SYSMON_ALERT_ID=" + ev.Alert.ID,
+		"SYSMON_ALERT_STATUS=" + ev.Status,
+		"SYSMON_ALERT_LEVEL=" + ev.Alert.Level,
+		"SYSMON_ALERT_SOURCE=" + ev.Alert.Source,
+		"SYSMON_ALERT_MESSAGE=" + ev.Alert.Message,
+		"SYSMON_ALERT_STARTED=" + ev.Alert.Timestamp.Format(time.RFC3339),
+		"SYSMON_ALERT_TIME=" + ev.Time.Format(time.RFC3339),
+		"SYSMON_HOST=" + ev.Host,
+	}
+}
@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+// Options configure a Dispatcher. Zero values select the defaults; a
+// negative RateLimit or Retries turns rate limiting or retries off.
+type Options struct {
+	Host string // reported in every event
+
+	// RateLimit is the minimum time between two notifications of the same
+	// alert. Changes within the interval are coalesced: when it ends only
+	// the latest state is sent, and nothing if that is the state last sent.
+	RateLimit time.Duration
+	Retries   int           // further attempts after a failed delivery
+	Backoff   time.Duration // delay before the first retry, doubled for each further one
+	Timeout   time.Duration // limit for one delivery attempt
+	QueueSize int           // events waiting per notifier before new ones are dropped
+}
+
+// Default dispatcher options
+const (
+	DefaultRateLimit = time.Minute
+	DefaultRetries   = 3
+	DefaultBackoff   = 2 * time.Second
+	DefaultTimeout   = 10 * time.Second
+	DefaultQueueSize = 64
+)
+
+// Dispatcher passes alert events to notifiers. Each notifier has its own
+// queue and goroutine so a slow destination does not hold up the others
+// or the collection cycle.
+type Dispatcher struct {
+	opts    Options
+	workers []*worker
+	wg      sync.WaitGroup
+	done    chan struct{} // closed when Close stops waiting for retries
+
+	mu     sync.Mutex
+	closed bool
+	limits map[string]*limit // rate limit state by alert source
+	warnf  func(format string, args ...any)
+}
+
+// limit is the rate limit state of one alert
+type limit struct {
+	sent    time.Time
+	status  string // status last sent
+	pending *Event // latest event held back, sent when the interval ends
+	timer   *time.Timer
+}
+
+// worker delivers the events queued for one notifier
+type worker struct {
+	notifier Notifier
+	queue    chan Event
+}
+
+// NewDispatcher starts delivering to notifiers
+func NewDispatcher(opts Options, notifiers ...Notifier) *Dispatcher {
+	if opts.RateLimit == 0 {
+		opts.RateLimit = DefaultRateLimit
+	}
+	if opts.Retries == 0 {
+		opts.Retries = DefaultRetries
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+
+	d := &Dispatcher{
+		opts:   opts,
+		done:   make(chan struct{}),
+		limits: make(map[string]*limit),
+		warnf: func(format string, args ...any) {
+			log.Printf("Warning: "+format, args...)
+		},
+	}
+	for _, n := range notifiers {
+		w := &worker{notifier: n, queue: make(chan Event, opts.QueueSize)}
+		d.workers = append(d.workers, w)
+		d.wg.Add(1)
+		go d.run(w)
+	}
+	return d
+}
+
+// SetWarnf replaces the standard logger as the destination of warnings
+// about failed and dropped notifications
+func (d *Dispatcher) SetWarnf(warnf func(format string, args ...any)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.warnf = warnf
+}
+
+// warn reports a failed or dropped notification
+func (d *Dispatcher) warn(format string, args ...any) {
+	d.mu.Lock()
+	warnf := d.warnf
+	d.mu.Unlock()
+	warnf(format, args...)
+}
+
+// Send notifies about an alert event, subject to the rate limit. Alerts
+// suppressed by a silence or maintenance window are not sent, except when
+// they resolve after firing was notified. It does not block, so it can be
+// subscribed to an AlertManager directly.
+func (d *Dispatcher) Send(ev system.AlertEvent) {
+	if ev.Alert.SuppressedBy != "" && !(ev.Alert.Resolved && d.notified(ev.Alert.Source)) {
+		return
+	}
+	d.send(NewEvent(ev, d.opts.Host))
+}
+
+// notified reports whether firing was the last notification sent or held
+// back for an alert
+func (d *Dispatcher) notified(source string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	l := d.limits[source]
+	return l != nil && (l.status == StatusFiring || l.pending != nil)
+}
+
+// send queues an event or holds it back until the rate limit allows it
+func (d *Dispatcher) send(ev Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+
+	source := ev.Alert.Source
+	l := d.limits[source]
+	if l == nil {
+		l = &limit{}
+		d.limits[source] = l
+	}
+	wait := d.opts.RateLimit - time.Since(l.sent)
+	if wait <= 0 {
+		d.deliver(l, ev)
+		return
+	}
+	l.pending = &ev
+	if l.timer == nil {
+		l.timer = time.AfterFunc(wait, func() { d.release(source) })
+	}
+}
+
+// release sends the event held back for an alert once its interval ends
+func (d *Dispatcher) release(source string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	l := d.limits[source]
+	l.timer = nil
+	d.flush(l)
+}
+
+// flush sends the pending event of an alert unless it repeats the state
+// last sent; the caller must hold d.mu
+func (d *Dispatcher) flush(l *limit) {
+	ev := l.pending
+	l.pending = nil
+	if ev != nil && ev.Status != l.status {
+		d.deliver(l, *ev)
+	}
+}
+
+// deliver queues an event for every notifier; the caller must hold d.mu
+func (d *Dispatcher) deliver(l *limit, ev Event) {
+	l.sent = time.Now()
+	l.status = ev.Status
+	for _, w := range d.workers {
+		select {
+		case w.queue <- ev:
+		default:
+			// d.mu is held, so call warnf directly
+			d.warnf("%s is falling behind; dropped the %s notification for %s", w.notifier.Name(), ev.Status, ev.Alert.Source)
+		}
+	}
+}
+
+// run delivers the queued events of a worker until Close
+func (d *Dispatcher) run(w *worker) {
+	defer d.wg.Done()
+	for ev := range w.queue {
+		d.notify(w.notifier, ev)
+	}
+}
+
+// notify delivers one event, retrying with exponential backoff
+func (d *Dispatcher) notify(n Notifier, ev Event) {
+	backoff := d.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+		err := n.Notify(ctx, ev)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= d.opts.Retries {
+			d.warn("%s: %s notification for %s failed: %v", n.Name(), ev.Status, ev.Alert.Source, err)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-d.done:
+			d.warn("%s: %s notification for %s failed: %v", n.Name(), ev.Status, ev.Alert.Source, err)
+			return
+		}
+	}
+}
+
+// Close sends the events still held back by the rate limit and waits for
+// the queued ones to be delivered. Retries still waiting after the attempt
+// timeout are given up.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	for _, l := range d.limits {
+		if l.timer != nil {
+			l.timer.Stop()
+			l.timer = nil
+		}
+		d.flush(l)
+	}
+	d.closed = true
+	for _, w := range d.workers {
+		close(w.queue)
+	}
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(d.opts.Timeout):
+		close(d.done)
+		<-finished
+	}
+}
@@ -0,0 +1,44 @@
+// Package notify delivers alerts that fire or resolve to external systems:
+// HTTP webhooks, commands and syslog. A Dispatcher rate limits the
+// notifications of each alert and retries failed deliveries.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"go_system_monitor/schema"
+	"go_system_monitor/system"
+)
+
+// Alert states reported in Event.Status
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Event is the notification of an alert that fired or resolved. Webhooks
+// receive it as their JSON body.
+type Event struct {
+	Status string       `json:"status"`
+	Host   string       `json:"host"`
+	Time   time.Time    `json:"time"`
+	Alert  schema.Alert `json:"alert"`
+}
+
+// NewEvent converts an alert event of the collector for host
+func NewEvent(ev system.AlertEvent, host string) Event {
+	status := StatusFiring
+	if ev.Alert.Resolved {
+		status = StatusResolved
+	}
+	return Event{Status: status, Host: host, Time: ev.Time, Alert: schema.FromAlert(ev.Alert)}
+}
+
+// Notifier delivers events to one destination
+type Notifier interface {
+	// Name identifies the notifier in log messages
+	Name() string
+	// Notify delivers one event, giving up when ctx is done
+	Notify(ctx context.Context, ev Event) error
+}
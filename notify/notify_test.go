@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+var fixtureTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// alertEvent returns an event of the collector for source
+func alertEvent(source string, resolved bool) system.AlertEvent {
+	return system.AlertEvent{
+		Alert: system.Alert{ID: "3f9c0e6b1d2a4857", Timestamp: fixtureTime, Message: source + " is high", Level: system.CriticalLevel, Source: source, Resolved: resolved},
+		Time:  fixtureTime.Add(time.Minute),
+	}
+}
+
+// recorder is a notifier that records the events it receives and fails
+// the first failures attempts
+type recorder struct {
+	mu       sync.Mutex
+	events   []string
+	attempts int
+	failures int
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Notify(ctx context.Context, ev Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.attempts <= r.failures {
+		return errors.New("unavailable")
+	}
+	r.events = append(r.events, ev.Alert.Source+" "+ev.Status)
+	return nil
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fmt.Sprint(r.events)
+}
+
+func TestWebhook(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		got      Event
+		auth     string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	hook := &Webhook{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	err := hook.Notify(context.Background(), NewEvent(alertEvent("cpu_usage", false), "db01"))
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "try again") {
+		t.Errorf("first attempt error = %v, want the 503 response", err)
+	}
+
+	d := NewDispatcher(Options{Host: "db01", Backoff: time.Millisecond}, hook)
+	d.Send(alertEvent("cpu_usage", false))
+	d.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := Event{Status: StatusFiring, Host: "db01", Time: fixtureTime.Add(time.Minute)}
+	want.Alert.ID = "3f9c0e6b1d2a4857"
+	want.Alert.Timestamp = fixtureTime
+	want.Alert.Level = "critical"
+	want.Alert.Source = "cpu_usage"
+	want.Alert.Message = "cpu_usage is high"
+	if got != want {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "alert")
+	cmd := &Command{Path: "/bin/sh", Args: []string{"-c", `env | grep ^SYSMON_ | sort > "$0"; cat >> "$0"`, out}}
+	ev := NewEvent(alertEvent("disk_usage{mount=/var}", true), "db01")
+	if err := cmd.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"SYSMON_ALERT_ID=3f9c0e6b1d2a4857\n",
+		"SYSMON_ALERT_LEVEL=critical\n",
+		"SYSMON_ALERT_MESSAGE=disk_usage{mount=/var} is high\n",
+		"SYSMON_ALERT_SOURCE=disk_usage{mount=/var}\n",
+		"SYSMON_ALERT_STARTED=2024-01-02T03:04:05Z\n",
+		"SYSMON_ALERT_STATUS=resolved\n",
+		"SYSMON_ALERT_TIME=2024-01-02T03:05:05Z\n",
+		"SYSMON_HOST=db01\n",
+		`{"status":"resolved","host":"db01"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("command output does not contain %q:\n%s", want, data)
+		}
+	}
+
+	fail := &Command{Path: "/bin/sh", Args: []string{"-c", "echo no route >&2; exit 3"}}
+	if err := fail.Notify(context.Background(), ev); err == nil || !strings.Contains(err.Error(), "no route") {
+		t.Errorf("failing command error = %v", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	r := &recorder{failures: 2}
+	d := NewDispatcher(Options{Backoff: time.Millisecond}, r)
+	d.SetWarnf(t.Errorf)
+	d.Send(alertEvent("cpu_usage", false))
+	d.Close()
+	if got := r.String(); got != "[cpu_usage firing]" || r.attempts != 3 {
+		t.Errorf("events %s after %d attempts, want one after 3", got, r.attempts)
+	}
+
+	r = &recorder{failures: 10}
+	d = NewDispatcher(Options{Retries: -1}, r)
+	var warning string
+	d.SetWarnf(func(format string, args ...any) { warning = fmt.Sprintf(format, args...) })
+	d.Send(alertEvent("cpu_usage", false))
+	d.Close()
+	if r.attempts != 1 {
+		t.Errorf("%d attempts with retries off", r.attempts)
+	}
+	if want := "recorder: firing notification for cpu_usage failed: unavailable"; warning != want {
+		t.Errorf("warning %q, want %q", warning, want)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	r := &recorder{}
+	d := NewDispatcher(Options{RateLimit: 100 * time.Millisecond}, r)
+
+	// A flapping alert is sent once, then again only when its state after
+	// the interval differs from the one sent
+	d.Send(alertEvent("cpu_usage", false))
+	d.Send(alertEvent("cpu_usage", true))
+	d.Send(alertEvent("cpu_usage", false))
+	d.Send(alertEvent("memory_usage", false))
+	d.Send(alertEvent("memory_usage", true))
+	time.Sleep(250 * time.Millisecond)
+	if got, want := r.String(), "[cpu_usage firing memory_usage firing memory_usage resolved]"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+
+	// Close sends what is still held back
+	d.Send(alertEvent("cpu_usage", true))
+	d.Send(alertEvent("cpu_usage", false))
+	d.Close()
+	if got, want := r.String(), "[cpu_usage firing memory_usage firing memory_usage resolved cpu_usage resolved cpu_usage firing]"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestSuppressedAlerts(t *testing.T) {
+	r := &recorder{}
+	d := NewDispatcher(Options{RateLimit: -1}, r)
+	suppressed := func(ev system.AlertEvent) system.AlertEvent {
+		ev.Alert.SuppressedBy = "maintenance backup"
+		return ev
+	}
+
+	// Suppressed alerts are not sent, but an alert silenced after firing
+	// was sent still resolves
+	d.Send(suppressed(alertEvent("cpu_usage", false)))
+	d.Send(suppressed(alertEvent("cpu_usage", true)))
+	d.Send(alertEvent("memory_usage", false))
+	d.Send(suppressed(alertEvent("memory_usage", true)))
+	d.Close()
+	if got, want := r.String(), "[memory_usage firing memory_usage resolved]"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
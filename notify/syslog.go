@@ -0,0 +1,31 @@
+package notify
+
+import "strings"
+
+// Syslog writes events to the system logger. With the default empty
+// network and address it writes to the local /dev/log socket, which
+// systemd-journald reads on most Linux distributions.
+type Syslog struct {
+	Tag     string // program name in the log, "sysmon" when empty
+	Network string // "udp", "tcp" or "" for the local socket
+	Address string // host:port of a remote syslog server
+
+	conn syslogWriter
+}
+
+// Name returns the syslog destination
+func (s *Syslog) Name() string {
+	if s.Address == "" {
+		return "syslog"
+	}
+	return "syslog " + s.Network + "://" + s.Address
+}
+
+// syslogLine formats an event as one log line
+func syslogLine(ev Event) string {
+	line := "[" + strings.ToUpper(ev.Status) + "] " + ev.Alert.Level + " " + ev.Alert.Source + ": " + ev.Alert.Message
+	if ev.Alert.ID != "" {
+		line += " (id " + ev.Alert.ID + ")"
+	}
+	return line
+}
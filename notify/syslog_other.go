@@ -0,0 +1,16 @@
+//go:build !unix
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"runtime"
+)
+
+type syslogWriter = struct{}
+
+// Notify fails; syslog is only available on Unix systems
+func (s *Syslog) Notify(ctx context.Context, ev Event) error {
+	return errors.New("syslog is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+package notify
+
+import (
+	"context"
+	"log/syslog"
+)
+
+type syslogWriter = *syslog.Writer
+
+// Notify logs the event with the priority of its level; resolved alerts
+// are logged as notices. The connection is opened on first use and again
+// after a failed write.
+func (s *Syslog) Notify(ctx context.Context, ev Event) error {
+	if s.conn == nil {
+		tag := s.Tag
+		if tag == "" {
+			tag = "sysmon"
+		}
+		w, err := syslog.Dial(s.Network, s.Address, syslog.LOG_DAEMON|syslog.LOG_INFO, tag)
+		if err != nil {
+			return err
+		}
+		s.conn = w
+	}
+
+	line := syslogLine(ev)
+	var err error
+	switch {
+	case ev.Status == StatusResolved:
+		err = s.conn.Notice(line)
+	case ev.Alert.Level == "critical":
+		err = s.conn.Crit(line)
+	case ev.Alert.Level == "warning":
+		err = s.conn.Warning(line)
+	default:
+		err = s.conn.Info(line)
+	}
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package record
+
+import (
+	"fmt"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+// Playback speed limits
+const (
+	MinSpeed = 0.25
+	MaxSpeed = 64
+)
+
+// Player steps through a recording in recorded time. The position moves
+// with Advance while playing and can be moved with Seek at any time.
+type Player struct {
+	rec      *Recording
+	offset   time.Duration // playback position relative to the first frame
+	speed    float64
+	paused   bool
+	sortBy   system.SortType
+	current  *system.Snapshot
+	position int
+}
+
+// NewPlayer creates a player positioned at the first frame of rec
+func NewPlayer(rec *Recording) *Player {
+	return &Player{
+		rec:      rec,
+		speed:    1,
+		position: -1,
+	}
+}
+
+// Advance moves playback forward by elapsed wall time scaled by the speed.
+// Playback pauses when it reaches the end of the recording.
+func (p *Player) Advance(elapsed time.Duration) {
+	if p.paused {
+		return
+	}
+	p.setOffset(p.offset + time.Duration(float64(elapsed)*p.speed))
+	if p.offset >= p.rec.Duration() {
+		p.paused = true
+	}
+}
+
+// Seek moves playback by d in recorded time; negative values seek backwards
+func (p *Player) Seek(d time.Duration) {
+	p.setOffset(p.offset + d)
+}
+
+// setOffset moves the playback position, clamped to the recording
+func (p *Player) setOffset(offset time.Duration) {
+	if offset < 0 {
+		offset = 0
+	}
+	if max := p.rec.Duration(); offset > max {
+		offset = max
+	}
+	p.offset = offset
+}
+
+// TogglePause pauses or resumes playback. Resuming at the end of the
+// recording starts over from the beginning.
+func (p *Player) TogglePause() {
+	if p.paused && p.offset >= p.rec.Duration() {
+		p.offset = 0
+	}
+	p.paused = !p.paused
+}
+
+// Paused reports whether playback is paused
+func (p *Player) Paused() bool {
+	return p.paused
+}
+
+// Faster doubles the playback speed
+func (p *Player) Faster() {
+	if p.speed*2 <= MaxSpeed {
+		p.speed *= 2
+	}
+}
+
+// Slower halves the playback speed
+func (p *Player) Slower() {
+	if p.speed/2 >= MinSpeed {
+		p.speed /= 2
+	}
+}
+
+// Speed returns the playback speed multiplier
+func (p *Player) Speed() float64 {
+	return p.speed
+}
+
+// SetSortBy re-sorts the recorded process lists. An empty sort type keeps
+// the order they were recorded in.
+func (p *Player) SetSortBy(sortBy system.SortType) {
+	p.sortBy = sortBy
+	p.current = nil
+}
+
+// Position returns the index of the frame at the playback position
+func (p *Player) Position() int {
+	return p.rec.Index(p.rec.Start().Add(p.offset))
+}
+
+// Time returns the collection time of the frame at the playback position
+func (p *Player) Time() time.Time {
+	if p.rec.Len() == 0 {
+		return time.Time{}
+	}
+	return p.rec.Time(p.Position())
+}
+
+// Snapshot returns the frame at the playback position. The same snapshot is
+// returned until the position moves to another frame.
+func (p *Player) Snapshot() (*system.Snapshot, error) {
+	if p.rec.Len() == 0 {
+		return nil, fmt.Errorf("recording is empty")
+	}
+
+	position := p.Position()
+	if p.current != nil && position == p.position {
+		return p.current, nil
+	}
+
+	snap, err := p.rec.Snapshot(position)
+	if err != nil {
+		return nil, err
+	}
+	if p.sortBy != "" {
+		snap.Process.SortBy = p.sortBy
+		system.SortProcessList(snap.Process.Processes, p.sortBy)
+	}
+
+	p.current = snap
+	p.position = position
+	return snap, nil
+}
+
+// Status describes the playback state for display
+func (p *Player) Status() string {
+	state := "▶"
+	if p.paused {
+		state = "⏸"
+	}
+	return fmt.Sprintf("%s REPLAY %s  %s / %s  frame %d/%d  %gx",
+		state,
+		p.Time().Format("2006-01-02 15:04:05"),
+		formatOffset(p.offset),
+		formatOffset(p.rec.Duration()),
+		p.Position()+1, p.rec.Len(),
+		p.speed,
+	)
+}
+
+// formatOffset formats a playback offset as [h:]mm:ss
+func formatOffset(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := (d % time.Minute) / time.Second
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
@@ -0,0 +1,231 @@
+// Package record stores collected snapshots in a session file and reads
+// them back for replay.
+//
+// A session file is a sequence of gzip members, one per snapshot, each
+// holding a single JSON encoded frame. Every member is complete on its own,
+// so recordings can be appended to across runs and a recording that was cut
+// off mid-write still loads up to its last complete frame.
+package record
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+// FormatVersion is the version of the frame encoding written by Recorder
+const FormatVersion = 1
+
+// ErrTruncated is returned by Load when the file ends in an incomplete frame.
+// The frames before it are still returned.
+var ErrTruncated = errors.New("recording ends in an incomplete frame")
+
+// frame is the on-disk representation of one snapshot
+type frame struct {
+	Version  int              `json:"version"`
+	Snapshot *system.Snapshot `json:"snapshot"`
+}
+
+// frameHeader decodes only the parts of a frame needed to index it
+type frameHeader struct {
+	Version  int `json:"version"`
+	Snapshot struct {
+		System struct {
+			LastUpdated time.Time
+		}
+	} `json:"snapshot"`
+}
+
+// Recorder appends snapshots to a session file
+type Recorder struct {
+	mu   sync.Mutex
+	file *os.File
+	buf  bytes.Buffer
+	gz   *gzip.Writer
+}
+
+// Create opens path for recording, creating it if needed. Existing frames
+// are kept and new ones are appended after them.
+func Create(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{file: file}
+	r.gz = gzip.NewWriter(&r.buf)
+	return r, nil
+}
+
+// Write appends a snapshot to the recording
+func (r *Recorder) Write(snap *system.Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf.Reset()
+	r.gz.Reset(&r.buf)
+	if err := json.NewEncoder(r.gz).Encode(frame{Version: FormatVersion, Snapshot: snap}); err != nil {
+		return err
+	}
+	if err := r.gz.Close(); err != nil {
+		return err
+	}
+
+	// Write the member in one call so readers never see half a frame from
+	// a recorder that is still running
+	_, err := r.file.Write(r.buf.Bytes())
+	return err
+}
+
+// Close closes the session file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Recording is a loaded session. Frames are kept compressed and decoded
+// when they are requested.
+type Recording struct {
+	times  []time.Time
+	frames [][]byte
+}
+
+// Load reads a session file. If the file ends in an incomplete frame the
+// complete frames are returned together with ErrTruncated.
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &Recording{}
+	err = readMembers(data, func(member, contents []byte) error {
+		var hdr frameHeader
+		if err := json.Unmarshal(contents, &hdr); err != nil {
+			return err
+		}
+		if hdr.Version != FormatVersion {
+			return fmt.Errorf("unsupported recording format version %d", hdr.Version)
+		}
+		rec.times = append(rec.times, hdr.Snapshot.System.LastUpdated)
+		rec.frames = append(rec.frames, member)
+		return nil
+	})
+	truncated := errors.Is(err, io.ErrUnexpectedEOF)
+	if err != nil && !truncated {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	// Appended sessions may overlap; play frames in time order
+	sort.Stable(byTime{rec})
+
+	if truncated {
+		return rec, ErrTruncated
+	}
+	return rec, nil
+}
+
+// readMembers calls fn for every gzip member in data with the compressed
+// member and its decompressed contents
+func readMembers(data []byte, fn func(member, contents []byte) error) error {
+	r := bytes.NewReader(data)
+	var zr *gzip.Reader
+	for r.Len() > 0 {
+		start := len(data) - r.Len()
+
+		var err error
+		if zr == nil {
+			zr, err = gzip.NewReader(r)
+		} else {
+			err = zr.Reset(r)
+		}
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		zr.Multistream(false)
+
+		contents, err := io.ReadAll(zr)
+		if err != nil {
+			return err
+		}
+		end := len(data) - r.Len()
+		if err := fn(data[start:end], contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of frames in the recording
+func (r *Recording) Len() int {
+	return len(r.frames)
+}
+
+// Time returns the collection time of frame i
+func (r *Recording) Time(i int) time.Time {
+	return r.times[i]
+}
+
+// Start returns the time of the first frame
+func (r *Recording) Start() time.Time {
+	if len(r.times) == 0 {
+		return time.Time{}
+	}
+	return r.times[0]
+}
+
+// Duration returns the time between the first and the last frame
+func (r *Recording) Duration() time.Duration {
+	if len(r.times) == 0 {
+		return 0
+	}
+	return r.times[len(r.times)-1].Sub(r.times[0])
+}
+
+// Index returns the last frame collected at or before t
+func (r *Recording) Index(t time.Time) int {
+	i := sort.Search(len(r.times), func(i int) bool {
+		return r.times[i].After(t)
+	})
+	if i > 0 {
+		i--
+	}
+	return i
+}
+
+// Snapshot decodes frame i. Every call returns a new snapshot.
+func (r *Recording) Snapshot(i int) (*system.Snapshot, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(r.frames[i]))
+	if err != nil {
+		return nil, fmt.Errorf("decoding frame %d: %w", i, err)
+	}
+	var f frame
+	if err := json.NewDecoder(zr).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decoding frame %d: %w", i, err)
+	}
+	if f.Snapshot == nil {
+		return nil, fmt.Errorf("frame %d has no snapshot", i)
+	}
+	return f.Snapshot, nil
+}
+
+// byTime sorts the frames of a recording by collection time
+type byTime struct{ r *Recording }
+
+func (b byTime) Len() int           { return len(b.r.times) }
+func (b byTime) Less(i, j int) bool { return b.r.times[i].Before(b.r.times[j]) }
+func (b byTime) Swap(i, j int) {
+	b.r.times[i], b.r.times[j] = b.r.times[j], b.r.times[i]
+	b.r.frames[i], b.r.frames[j] = b.r.frames[j], b.r.frames[i]
+}
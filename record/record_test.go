@@ -0,0 +1,163 @@
+package record
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go_system_monitor/internal/testutil"
+	"go_system_monitor/system"
+)
+
+var start = testutil.Time
+
+// testSnapshot returns the shared fixture collected at start plus offset
+// with the given CPU usage
+func testSnapshot(offset time.Duration, cpu float64) *system.Snapshot {
+	at := start.Add(offset)
+	snap := testutil.Snapshot()
+	snap.System.LastUpdated = at
+	snap.CPU.Usage = cpu
+	snap.CPU.History = system.TimeSeries{Points: []system.TimeSeriesPoint{{Timestamp: at, Value: cpu}}}
+	// Recorded in PID order, so that replay has to sort them again
+	system.SortProcessList(snap.Process.Processes, system.SortByPID)
+	snap.Process.SortBy = system.SortByPID
+	return snap
+}
+
+// writeSession records snaps to path in one recorder session
+func writeSession(t *testing.T, path string, snaps ...*system.Snapshot) {
+	t.Helper()
+	r, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snap := range snaps {
+		if err := r.Write(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	want := []*system.Snapshot{testSnapshot(0, 10), testSnapshot(time.Second, 20)}
+	writeSession(t, path, want...)
+
+	rec, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", rec.Len(), len(want))
+	}
+	for i := range want {
+		got, err := rec.Snapshot(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("frame %d:\ngot  %+v\nwant %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestRecordAppendsSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	writeSession(t, path, testSnapshot(0, 10), testSnapshot(time.Second, 20))
+	writeSession(t, path, testSnapshot(2*time.Second, 30))
+
+	rec, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", rec.Len())
+	}
+	if got := rec.Duration(); got != 2*time.Second {
+		t.Errorf("Duration() = %v, want 2s", got)
+	}
+}
+
+func TestLoadTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	writeSession(t, path, testSnapshot(0, 10), testSnapshot(time.Second, 20))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-10], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := Load(path)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Load() error = %v, want ErrTruncated", err)
+	}
+	if rec.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 complete frame", rec.Len())
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	var snaps []*system.Snapshot
+	for i := 0; i < 10; i++ {
+		snaps = append(snaps, testSnapshot(time.Duration(i)*time.Second, float64(i)))
+	}
+	writeSession(t, path, snaps...)
+
+	rec, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPlayer(rec)
+
+	steps := []struct {
+		name string
+		do   func()
+		want int
+	}{
+		{"start", func() {}, 0},
+		{"advance", func() { p.Advance(1500 * time.Millisecond) }, 1},
+		{"faster", func() { p.Faster(); p.Advance(time.Second) }, 3},
+		{"pause", func() { p.TogglePause(); p.Advance(time.Second) }, 3},
+		{"seek forward", func() { p.Seek(4 * time.Second) }, 7},
+		{"seek past end", func() { p.Seek(time.Minute) }, 9},
+		{"seek back", func() { p.Seek(-5 * time.Second) }, 4},
+		{"seek before start", func() { p.Seek(-time.Minute) }, 0},
+		{"resume to end", func() { p.TogglePause(); p.Advance(time.Minute) }, 9},
+	}
+	for _, step := range steps {
+		step.do()
+		if got := p.Position(); got != step.want {
+			t.Fatalf("%s: Position() = %d, want %d", step.name, got, step.want)
+		}
+		snap, err := p.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap.CPU.Usage != float64(step.want) {
+			t.Fatalf("%s: snapshot cpu = %v, want %v", step.name, snap.CPU.Usage, step.want)
+		}
+	}
+	if !p.Paused() {
+		t.Error("player should pause at the end of the recording")
+	}
+
+	p.SetSortBy(system.SortByCPU)
+	snap, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Process.Processes[0].Name != "java" || snap.Process.SortBy != system.SortByCPU {
+		t.Errorf("processes not re-sorted by cpu: %+v", snap.Process)
+	}
+}
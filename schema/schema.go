@@ -0,0 +1,357 @@
+// Package schema defines the public JSON representation of a snapshot used
+// by the -json and -ndjson output modes.
+//
+// The types here are a stable contract for scripts and log pipelines and
+// are decoupled from the collector's internal structs. Fields may be added
+// within a schema version; renaming or removing a field, or changing its
+// meaning, requires bumping Version.
+package schema
+
+import (
+	"sort"
+	"time"
+
+	"go_system_monitor/system"
+)
+
+// Version is the schema version written to every document
+const Version = 1
+
+// Snapshot is one collection cycle
+type Snapshot struct {
+	SchemaVersion int       `json:"schema_version"`
+	Timestamp     time.Time `json:"timestamp"`
+	System        System    `json:"system"`
+	CPU           CPU       `json:"cpu"`
+	Memory        Memory    `json:"memory"`
+	Disk          Disk      `json:"disk"`
+	Network       Network   `json:"network"`
+	Processes     Processes `json:"processes"`
+	Alerts        []Alert   `json:"alerts"`
+}
+
+// System describes the host
+type System struct {
+	Hostname      string  `json:"hostname"`
+	Platform      string  `json:"platform"`
+	OS            string  `json:"os"`
+	KernelVersion string  `json:"kernel_version"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// CPU holds processor usage
+type CPU struct {
+	UsagePercent       float64   `json:"usage_percent"`
+	PerCPUPercent      []float64 `json:"per_cpu_percent"`
+	Cores              int       `json:"cores"`
+	Load               *Load     `json:"load,omitempty"`
+	TemperatureCelsius float64   `json:"temperature_celsius,omitempty"`
+}
+
+// Load holds the load averages
+type Load struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// Memory holds physical memory and swap usage
+type Memory struct {
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+	Swap        Swap    `json:"swap"`
+}
+
+// Swap holds swap usage
+type Swap struct {
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// Disk holds filesystem usage and block device throughput
+type Disk struct {
+	Filesystems []Filesystem `json:"filesystems"`
+	Devices     []DiskDevice `json:"devices"`
+}
+
+// Filesystem is a mounted filesystem
+type Filesystem struct {
+	Device      string  `json:"device"`
+	Mountpoint  string  `json:"mountpoint"`
+	Fstype      string  `json:"fstype"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// DiskDevice is a block device
+type DiskDevice struct {
+	Name                string  `json:"name"`
+	ReadBytes           uint64  `json:"read_bytes"`
+	WriteBytes          uint64  `json:"write_bytes"`
+	ReadBytesPerSecond  float64 `json:"read_bytes_per_second"`
+	WriteBytesPerSecond float64 `json:"write_bytes_per_second"`
+}
+
+// Network holds per-interface traffic
+type Network struct {
+	Interfaces []Interface `json:"interfaces"`
+}
+
+// Interface is a network interface
+type Interface struct {
+	Name                   string  `json:"name"`
+	ReceivedBytes          uint64  `json:"received_bytes"`
+	SentBytes              uint64  `json:"sent_bytes"`
+	ReceiveBytesPerSecond  float64 `json:"receive_bytes_per_second"`
+	TransmitBytesPerSecond float64 `json:"transmit_bytes_per_second"`
+}
+
+// Processes holds the process list. List is sorted by SortBy and limited
+// to the configured maximum number of processes; Total counts all of them.
+type Processes struct {
+	Total  int       `json:"total"`
+	SortBy string    `json:"sort_by"`
+	List   []Process `json:"list"`
+}
+
+// Process is a single process
+type Process struct {
+	PID           int32     `json:"pid"`
+	PPID          int32     `json:"ppid"`
+	Name          string    `json:"name"`
+	User          string    `json:"user"`
+	State         string    `json:"state"`                // one of the Process states
+	CPUPercent    float64   `json:"cpu_percent"`          // averaged over the process lifetime
+	CPUInterval   float64   `json:"cpu_interval_percent"` // since the previous cycle
+	MemoryPercent float32   `json:"memory_percent"`
+	RSSBytes      uint64    `json:"rss_bytes"`
+	VMSBytes      uint64    `json:"vms_bytes"`
+	Threads       int32     `json:"threads"`
+	Nice          int32     `json:"nice"`
+	StartTime     time.Time `json:"start_time"`
+	Command       string    `json:"command"`
+	Cgroup        string    `json:"cgroup,omitempty"` // cgroup path on Linux
+}
+
+// Process states
+const (
+	StateRunning   = "running"
+	StateSleeping  = "sleeping"
+	StateDiskSleep = "disk_sleep"
+	StateIdle      = "idle"
+	StateStopped   = "stopped"
+	StateZombie    = "zombie"
+	StateUnknown   = "unknown"
+)
+
+// Alert is an alert raised by the alert manager
+type Alert struct {
+	ID        string    `json:"id,omitempty"`    // stable from first seen until resolved
+	State     string    `json:"state,omitempty"` // pending, firing or resolved
+	Timestamp time.Time `json:"timestamp"`       // when the alert was raised (fired)
+	Level     string    `json:"level"`
+	Source    string    `json:"source"`
+	Message   string    `json:"message"`
+	Resolved  bool      `json:"resolved"`
+	// FirstSeen and LastSeen are the first and latest check at which the
+	// alert was active, and Count the number of those checks
+	FirstSeen  *time.Time `json:"first_seen,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Count      int        `json:"count,omitempty"`
+	// Value is the metric at the latest check and Peak the value furthest
+	// past the threshold
+	Value float64 `json:"value"`
+	Peak  float64 `json:"peak"`
+	// Acknowledged and SuppressedBy are set for alerts that were
+	// acknowledged, or silenced or in a maintenance window
+	Acknowledged bool   `json:"acknowledged,omitempty"`
+	SuppressedBy string `json:"suppressed_by,omitempty"`
+}
+
+// FromSnapshot converts a collector snapshot to the public schema
+func FromSnapshot(snap *system.Snapshot) Snapshot {
+	doc := Snapshot{
+		SchemaVersion: Version,
+		Timestamp:     snap.System.LastUpdated,
+		System: System{
+			Hostname:      snap.System.Hostname,
+			Platform:      snap.System.Platform,
+			OS:            snap.System.OS,
+			KernelVersion: snap.System.KernelVer,
+			UptimeSeconds: snap.System.Uptime.Seconds(),
+		},
+		CPU: CPU{
+			UsagePercent:       snap.CPU.Usage,
+			PerCPUPercent:      append([]float64{}, snap.CPU.UsagePerCPU...),
+			Cores:              snap.CPU.Cores,
+			TemperatureCelsius: snap.CPU.Temperature,
+		},
+		Memory: Memory{
+			TotalBytes:  snap.Memory.Total,
+			UsedBytes:   snap.Memory.Used,
+			FreeBytes:   snap.Memory.Free,
+			UsedPercent: snap.Memory.UsedPercent,
+			Swap: Swap{
+				TotalBytes:  snap.Memory.SwapTotal,
+				UsedBytes:   snap.Memory.SwapUsed,
+				FreeBytes:   snap.Memory.SwapFree,
+				UsedPercent: snap.Memory.SwapPercent,
+			},
+		},
+		Disk:    Disk{Filesystems: []Filesystem{}, Devices: []DiskDevice{}},
+		Network: Network{Interfaces: []Interface{}},
+		Processes: Processes{
+			Total:  snap.Process.Total,
+			SortBy: string(snap.Process.SortBy),
+			List:   []Process{},
+		},
+		Alerts: []Alert{},
+	}
+
+	if l := snap.CPU.LoadAvg; l != nil {
+		doc.CPU.Load = &Load{Load1: l.Load1, Load5: l.Load5, Load15: l.Load15}
+	}
+
+	// Filesystems in partition order; mounts without usage are skipped
+	for _, p := range snap.Disk.Partitions {
+		u, ok := snap.Disk.UsageStats[p.Mountpoint]
+		if !ok || u == nil {
+			continue
+		}
+		doc.Disk.Filesystems = append(doc.Disk.Filesystems, Filesystem{
+			Device:      p.Device,
+			Mountpoint:  p.Mountpoint,
+			Fstype:      p.Fstype,
+			TotalBytes:  u.Total,
+			UsedBytes:   u.Used,
+			FreeBytes:   u.Free,
+			UsedPercent: u.UsedPercent,
+		})
+	}
+
+	for _, name := range sortedKeys(snap.Disk.IOCounters) {
+		io := snap.Disk.IOCounters[name]
+		doc.Disk.Devices = append(doc.Disk.Devices, DiskDevice{
+			Name:                name,
+			ReadBytes:           io.ReadBytes,
+			WriteBytes:          io.WriteBytes,
+			ReadBytesPerSecond:  snap.Disk.ReadRate[name],
+			WriteBytesPerSecond: snap.Disk.WriteRate[name],
+		})
+	}
+
+	for _, name := range sortedKeys(snap.Network.IOCounters) {
+		io := snap.Network.IOCounters[name]
+		doc.Network.Interfaces = append(doc.Network.Interfaces, Interface{
+			Name:                   name,
+			ReceivedBytes:          io.BytesRecv,
+			SentBytes:              io.BytesSent,
+			ReceiveBytesPerSecond:  snap.Network.RecvRate[name],
+			TransmitBytesPerSecond: snap.Network.SentRate[name],
+		})
+	}
+
+	processes := snap.Process.Processes
+	if snap.MaxProcesses > 0 && len(processes) > snap.MaxProcesses {
+		processes = processes[:snap.MaxProcesses]
+	}
+	for _, p := range processes {
+		doc.Processes.List = append(doc.Processes.List, Process{
+			PID:           p.PID,
+			PPID:          p.PPID,
+			Name:          p.Name,
+			User:          p.Username,
+			State:         processState(p.Status),
+			CPUPercent:    p.CPULifetime,
+			CPUInterval:   p.CPUPercent,
+			MemoryPercent: p.MemPercent,
+			RSSBytes:      p.MemRSS,
+			VMSBytes:      p.MemVMS,
+			Threads:       p.NumThreads,
+			Nice:          p.Nice,
+			StartTime:     p.CreatedAt,
+			Command:       p.CmdLine,
+			Cgroup:        p.Cgroup,
+		})
+	}
+
+	// Version 1 lists only raised alerts; a pending alert has not fired yet
+	// and would read as active to consumers that only check resolved
+	for _, a := range snap.Alerts {
+		if a.State == system.AlertPending {
+			continue
+		}
+		doc.Alerts = append(doc.Alerts, FromAlert(a))
+	}
+
+	return doc
+}
+
+// FromAlert converts an alert to its schema document
+func FromAlert(a system.Alert) Alert {
+	return Alert{
+		ID:           a.ID,
+		State:        string(a.State),
+		Timestamp:    a.Timestamp,
+		Level:        string(a.Level),
+		Source:       a.Source,
+		Message:      a.Message,
+		Resolved:     a.Resolved,
+		FirstSeen:    optionalTime(a.FirstSeen),
+		LastSeen:     optionalTime(a.LastSeen),
+		ResolvedAt:   optionalTime(a.ResolvedAt),
+		Count:        a.Count,
+		Value:        a.Value,
+		Peak:         a.Peak,
+		Acknowledged: a.Acknowledged,
+		SuppressedBy: a.SuppressedBy,
+	}
+}
+
+// optionalTime returns nil for the zero time, so that it is left out
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// processState maps the gopsutil status words, or ps state letters, to
+// the schema's process states
+func processState(status []string) string {
+	if len(status) == 0 {
+		return StateUnknown
+	}
+	switch status[0] {
+	case "running", "R":
+		return StateRunning
+	case "sleep", "S":
+		return StateSleeping
+	case "blocked", "D":
+		return StateDiskSleep
+	case "idle", "I":
+		return StateIdle
+	case "stop", "T", "t":
+		return StateStopped
+	case "zombie", "Z":
+		return StateZombie
+	}
+	return StateUnknown
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
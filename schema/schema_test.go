@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"go_system_monitor/internal/testutil"
+	"go_system_monitor/system"
+)
+
+// testSnapshot returns the shared fixture with every schema section filled,
+// more processes than MaxProcesses and alerts that test the edge cases
+func testSnapshot() *system.Snapshot {
+	snap := testutil.Snapshot()
+	at := testutil.Time
+	snap.Memory.SwapTotal, snap.Memory.SwapUsed, snap.Memory.SwapFree, snap.Memory.SwapPercent = 2<<30, 1<<30, 1<<30, 50
+	snap.Disk.IOCounters = map[string]disk.IOCountersStat{
+		"sdb": {Name: "sdb", ReadBytes: 2048, WriteBytes: 4096},
+		"sda": {Name: "sda", ReadBytes: 1024, WriteBytes: 512},
+	}
+	snap.Process.Processes = append(snap.Process.Processes,
+		system.ProcessDetail{PID: 666, PPID: 1, Name: "defunct", Username: "root", Status: []string{"zombie"}})
+	snap.Process.Total = len(snap.Process.Processes)
+	snap.MaxProcesses = 2
+	snap.Alerts = append(snap.Alerts,
+		// Not raised yet, so left out of the document
+		system.Alert{ID: "5d6e7f8091a2b3c4", State: system.AlertPending, Timestamp: at, Message: "Memory usage high", Level: system.WarningLevel, Source: "memory_usage",
+			FirstSeen: at, LastSeen: at, Count: 1, Value: 90, Peak: 90},
+		// A value of 0 is kept
+		system.Alert{ID: "0e1f2a3b4c5d6e7f", State: system.AlertResolved, Resolved: true, Timestamp: at.Add(-time.Hour), Message: "No free swap", Level: system.WarningLevel, Source: "swap_free",
+			FirstSeen: at.Add(-time.Hour), LastSeen: at.Add(-30 * time.Minute), ResolvedAt: at.Add(-29 * time.Minute), Count: 30},
+	)
+	return snap
+}
+
+func TestFromSnapshot(t *testing.T) {
+	got, err := json.MarshalIndent(FromSnapshot(testSnapshot()), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	testutil.AssertGolden(t, filepath.Join("testdata", "snapshot.golden.json"), got)
+}
+
+func TestFromSnapshotEmpty(t *testing.T) {
+	got, err := json.Marshal(FromSnapshot(&system.Snapshot{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(got, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if v := doc["schema_version"]; v != float64(Version) {
+		t.Errorf("schema_version = %v, want %d", v, Version)
+	}
+	// Lists are always arrays so consumers never have to handle null
+	if !bytes.Contains(got, []byte(`"alerts":[]`)) || !bytes.Contains(got, []byte(`"list":[]`)) ||
+		!bytes.Contains(got, []byte(`"per_cpu_percent":[]`)) {
+		t.Errorf("empty lists are not encoded as arrays: %s", got)
+	}
+}
@@ -2,6 +2,8 @@ package system
 
 import (
 	"fmt"
+	"hash/fnv"
+	"sync"
 	"time"
 )
 
@@ -15,129 +17,274 @@ const (
 	CriticalLevel AlertLevel = "critical"
 )
 
-// Alert represents a system alert for high resource usage
+// AlertState is the stage of an alert's lifecycle
+type AlertState string
+
+const (
+	// AlertPending alerts hold their condition but have not held it for
+	// their rule's duration yet
+	AlertPending  AlertState = "pending"
+	AlertFiring   AlertState = "firing"
+	AlertResolved AlertState = "resolved"
+)
+
+// Alert represents a system alert for high resource usage. An alert keeps
+// its ID from when it is first seen until it resolves; an alert of the same
+// source raised again later is a new alert with a new ID.
 type Alert struct {
-	Timestamp time.Time
+	ID        string
+	State     AlertState
+	Timestamp time.Time // when the alert fired, or was first seen while pending
 	Message   string
 	Level     AlertLevel
 	Source    string
-	Resolved  bool
+	Resolved  bool // State is AlertResolved
+	// FirstSeen and LastSeen are the first and the latest check at which
+	// the alert was active, and Count the number of such checks
+	FirstSeen  time.Time
+	LastSeen   time.Time
+	ResolvedAt time.Time
+	Count      int
+	// Value is the value of the metric at the latest check and Peak the one
+	// furthest past the threshold
+	Value float64
+	Peak  float64
+	// Acknowledged alerts have been seen by the user and no longer count
+	// as needing attention
+	Acknowledged bool
+	// SuppressedBy names the silence or maintenance window that keeps the
+	// alert from being notified or counted; empty when there is none
+	SuppressedBy string
+}
+
+// Firing reports whether the alert is active and past pending. Alerts
+// without a state, such as those of older recordings, fire unless resolved.
+func (a Alert) Firing() bool {
+	return !a.Resolved && a.State != AlertPending
 }
 
-// AlertManager handles system alerts
+// NeedsAttention reports whether the alert is firing and has been neither
+// acknowledged nor suppressed
+func (a Alert) NeedsAttention() bool {
+	return a.Firing() && !a.Acknowledged && a.SuppressedBy == ""
+}
+
+// Duration returns how long the alert has been pending or firing, up to
+// now while it is active
+func (a Alert) Duration(now time.Time) time.Duration {
+	start, end := a.Timestamp, now
+	if a.State == AlertPending && !a.FirstSeen.IsZero() {
+		start = a.FirstSeen
+	}
+	if a.Resolved {
+		end = a.ResolvedAt
+	}
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// alertID derives the ID of an alert from its source and when it was first
+// seen, so that it stays the same however often the alert is updated
+func alertID(source string, firstSeen time.Time) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s@%d", source, firstSeen.UnixNano())
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// AlertEvent reports that a rule alert fired or resolved. Alert.Resolved
+// tells the two apart.
+type AlertEvent struct {
+	Alert Alert
+	Time  time.Time // collection time of the cycle that changed the alert
+}
+
+// AlertManager handles system alerts. Alerts are raised by evaluating
+// alert rules against the collected metrics. Its methods are safe for
+// concurrent use; read the alert list through Snapshot rather than the
+// Alerts field while collection is running.
 type AlertManager struct {
-	Alerts       []Alert
-	MaxAlerts    int
-	CPUThreshold float64
-	MemThreshold float64
-	DiskThreshold float64
-	SwapThreshold float64
+	mu        sync.Mutex
+	Alerts    []Alert
+	MaxAlerts int
+	rules     []compiledRule
+	states    map[string]*ruleState // rule state by alert source
+	handlers  []func(AlertEvent)
+	events    []AlertEvent         // raised during the current evaluation
+	silences  map[string]time.Time // end of the silence by alert source
+	windows   []MaintenanceWindow
 }
 
-// NewAlertManager creates a new alert manager with configurable thresholds
+// NewAlertManager creates a new alert manager with the default rules for
+// the given usage thresholds
 func NewAlertManager(cpuThreshold, memThreshold, diskThreshold, swapThreshold float64, maxAlerts int) *AlertManager {
-	return &AlertManager{
-		Alerts:       []Alert{},
-		MaxAlerts:    maxAlerts,
-		CPUThreshold: cpuThreshold,
-		MemThreshold: memThreshold,
-		DiskThreshold: diskThreshold,
-		SwapThreshold: swapThreshold,
+	am := &AlertManager{
+		Alerts:    []Alert{},
+		MaxAlerts: maxAlerts,
 	}
+	// The default rules are always valid
+	_ = am.SetRules(DefaultAlertRules(AlertThresholds{
+		CPU:    cpuThreshold,
+		Memory: memThreshold,
+		Disk:   diskThreshold,
+		Swap:   swapThreshold,
+	}))
+	return am
 }
 
-// AddAlert adds a new alert
+// Snapshot returns a copy of the current alerts, most recent first
+func (am *AlertManager) Snapshot() []Alert {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	return append([]Alert(nil), am.Alerts...)
+}
+
+// Subscribe registers fn to be called whenever a rule alert fires or
+// resolves. Handlers run on the collection goroutine after the rules have
+// been evaluated, so they must not block.
+func (am *AlertManager) Subscribe(fn func(AlertEvent)) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.handlers = append(am.handlers, fn)
+}
+
+// AddAlert raises an alert that fires straight away, or updates the
+// active alert of the source
 func (am *AlertManager) AddAlert(message string, level AlertLevel, source string) {
-	// Check if a similar unresolved alert already exists
-	for i, alert := range am.Alerts {
-		if alert.Source == source && alert.Level == level && !alert.Resolved {
-			// Similar alert exists, don't create a duplicate
-			return
-		}
-		
-		// If we find a resolved alert with the same source and it's a different level,
-		// we can mark it as resolved since the condition has changed
-		if alert.Source == source && alert.Level != level && !alert.Resolved {
-			am.Alerts[i].Resolved = true
-		}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	now := time.Now()
+	am.observe(source, level, message, 0, now)
+	am.fire(source, now)
+}
+
+// observe records that the alert of a source is active at now, raising it
+// as pending if it is new, and returns its index. A change of level is
+// applied to the active alert. The caller must hold am.mu.
+func (am *AlertManager) observe(source string, level AlertLevel, message string, value float64, now time.Time) int {
+	if i := am.activeIndex(source); i >= 0 {
+		a := &am.Alerts[i]
+		a.Level = level
+		a.LastSeen = now
+		a.Count++
+		a.Value = value
+		return i
 	}
 
 	// Add new alert to the beginning of the slice (most recent first)
 	am.Alerts = append([]Alert{{
-		Timestamp: time.Now(),
+		ID:        alertID(source, now),
+		State:     AlertPending,
+		Timestamp: now,
 		Message:   message,
 		Level:     level,
 		Source:    source,
-		Resolved:  false,
+		FirstSeen: now,
+		LastSeen:  now,
+		Count:     1,
+		Value:     value,
+		Peak:      value,
 	}}, am.Alerts...)
-
-	// Trim old alerts if we exceed the maximum
-	if len(am.Alerts) > am.MaxAlerts {
-		am.Alerts = am.Alerts[:am.MaxAlerts]
-	}
+	am.trim()
+	return 0
 }
 
-// ResolveAlert marks an alert as resolved
-func (am *AlertManager) ResolveAlert(source string) {
-	for i, alert := range am.Alerts {
-		if alert.Source == source && !alert.Resolved {
-			am.Alerts[i].Resolved = true
-			// Add a resolution notice
-			am.AddAlert(
-				fmt.Sprintf("%s has returned to normal levels", source),
-				InfoLevel,
-				fmt.Sprintf("%s_resolved", source),
-			)
-			break
+// trim drops the oldest resolved alerts and events while there are more
+// than MaxAlerts. Pending and firing alerts are kept however many there
+// are, so that their rules can still fire and resolve them. The caller
+// must hold am.mu.
+func (am *AlertManager) trim() {
+	for i := len(am.Alerts) - 1; i >= 0 && len(am.Alerts) > am.MaxAlerts; i-- {
+		if am.Alerts[i].Resolved {
+			am.Alerts = append(am.Alerts[:i], am.Alerts[i+1:]...)
 		}
 	}
 }
 
-// CheckResourceAlerts generates alerts based on resource usage
-func (am *AlertManager) CheckResourceAlerts(metrics *Collector) {
-	// Check CPU usage
-	if metrics.CPU.Usage >= am.CPUThreshold {
-		am.AddAlert(
-			fmt.Sprintf("CPU usage is high (%.1f%%)", metrics.CPU.Usage),
-			CriticalLevel,
-			"cpu_usage",
-		)
-	} else if metrics.CPU.Usage < am.CPUThreshold-10 { // 10% hysteresis
-		am.ResolveAlert("cpu_usage")
+// fire moves the pending alert of a source to firing and returns it. An
+// alert that already fires is returned unchanged. The caller must hold
+// am.mu.
+func (am *AlertManager) fire(source string, now time.Time) (Alert, bool) {
+	i := am.activeIndex(source)
+	if i < 0 {
+		return Alert{}, false
 	}
-
-	// Check memory usage
-	if metrics.Memory.UsedPercent >= am.MemThreshold {
-		am.AddAlert(
-			fmt.Sprintf("Memory usage is high (%.1f%%)", metrics.Memory.UsedPercent),
-			CriticalLevel,
-			"memory_usage",
-		)
-	} else if metrics.Memory.UsedPercent < am.MemThreshold-10 { // 10% hysteresis
-		am.ResolveAlert("memory_usage")
+	if am.Alerts[i].State == AlertPending {
+		am.Alerts[i].State = AlertFiring
+		am.Alerts[i].Timestamp = now
 	}
+	return am.Alerts[i], true
+}
+
+// RecordEvent logs an action taken by the user, such as signalling a
+// process. Events are stored as resolved alerts so that they show up in the
+// alert log without counting as active alerts.
+func (am *AlertManager) RecordEvent(message string, level AlertLevel, source string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	now := time.Now()
+	am.Alerts = append([]Alert{{
+		ID:         alertID(source, now),
+		State:      AlertResolved,
+		Timestamp:  now,
+		Message:    message,
+		Level:      level,
+		Source:     source,
+		Resolved:   true,
+		FirstSeen:  now,
+		LastSeen:   now,
+		ResolvedAt: now,
+		Count:      1,
+	}}, am.Alerts...)
+	am.trim()
+}
+
+// ResolveAlert marks the active alert of a source as resolved
+func (am *AlertManager) ResolveAlert(source string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.resolveAlert(source, time.Now())
+}
 
-	// Check swap usage if swap is enabled
-	if metrics.Memory.SwapTotal > 0 && metrics.Memory.SwapPercent >= am.SwapThreshold {
-		am.AddAlert(
-			fmt.Sprintf("Swap usage is high (%.1f%%)", metrics.Memory.SwapPercent),
-			WarningLevel,
-			"swap_usage",
-		)
-	} else if metrics.Memory.SwapTotal > 0 && metrics.Memory.SwapPercent < am.SwapThreshold-10 {
-		am.ResolveAlert("swap_usage")
+// resolveAlert marks the active alert of a source as resolved at now and
+// returns it. A pending alert that never fired is dropped instead and not
+// returned. The caller must hold am.mu.
+func (am *AlertManager) resolveAlert(source string, now time.Time) (Alert, bool) {
+	i := am.activeIndex(source)
+	if i < 0 {
+		return Alert{}, false
+	}
+	if am.Alerts[i].State == AlertPending {
+		am.Alerts = append(am.Alerts[:i], am.Alerts[i+1:]...)
+		return Alert{}, false
 	}
+	a := &am.Alerts[i]
+	a.State = AlertResolved
+	a.Resolved = true
+	a.ResolvedAt = now
+	return *a, true
+}
+
+// CheckResourceAlerts evaluates the alert rules against the collected
+// metrics and passes the alerts that fired or resolved to the subscribers
+func (am *AlertManager) CheckResourceAlerts(metrics *Collector) {
+	am.mu.Lock()
+	am.evaluateRules(metrics)
+	events, handlers := am.events, am.handlers
+	am.events = nil
+	am.mu.Unlock()
+
+	notify(events, handlers)
+}
 
-	// Check disk usage
-	for mountpoint, usage := range metrics.Disk.UsageStats {
-		if usage.UsedPercent >= am.DiskThreshold {
-			am.AddAlert(
-				fmt.Sprintf("Disk usage on %s is high (%.1f%%)", mountpoint, usage.UsedPercent),
-				WarningLevel,
-				fmt.Sprintf("disk_usage_%s", mountpoint),
-			)
-		} else if usage.UsedPercent < am.DiskThreshold-5 { // 5% hysteresis for disk
-			am.ResolveAlert(fmt.Sprintf("disk_usage_%s", mountpoint))
+// notify passes the events to the handlers. It is called without am.mu
+// held, so that handlers can use the alert manager.
+func notify(events []AlertEvent, handlers []func(AlertEvent)) {
+	for _, ev := range events {
+		for _, fn := range handlers {
+			fn(ev)
 		}
 	}
 }
@@ -0,0 +1,258 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+func TestCheckResourceAlerts(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+
+	steps := []struct {
+		name             string
+		cpu, mem, swap   float64
+		swapTotal        uint64
+		rootDisk, varDsk float64
+	}{
+		{"idle", 20, 40, 0, 1 << 30, 50, 50},
+		{"cpu spike", 92, 40, 0, 1 << 30, 50, 50},
+		{"cpu still high", 95, 40, 0, 1 << 30, 50, 50},
+		{"cpu inside hysteresis", 80, 40, 0, 1 << 30, 50, 50},
+		{"cpu recovered", 70, 40, 0, 1 << 30, 50, 50},
+		{"memory and swap pressure", 30, 90, 85, 1 << 30, 50, 50},
+		{"no swap configured", 30, 60, 85, 0, 50, 50},
+		{"disk filling", 30, 60, 0, 1 << 30, 91, 50},
+		{"disk inside hysteresis", 30, 60, 0, 1 << 30, 87, 50},
+		{"disk recovered", 30, 60, 0, 1 << 30, 84, 50},
+	}
+
+	var b strings.Builder
+	for _, step := range steps {
+		c := &Collector{
+			CPU: CPUInfo{Usage: step.cpu},
+			Memory: MemoryInfo{
+				UsedPercent: step.mem,
+				SwapTotal:   step.swapTotal,
+				SwapPercent: step.swap,
+			},
+			Disk: DiskInfo{UsageStats: map[string]*disk.UsageStat{
+				"/":    {UsedPercent: step.rootDisk},
+				"/var": {UsedPercent: step.varDsk},
+			}},
+		}
+		am.CheckResourceAlerts(c)
+
+		fmt.Fprintf(&b, "# %s\n", step.name)
+		for _, a := range am.Snapshot() {
+			fmt.Fprintf(&b, "%-8s %-20s %-8s count=%d peak=%.1f %s\n", a.Level, a.Source, a.State, a.Count, a.Peak, a.Message)
+		}
+	}
+
+	assertGolden(t, "alerts", []byte(b.String()))
+}
+
+func TestRecordEventTrimsToMaxAlerts(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 3)
+	for i := 0; i < 5; i++ {
+		am.RecordEvent(fmt.Sprintf("alert %d", i), InfoLevel, fmt.Sprintf("source_%d", i))
+	}
+
+	alerts := am.Snapshot()
+	if len(alerts) != 3 {
+		t.Fatalf("got %d alerts, want 3", len(alerts))
+	}
+	if alerts[0].Message != "alert 4" {
+		t.Errorf("newest alert = %q, want %q", alerts[0].Message, "alert 4")
+	}
+}
+
+func TestCheckPressureAlerts(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	if err := am.SetRules(DefaultAlertRules(AlertThresholds{MemoryPressure: 20})); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+
+	steps := []struct {
+		name       string
+		some, full float64
+		want       []string // memory pressure alerts, newest first
+	}{
+		{"calm", 5, 0, nil},
+		{"some tasks stalled", 25, 5, []string{"memory_pressure warning"}},
+		{"all tasks stalled", 30, 22, []string{"memory_pressure_full critical", "memory_pressure warning"}},
+		{"inside hysteresis", 15, 2, []string{"memory_pressure_full critical resolved", "memory_pressure warning"}},
+		{"recovered", 8, 0, []string{"memory_pressure_full critical resolved", "memory_pressure warning resolved"}},
+	}
+	for _, step := range steps {
+		c := &Collector{Pressure: PressureInfo{
+			Available: true,
+			CPU:       Pressure{Some: PressureStats{Avg10: 90}}, // no CPU threshold set
+			Memory:    Pressure{Some: PressureStats{Avg10: step.some}, Full: PressureStats{Avg10: step.full}},
+		}}
+		am.CheckResourceAlerts(c)
+
+		var got []string
+		for _, a := range am.Snapshot() {
+			switch {
+			case strings.HasPrefix(a.Source, "cpu_pressure"):
+				t.Fatalf("%s: CPU pressure alert without a threshold", step.name)
+			case strings.HasPrefix(a.Source, "memory_pressure"):
+				state := a.Source + " " + string(a.Level)
+				if a.Resolved {
+					state += " resolved"
+				}
+				got = append(got, state)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Errorf("%s: memory pressure alerts = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestAlertEvents(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	var got []string
+	am.Subscribe(func(ev AlertEvent) {
+		got = append(got, fmt.Sprintf("%s resolved=%t", ev.Alert.Source, ev.Alert.Resolved))
+	})
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, usage := range []float64{50, 90, 95, 80, 70, 90} {
+		am.CheckResourceAlerts(&Collector{
+			System: SystemInfo{LastUpdated: start.Add(time.Duration(i) * time.Second)},
+			Memory: MemoryInfo{UsedPercent: usage},
+		})
+	}
+
+	// Repeated checks while firing raise no events
+	want := "[memory_usage resolved=false memory_usage resolved=true memory_usage resolved=false]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("events = %v, want %s", got, want)
+	}
+}
+
+func TestAlertLifecycle(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	if err := am.SetRules([]AlertRule{{Name: "memory_usage", Metric: "mem.used_percent", Op: ">=", Threshold: 85, For: 2 * time.Second}}); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	var events []string
+	am.Subscribe(func(ev AlertEvent) {
+		events = append(events, fmt.Sprintf("%s %s", ev.Alert.ID, ev.Alert.State))
+	})
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	check := func(at int, usage float64) []Alert {
+		am.CheckResourceAlerts(&Collector{
+			System: SystemInfo{LastUpdated: start.Add(time.Duration(at) * time.Second)},
+			Memory: MemoryInfo{UsedPercent: usage},
+		})
+		return am.Snapshot()
+	}
+
+	// A pending alert that clears before its duration is dropped silently
+	if alerts := check(0, 90); len(alerts) != 1 || alerts[0].State != AlertPending || alerts[0].NeedsAttention() {
+		t.Fatalf("after first check: %+v", alerts)
+	}
+	if alerts := check(1, 50); len(alerts) != 0 {
+		t.Fatalf("pending alert kept after it cleared: %+v", alerts)
+	}
+
+	alerts := check(2, 90)
+	id := alerts[0].ID
+	check(3, 95)
+	alerts = check(4, 88)
+	a := alerts[0]
+	if a.ID != id || a.State != AlertFiring || a.Count != 3 || a.Peak != 95 || a.Value != 88 {
+		t.Errorf("firing alert = %+v", a)
+	}
+	if !a.FirstSeen.Equal(start.Add(2*time.Second)) || !a.Timestamp.Equal(start.Add(4*time.Second)) || !a.LastSeen.Equal(start.Add(4*time.Second)) {
+		t.Errorf("first seen %s, fired %s, last seen %s", a.FirstSeen, a.Timestamp, a.LastSeen)
+	}
+
+	alerts = check(5, 50)
+	a = alerts[0]
+	if a.ID != id || !a.Resolved || a.State != AlertResolved || !a.ResolvedAt.Equal(start.Add(5*time.Second)) {
+		t.Errorf("resolved alert = %+v", a)
+	}
+	if got := a.Duration(start.Add(time.Hour)); got != time.Second {
+		t.Errorf("Duration = %s, want 1s", got)
+	}
+
+	// Firing again raises a new alert
+	check(6, 90)
+	alerts = check(8, 90)
+	if len(alerts) != 2 || alerts[0].ID == id || alerts[1].ID != id {
+		t.Errorf("alerts after firing again: %+v", alerts)
+	}
+
+	want := fmt.Sprint([]string{id + " firing", id + " resolved", alerts[0].ID + " firing"})
+	if fmt.Sprint(events) != want {
+		t.Errorf("events = %v, want %s", events, want)
+	}
+}
+
+func TestAlertTrimKeepsActive(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 2)
+	var events []string
+	am.Subscribe(func(ev AlertEvent) {
+		events = append(events, fmt.Sprintf("%s %s", ev.Alert.ID, ev.Alert.State))
+	})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	check := func(at int, usage float64) {
+		am.CheckResourceAlerts(&Collector{
+			System: SystemInfo{LastUpdated: start.Add(time.Duration(at) * time.Second)},
+			Memory: MemoryInfo{UsedPercent: usage},
+		})
+	}
+
+	check(0, 90)
+	id := am.Snapshot()[0].ID
+	for i := 0; i < 3; i++ {
+		am.RecordEvent(fmt.Sprintf("Sent SIGTERM to %d", 100+i), InfoLevel, SourceSignal)
+	}
+	// The firing alert outlives the events
+	alerts := am.Snapshot()
+	if len(alerts) != 2 || alerts[1].ID != id || alerts[1].State != AlertFiring {
+		t.Fatalf("alerts after events: %+v", alerts)
+	}
+
+	check(1, 90)
+	check(2, 50)
+	want := fmt.Sprint([]string{id + " firing", id + " resolved"})
+	if fmt.Sprint(events) != want {
+		t.Errorf("events = %v, want %s", events, want)
+	}
+}
+
+func TestAlertRaisedAgainWhileFiring(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	var events []string
+	am.Subscribe(func(ev AlertEvent) {
+		events = append(events, string(ev.Alert.State))
+	})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	check := func(at int, usage float64) {
+		am.CheckResourceAlerts(&Collector{
+			System: SystemInfo{LastUpdated: start.Add(time.Duration(at) * time.Second)},
+			Memory: MemoryInfo{UsedPercent: usage},
+		})
+	}
+
+	check(0, 90)
+	am.ResolveAlert("memory_usage")
+	// The rule still fires, so the alert comes back firing rather than pending
+	check(1, 90)
+	if a := am.Snapshot()[0]; a.State != AlertFiring {
+		t.Errorf("alert raised again = %+v", a)
+	}
+	check(2, 50)
+	if want := "[firing firing resolved]"; fmt.Sprint(events) != want {
+		t.Errorf("events = %v, want %s", events, want)
+	}
+}
@@ -0,0 +1,328 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SourceCgroup is the name of the cgroup source
+const SourceCgroup = "cgroup"
+
+// CgroupInfo holds the cgroup v2 hierarchy
+type CgroupInfo struct {
+	Available bool          // false when no cgroup v2 hierarchy is mounted
+	Groups    []CgroupStats // depth first from the root, siblings by name
+}
+
+// CgroupStats holds the resource usage and limits of a cgroup. Limits of 0
+// mean unlimited; rates and throttling are measured since the previous
+// cycle and are 0 on the first.
+type CgroupStats struct {
+	Path  string // relative to the hierarchy root; "/" is the root
+	Depth int
+
+	CPUPercent       float64 // CPU usage in percent of one core
+	CPUUsage         time.Duration
+	CPUQuota         float64 // cores allowed by cpu.max
+	Periods          uint64  // enforcement periods elapsed
+	Throttled        uint64  // periods in which the cgroup was throttled
+	ThrottledPercent float64 // share of the periods of the interval that were throttled
+	ThrottledTime    time.Duration
+
+	MemoryCurrent uint64
+	MemoryMax     uint64
+	OOM           uint64 // times memory.max was hit and reclaim failed
+	OOMKills      uint64 // processes killed by the OOM killer
+
+	IORead      uint64 // bytes read from block devices
+	IOWrite     uint64 // bytes written to block devices
+	IOReadRate  float64
+	IOWriteRate float64
+
+	PidsCurrent uint64
+	PidsMax     uint64
+
+	CPUPressure    Pressure
+	MemoryPressure Pressure
+	IOPressure     Pressure
+}
+
+// Name returns the last element of the path, or "/" for the root
+func (g *CgroupStats) Name() string {
+	if g.Path == "/" {
+		return "/"
+	}
+	return filepath.Base(g.Path)
+}
+
+// MemoryPercent returns the memory usage in percent of memory.max, or 0
+// when the memory is not limited
+func (g *CgroupStats) MemoryPercent() float64 {
+	if g.MemoryMax == 0 {
+		return 0
+	}
+	return 100 * float64(g.MemoryCurrent) / float64(g.MemoryMax)
+}
+
+// Find returns the cgroup with the given path
+func (c *CgroupInfo) Find(path string) (CgroupStats, bool) {
+	for _, g := range c.Groups {
+		if g.Path == path {
+			return g, true
+		}
+	}
+	return CgroupStats{}, false
+}
+
+// CgroupSource reads the cgroup v2 hierarchy below sys/fs/cgroup. Only
+// Linux has cgroups; elsewhere the hierarchy is not found and the source
+// reports none.
+type CgroupSource struct {
+	BaseSource
+	prev       map[string]CgroupStats
+	lastSample time.Time
+}
+
+// NewCgroupSource creates the cgroup source
+func NewCgroupSource() *CgroupSource {
+	return &CgroupSource{BaseSource: BaseSource{SourceName: SourceCgroup}}
+}
+
+// Collect walks the hierarchy and reads the statistics of every cgroup
+func (s *CgroupSource) Collect(ctx context.Context) (Update, error) {
+	var info CgroupInfo
+	root := findCgroupRoot(ctx)
+	if root == "" {
+		return func(c *Collector) { c.Cgroups = info }, nil
+	}
+	info.Available = true
+
+	now := CollectTime(ctx)
+	err := walkCgroups(ctx, root, "/", 0, func(dir, path string, depth int) {
+		info.Groups = append(info.Groups, readCgroupStats(dir, path, depth))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Rates and throttling over the interval
+	if s.prev != nil {
+		interval := rateInterval(s.lastSample, now)
+		for i := range info.Groups {
+			g := &info.Groups[i]
+			prev, ok := s.prev[g.Path]
+			if !ok {
+				continue
+			}
+			if g.CPUUsage >= prev.CPUUsage {
+				g.CPUPercent = 100 * (g.CPUUsage - prev.CPUUsage).Seconds() / interval
+			}
+			if g.Periods > prev.Periods && g.Throttled >= prev.Throttled {
+				g.ThrottledPercent = 100 * float64(g.Throttled-prev.Throttled) / float64(g.Periods-prev.Periods)
+			}
+			if g.IORead >= prev.IORead {
+				g.IOReadRate = float64(g.IORead-prev.IORead) / interval
+			}
+			if g.IOWrite >= prev.IOWrite {
+				g.IOWriteRate = float64(g.IOWrite-prev.IOWrite) / interval
+			}
+		}
+	}
+	s.prev = make(map[string]CgroupStats, len(info.Groups))
+	for _, g := range info.Groups {
+		s.prev[g.Path] = g
+	}
+	s.lastSample = now
+
+	return func(c *Collector) {
+		c.Cgroups = info
+	}, nil
+}
+
+// findCgroupRoot returns the directory of the cgroup v2 hierarchy: sys/fs/cgroup,
+// or its unified subdirectory on hybrid v1/v2 systems. It returns "" when
+// there is none.
+func findCgroupRoot(ctx context.Context) string {
+	for _, dir := range []string{hostSys(ctx, "fs", "cgroup"), hostSys(ctx, "fs", "cgroup", "unified")} {
+		if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+// walkCgroups calls fn for the cgroup in dir and, depth first, for every
+// cgroup below it, visiting siblings in name order. The walk stops with the
+// context's error once ctx is done, as hosts may have thousands of cgroups.
+func walkCgroups(ctx context.Context, dir, path string, depth int, fn func(dir, path string, depth int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fn(dir, path, depth)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if depth > 0 {
+			return nil // removed while walking
+		}
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if err := walkCgroups(ctx, filepath.Join(dir, e.Name()), filepath.Join(path, e.Name()), depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCgroupStats reads the interface files of a cgroup. Files that do not
+// exist, such as the limits of the root cgroup or those of disabled
+// controllers, leave their values at 0.
+func readCgroupStats(dir, path string, depth int) CgroupStats {
+	g := CgroupStats{Path: path, Depth: depth}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	cpuStat := parseKeyValues(read("cpu.stat"))
+	g.CPUUsage = time.Duration(cpuStat["usage_usec"]) * time.Microsecond
+	g.Periods = cpuStat["nr_periods"]
+	g.Throttled = cpuStat["nr_throttled"]
+	g.ThrottledTime = time.Duration(cpuStat["throttled_usec"]) * time.Microsecond
+	g.CPUQuota = parseCPUMax(read("cpu.max"))
+
+	g.MemoryCurrent = parseLimit(read("memory.current"))
+	g.MemoryMax = parseLimit(read("memory.max"))
+	events := parseKeyValues(read("memory.events"))
+	g.OOM, g.OOMKills = events["oom"], events["oom_kill"]
+
+	g.IORead, g.IOWrite = parseIOStat(read("io.stat"))
+
+	g.PidsCurrent = parseLimit(read("pids.current"))
+	g.PidsMax = parseLimit(read("pids.max"))
+
+	g.CPUPressure = parsePressure(read("cpu.pressure"))
+	g.MemoryPressure = parsePressure(read("memory.pressure"))
+	g.IOPressure = parsePressure(read("io.pressure"))
+	return g
+}
+
+// parseKeyValues parses "key value" lines such as those of cpu.stat and
+// memory.events
+func parseKeyValues(data string) map[string]uint64 {
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values
+}
+
+// parseLimit parses a single value file; "max" and unreadable values are 0
+func parseLimit(data string) uint64 {
+	v, err := strconv.ParseUint(strings.TrimSpace(data), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// parseCPUMax returns the cores allowed by a cpu.max file ("$MAX $PERIOD"),
+// or 0 when unlimited
+func parseCPUMax(data string) float64 {
+	fields := strings.Fields(data)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// parseIOStat sums the bytes read and written over the devices of io.stat
+func parseIOStat(data string) (read, write uint64) {
+	for _, line := range strings.Split(data, "\n") {
+		for _, field := range strings.Fields(line) {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbytes":
+				read += v
+			case "wbytes":
+				write += v
+			}
+		}
+	}
+	return read, write
+}
+
+// parseProcCgroup returns the cgroup of a process from the contents of
+// /proc/<pid>/cgroup: the unified (v2) path, or on cgroup v1 the path in
+// the systemd hierarchy, falling back to the first hierarchy listed
+func parseProcCgroup(data string) string {
+	var first, systemd string
+	for _, line := range strings.Split(data, "\n") {
+		// hierarchy-ID:controller-list:path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		switch {
+		case fields[0] == "0" && fields[1] == "":
+			return fields[2]
+		case fields[1] == "name=systemd":
+			systemd = fields[2]
+		case first == "":
+			first = fields[2]
+		}
+	}
+	if systemd != "" {
+		return systemd
+	}
+	return first
+}
+
+// CgroupUnit returns the systemd unit of a cgroup path: its innermost
+// service or scope, else its innermost slice. Paths outside systemd are
+// returned unchanged.
+func CgroupUnit(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for _, suffixes := range [][]string{{".service", ".scope"}, {".slice"}} {
+		for i := len(parts) - 1; i >= 0; i-- {
+			for _, suffix := range suffixes {
+				if strings.HasSuffix(parts[i], suffix) {
+					return parts[i]
+				}
+			}
+		}
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProcCgroup(t *testing.T) {
+	tests := []struct{ data, want string }{
+		{"0::/system.slice/sshd.service\n", "/system.slice/sshd.service"},
+		{"12:cpu,cpuacct:/user.slice\n1:name=systemd:/user.slice/user-1000.slice/session-2.scope\n", "/user.slice/user-1000.slice/session-2.scope"},
+		{"4:memory:/docker/abc\n", "/docker/abc"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseProcCgroup(tt.data); got != tt.want {
+			t.Errorf("parseProcCgroup(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseCgroupFiles(t *testing.T) {
+	for data, want := range map[string]float64{"max 100000\n": 0, "50000 100000\n": 0.5, "200000 100000": 2, "": 0} {
+		if got := parseCPUMax(data); got != want {
+			t.Errorf("parseCPUMax(%q) = %v, want %v", data, got, want)
+		}
+	}
+	if got := parseLimit("max\n"); got != 0 {
+		t.Errorf(`parseLimit("max") = %d, want 0`, got)
+	}
+	read, write := parseIOStat("8:0 rbytes=100 wbytes=20 rios=1 wios=1\n8:16 rbytes=5 wbytes=1 rios=1 wios=1\n")
+	if read != 105 || write != 21 {
+		t.Errorf("parseIOStat = %d, %d; want 105, 21", read, write)
+	}
+}
+
+func TestCgroupUnit(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"/system.slice/postgresql@14-main.service", "postgresql@14-main.service"},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-foo-1.scope", "app-foo-1.scope"},
+		{"/user.slice/user-1000.slice", "user-1000.slice"},
+		{"/kubepods/burstable/pod1/abc", "/kubepods/burstable/pod1/abc"},
+	}
+	for _, tt := range tests {
+		if got := CgroupUnit(tt.path); got != tt.want {
+			t.Errorf("CgroupUnit(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWalkCgroupsCancel(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"system.slice/a.service", "system.slice/b.service", "user.slice"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancelled after the second cgroup
+	ctx, cancel := context.WithCancel(context.Background())
+	var visited []string
+	err := walkCgroups(ctx, root, "/", 0, func(dir, path string, depth int) {
+		visited = append(visited, path)
+		if len(visited) == 2 {
+			cancel()
+		}
+	})
+	if err != context.Canceled || len(visited) != 2 {
+		t.Errorf("walk = %v after %v, want %v after 2 cgroups", err, visited, context.Canceled)
+	}
+}
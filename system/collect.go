@@ -0,0 +1,173 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+// CollectReport describes the outcome of a collection cycle
+type CollectReport struct {
+	Started  time.Time
+	Duration time.Duration
+	TimedOut []string          // sources that missed their deadline or were still busy
+	Errors   map[string]string // error messages of sources that failed
+}
+
+// collectTimeKey is the context key holding the start time of a cycle
+type collectTimeKey struct{}
+
+// withCollectTime records the start time of a collection cycle on ctx
+func withCollectTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, collectTimeKey{}, t)
+}
+
+// CollectTime returns the start time of the collection cycle a source is
+// running in. Sources should use it rather than time.Now for rate
+// calculations so that every source in a cycle agrees on the sample time.
+func CollectTime(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(collectTimeKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// sourceResult carries the outcome of a single source run
+type sourceResult struct {
+	name   string
+	update Update
+	err    error
+}
+
+// collectCycle tracks the sources started by one call to Collect. Once the
+// cycle is abandoned, results from sources that are still running are kept
+// for the next cycle instead.
+type collectCycle struct {
+	done      chan sourceResult
+	abandoned bool
+}
+
+// SourceTimeout returns how long the collector waits for the named source.
+// Unless overridden in SourceTimeouts, sources get one refresh interval.
+func (c *Collector) SourceTimeout(name string) time.Duration {
+	if timeout, ok := c.SourceTimeouts[name]; ok && timeout > 0 {
+		return timeout
+	}
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return time.Second
+}
+
+// runSources polls every enabled source in parallel and applies the results
+// that arrive before their deadline. Sources that are still running from an
+// earlier cycle are skipped and reported as timed out; their results are
+// applied once they arrive.
+func (c *Collector) runSources(ctx context.Context, report *CollectReport) {
+	report.Errors = make(map[string]string)
+
+	// Apply results from sources that finished after the previous deadline
+	c.runMu.Lock()
+	late := c.late
+	c.late = nil
+	c.runMu.Unlock()
+	for _, res := range late {
+		c.applyResult(res, report)
+	}
+
+	cycle := &collectCycle{done: make(chan sourceResult, len(c.sources))}
+	waiting := make(map[string]bool)
+	var wait time.Duration
+
+	c.runMu.Lock()
+	if c.inflight == nil {
+		c.inflight = make(map[string]bool)
+	}
+	for _, src := range c.sources {
+		if !src.Enabled() {
+			continue
+		}
+		name := src.Name()
+		if c.inflight[name] {
+			report.TimedOut = append(report.TimedOut, name)
+			continue
+		}
+		timeout := c.SourceTimeout(name)
+		if timeout > wait {
+			wait = timeout
+		}
+		c.inflight[name] = true
+		waiting[name] = true
+		go c.runSource(ctx, src, timeout, cycle)
+	}
+	c.runMu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for len(waiting) > 0 {
+		select {
+		case res := <-cycle.done:
+			delete(waiting, res.name)
+			c.applyResult(res, report)
+
+		case <-timer.C:
+			c.runMu.Lock()
+			cycle.abandoned = true
+			c.runMu.Unlock()
+
+			// Results may have been delivered while the timer fired
+			for drained := false; !drained; {
+				select {
+				case res := <-cycle.done:
+					delete(waiting, res.name)
+					c.applyResult(res, report)
+				default:
+					drained = true
+				}
+			}
+			for name := range waiting {
+				report.TimedOut = append(report.TimedOut, name)
+			}
+			waiting = nil
+		}
+	}
+
+	sort.Strings(report.TimedOut)
+}
+
+// runSource collects a single source and hands the result to its cycle, or
+// keeps it for the next cycle if the collector stopped waiting
+func (c *Collector) runSource(ctx context.Context, src Source, timeout time.Duration, cycle *collectCycle) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	update, err := src.Collect(ctx)
+	res := sourceResult{name: src.Name(), update: update, err: err}
+
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	delete(c.inflight, res.name)
+	if cycle.abandoned {
+		c.late = append(c.late, res)
+		return
+	}
+	cycle.done <- res
+}
+
+// applyResult stores a source result on the collector and records failures
+func (c *Collector) applyResult(res sourceResult, report *CollectReport) {
+	if res.err != nil {
+		if errors.Is(res.err, context.DeadlineExceeded) {
+			report.TimedOut = append(report.TimedOut, res.name)
+		} else {
+			report.Errors[res.name] = res.err.Error()
+			log.Printf("Warning: Failed to collect %s info: %v", res.name, res.err)
+		}
+	}
+	if res.update != nil {
+		res.update(c)
+	}
+}
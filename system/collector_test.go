@@ -0,0 +1,229 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// fixtureUsage stands in for statfs, which cannot be redirected to a fixture tree
+var fixtureUsage = map[string]*disk.UsageStat{
+	"/":    {Path: "/", Fstype: "ext4", Total: 100 << 30, Used: 40 << 30, Free: 60 << 30, UsedPercent: 40},
+	"/var": {Path: "/var", Fstype: "xfs", Total: 500 << 30, Used: 475 << 30, Free: 25 << 30, UsedPercent: 95},
+}
+
+// newFixtureCollector returns a collector reading the recorded host tree in
+// testdata/host/<frame> with a fake clock advanced by advance()
+func newFixtureCollector(t *testing.T, frame string) (*Collector, func(time.Duration)) {
+	t.Helper()
+	c := NewCollector(60, 85, 90, 80, 1000, "pid", 15, 100)
+	c.Root = filepath.Join("testdata", "host", frame)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.Clock = func() time.Time { return now }
+
+	c.Source(SourceDisk).(*DiskSource).Usage = func(ctx context.Context, path string) (*disk.UsageStat, error) {
+		if u, ok := fixtureUsage[path]; ok {
+			return u, nil
+		}
+		return nil, fmt.Errorf("no usage recorded for %s", path)
+	}
+
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+// dumpSnapshot renders the deterministic parts of a snapshot as text.
+// Hostname, uptime, swap, process start times and lifetime CPU are
+// read from the live system by gopsutil and are left out, as is the CPU%
+// of a process's first sample, which is its lifetime average.
+func dumpSnapshot(s *Snapshot) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "system platform=%s os=%s\n", s.System.Platform, s.System.OS)
+	fmt.Fprintf(&b, "cpu usage=%.2f cores=%d per_cpu=%.2f", s.CPU.Usage, s.CPU.Cores, s.CPU.UsagePerCPU)
+	if s.CPU.LoadAvg != nil {
+		fmt.Fprintf(&b, " load=%.2f/%.2f/%.2f", s.CPU.LoadAvg.Load1, s.CPU.LoadAvg.Load5, s.CPU.LoadAvg.Load15)
+	}
+	fmt.Fprintf(&b, " history=%d\n", len(s.CPU.History.Points))
+	fmt.Fprintf(&b, "memory total=%d used=%d free=%d used_percent=%.2f history=%d\n",
+		s.Memory.Total, s.Memory.Used, s.Memory.Free, s.Memory.UsedPercent, len(s.Memory.History.Points))
+
+	for _, p := range s.Disk.Partitions {
+		fmt.Fprintf(&b, "partition %s %s %s", p.Device, p.Mountpoint, p.Fstype)
+		if u, ok := s.Disk.UsageStats[p.Mountpoint]; ok {
+			fmt.Fprintf(&b, " used_percent=%.1f", u.UsedPercent)
+		}
+		b.WriteString("\n")
+	}
+	for _, name := range sortedNames(s.Disk.IOCounters) {
+		io := s.Disk.IOCounters[name]
+		fmt.Fprintf(&b, "disk_io %s read=%d write=%d read_rate=%.0f write_rate=%.0f\n",
+			name, io.ReadBytes, io.WriteBytes, s.Disk.ReadRate[name], s.Disk.WriteRate[name])
+	}
+	for _, name := range sortedNames(s.Network.IOCounters) {
+		io := s.Network.IOCounters[name]
+		fmt.Fprintf(&b, "net_io %s recv=%d sent=%d recv_rate=%.0f sent_rate=%.0f\n",
+			name, io.BytesRecv, io.BytesSent, s.Network.RecvRate[name], s.Network.SentRate[name])
+	}
+
+	fmt.Fprintf(&b, "processes total=%d sort=%s\n", s.Process.Total, s.Process.SortBy)
+	for _, p := range s.Process.Processes {
+		fmt.Fprintf(&b, "process pid=%d ppid=%d name=%s user=%s status=%s nice=%d threads=%d mem=%.2f rss=%d vms=%d cgroup=%s cmd=%q\n",
+			p.PID, p.PPID, p.Name, p.Username, strings.Join(p.Status, ","), p.Nice, p.NumThreads,
+			p.MemPercent, p.MemRSS, p.MemVMS, p.Cgroup, p.CmdLine)
+		if h, ok := s.Process.HistoryOf(p); ok {
+			fmt.Fprintf(&b, "process_history pid=%d cpu=%d rss=%d read_rate=%v write_rate=%v\n",
+				p.PID, len(h.CPU.Points), len(h.RSS.Points), seriesValues(h.ReadRate), seriesValues(h.WriteRate))
+			if len(h.CPU.Points) > 1 {
+				fmt.Fprintf(&b, "process_cpu pid=%d interval=%.1f\n", p.PID, p.CPUPercent)
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "cgroups available=%t\n", s.Cgroups.Available)
+	for _, g := range s.Cgroups.Groups {
+		fmt.Fprintf(&b, "cgroup %s depth=%d cpu=%.1f quota=%.1f throttled=%d/%d throttled_percent=%.1f mem=%d/%d oom=%d oom_kill=%d io=%d/%d io_rate=%.0f/%.0f pids=%d/%d\n",
+			g.Path, g.Depth, g.CPUPercent, g.CPUQuota, g.Throttled, g.Periods, g.ThrottledPercent,
+			g.MemoryCurrent, g.MemoryMax, g.OOM, g.OOMKills, g.IORead, g.IOWrite, g.IOReadRate, g.IOWriteRate,
+			g.PidsCurrent, g.PidsMax)
+		fmt.Fprintf(&b, "cgroup_pressure %s cpu=%s memory=%s io=%s\n",
+			g.Path, formatPressure(g.CPUPressure), formatPressure(g.MemoryPressure), formatPressure(g.IOPressure))
+	}
+
+	fmt.Fprintf(&b, "pressure available=%t cpu=%s memory=%s io=%s history=%v/%v/%v\n", s.Pressure.Available,
+		formatPressure(s.Pressure.CPU), formatPressure(s.Pressure.Memory), formatPressure(s.Pressure.IO),
+		seriesValues(s.Pressure.CPUHistory), seriesValues(s.Pressure.MemoryHistory), seriesValues(s.Pressure.IOHistory))
+
+	for _, a := range s.Alerts {
+		fmt.Fprintf(&b, "alert %s %s resolved=%t %q\n", a.Level, a.Source, a.Resolved, a.Message)
+	}
+
+	return b.String()
+}
+
+// formatPressure renders the some and full averages of a PSI resource
+func formatPressure(p Pressure) string {
+	return fmt.Sprintf("%.2f/%.2f/%.2f,%.2f/%.2f/%.2f",
+		p.Some.Avg10, p.Some.Avg60, p.Some.Avg300, p.Full.Avg10, p.Full.Avg60, p.Full.Avg300)
+}
+
+// seriesValues returns the values of a time series
+func seriesValues(ts TimeSeries) []float64 {
+	values := make([]float64, len(ts.Points))
+	for i, p := range ts.Points {
+		values[i] = p.Value
+	}
+	return values
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCollectorFixture(t *testing.T) {
+	c, advance := newFixtureCollector(t, "frame1")
+
+	first, err := c.Collect()
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	// The second frame is recorded two seconds later
+	c.Root = filepath.Join("testdata", "host", "frame2")
+	advance(2 * time.Second)
+
+	second, err := c.Collect()
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	got := "# frame1\n" + dumpSnapshot(first) + "# frame2\n" + dumpSnapshot(second)
+	assertGolden(t, "collector", []byte(got))
+
+	if len(first.CPU.History.Points) != 1 {
+		t.Errorf("first snapshot history changed after later collection: %d points", len(first.CPU.History.Points))
+	}
+	if len(second.Report.TimedOut) != 0 {
+		t.Errorf("unexpected timed out sources: %v", second.Report.TimedOut)
+	}
+}
+
+func TestCollectorDisabledSource(t *testing.T) {
+	c, _ := newFixtureCollector(t, "frame1")
+	if !c.SetSourceEnabled(SourceProcess, false) {
+		t.Fatal("process source not registered")
+	}
+
+	snap, err := c.Collect()
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(snap.Process.Processes) != 0 {
+		t.Errorf("disabled process source still collected %d processes", len(snap.Process.Processes))
+	}
+	if snap.Memory.Total == 0 {
+		t.Error("memory source did not run")
+	}
+}
+
+// blockingSource never finishes within its deadline
+type blockingSource struct {
+	BaseSource
+	release chan struct{}
+}
+
+func (s *blockingSource) Collect(ctx context.Context) (Update, error) {
+	<-s.release
+	return func(c *Collector) { c.System.Hostname = "late" }, nil
+}
+
+func TestCollectorSourceTimeout(t *testing.T) {
+	c, _ := newFixtureCollector(t, "frame1")
+	slow := &blockingSource{BaseSource: BaseSource{SourceName: "slow"}, release: make(chan struct{})}
+	if err := c.RegisterSource(slow); err != nil {
+		t.Fatalf("RegisterSource: %v", err)
+	}
+	if err := c.RegisterSource(slow); err == nil {
+		t.Error("registering a duplicate source name succeeded")
+	}
+	c.SourceTimeouts = map[string]time.Duration{"slow": 10 * time.Millisecond}
+
+	snap, err := c.Collect()
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if fmt.Sprint(snap.Report.TimedOut) != "[slow]" {
+		t.Errorf("TimedOut = %v, want [slow]", snap.Report.TimedOut)
+	}
+	if snap.Memory.Total == 0 {
+		t.Error("other sources were held up by the slow source")
+	}
+
+	// Still running: skipped and reported again
+	snap, _ = c.Collect()
+	if fmt.Sprint(snap.Report.TimedOut) != "[slow]" {
+		t.Errorf("TimedOut while busy = %v, want [slow]", snap.Report.TimedOut)
+	}
+
+	// Once it finishes, its result is applied on the next cycle
+	close(slow.release)
+	for i := 0; i < 100 && snap.System.Hostname != "late"; i++ {
+		time.Sleep(time.Millisecond)
+		c.SetSourceEnabled(SourceSystem, false)
+		snap, _ = c.Collect()
+	}
+	if snap.System.Hostname != "late" {
+		t.Errorf("late result was not applied, hostname = %q", snap.System.Hostname)
+	}
+}
@@ -0,0 +1,552 @@
+package system
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ProcessFilter selects processes with a query such as
+//
+//	user:postgres cpu>10 state:D name~^java
+//
+// A query is a list of terms that must all match. Terms can be combined
+// with OR (or |), negated with ! or NOT and grouped with parentheses. A
+// term is either field, operator and value, or a bare word matched against
+// the name, user and command line like the old substring filter.
+//
+// Text fields (name, user, cmd, state) support : (contains), = and !=
+// (equal, ignoring case), ~ and !~ (regular expression). Numeric fields
+// (pid, ppid, cpu, life, mem, rss, vms, threads, nice, read, write, age)
+// support :, =, !=, <, <=, > and >=. Sizes take K, M, G and T suffixes
+// and age takes durations such as 90s, 15m or 2d.
+type ProcessFilter struct {
+	query string
+	match predicate
+	now   func() time.Time
+}
+
+// predicate reports whether a process matches part of a query
+type predicate func(p *ProcessDetail, now time.Time) bool
+
+// ParseProcessFilter parses a filter query. An empty query returns nil,
+// which matches every process.
+func ParseProcessFilter(query string) (*ProcessFilter, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	tokens, err := lexFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokRParen {
+		return nil, fmt.Errorf("unexpected ) at position %d", tok.pos+1)
+	}
+	return &ProcessFilter{query: query, match: match, now: time.Now}, nil
+}
+
+// String returns the query the filter was parsed from
+func (f *ProcessFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.query
+}
+
+// Match reports whether a process matches the filter. A nil filter
+// matches every process.
+func (f *ProcessFilter) Match(p ProcessDetail) bool {
+	if f == nil {
+		return true
+	}
+	return f.match(&p, f.now())
+}
+
+// Filter returns the processes matching the filter
+func (f *ProcessFilter) Filter(processes []ProcessDetail) []ProcessDetail {
+	if f == nil {
+		return processes
+	}
+	now := f.now()
+	matched := make([]ProcessDetail, 0, len(processes))
+	for i := range processes {
+		if f.match(&processes[i], now) {
+			matched = append(matched, processes[i])
+		}
+	}
+	return matched
+}
+
+// Query tokens
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokTerm
+	tokLParen
+	tokRParen
+	tokNot
+	tokAnd
+	tokOr
+)
+
+// filterToken is a token of a filter query. Terms are split into field,
+// operator and value while lexing, since where a value ends depends on it.
+type filterToken struct {
+	kind  tokenKind
+	pos   int // rune offset in the query
+	field string
+	op    string // empty for a bare word
+	value string
+}
+
+// filterOps lists the operators, longest first so that "!=" wins over "!"
+var filterOps = []string{"!=", "!~", ">=", "<=", ":", "=", "~", ">", "<"}
+
+// lexFilter splits a query into tokens
+func lexFilter(query string) ([]filterToken, error) {
+	runes := []rune(query)
+	var tokens []filterToken
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokLParen, pos: i})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokRParen, pos: i})
+			i++
+			continue
+		case (r == '!' || r == '-') && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != '=':
+			tokens = append(tokens, filterToken{kind: tokNot, pos: i})
+			i++
+			continue
+		}
+
+		start := i
+		if r == '"' {
+			value, next, err := lexQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokTerm, pos: start, value: value})
+			i = next
+			continue
+		}
+
+		// A field name followed by an operator starts a field term
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || runes[j] == '_') {
+			j++
+		}
+		if op := opAt(runes, j); j > i && op != "" {
+			tok := filterToken{kind: tokTerm, pos: start, field: strings.ToLower(string(runes[i:j])), op: op}
+			i = j + len([]rune(op))
+			if i < len(runes) && runes[i] == '"' {
+				value, next, err := lexQuoted(runes, i)
+				if err != nil {
+					return nil, err
+				}
+				tok.value, i = value, next
+			} else {
+				// Parentheses inside a value, as in a regex group, are part of
+				// it; an unbalanced ) closes an enclosing group
+				depth, k := 0, i
+				for ; k < len(runes) && !unicode.IsSpace(runes[k]); k++ {
+					if runes[k] == '(' {
+						depth++
+					} else if runes[k] == ')' {
+						if depth == 0 {
+							break
+						}
+						depth--
+					}
+				}
+				tok.value, i = string(runes[i:k]), k
+			}
+			if tok.value == "" {
+				return nil, fmt.Errorf("missing value after %s%s", tok.field, tok.op)
+			}
+			tokens = append(tokens, tok)
+			continue
+		}
+
+		// Anything else is a keyword or a bare word
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+			i++
+		}
+		word := string(runes[start:i])
+		switch word {
+		case "AND", "&&":
+			tokens = append(tokens, filterToken{kind: tokAnd, pos: start})
+		case "OR", "||", "|":
+			tokens = append(tokens, filterToken{kind: tokOr, pos: start})
+		case "NOT":
+			tokens = append(tokens, filterToken{kind: tokNot, pos: start})
+		default:
+			tokens = append(tokens, filterToken{kind: tokTerm, pos: start, value: word})
+		}
+	}
+	return append(tokens, filterToken{kind: tokEOF, pos: len(runes)}), nil
+}
+
+// opAt returns the operator starting at runes[i], if any
+func opAt(runes []rune, i int) string {
+	for _, op := range filterOps {
+		if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// lexQuoted reads the double-quoted string starting at runes[i] and returns
+// its contents and the offset after the closing quote
+func lexQuoted(runes []rune, i int) (string, int, error) {
+	var b strings.Builder
+	for j := i + 1; j < len(runes); j++ {
+		switch runes[j] {
+		case '\\':
+			if j+1 < len(runes) {
+				j++
+				b.WriteRune(runes[j])
+			}
+		case '"':
+			return b.String(), j + 1, nil
+		default:
+			b.WriteRune(runes[j])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quote at position %d", i+1)
+}
+
+// filterParser builds a predicate from tokens by recursive descent:
+//
+//	or    = and { OR and }
+//	and   = unary { [AND] unary }
+//	unary = NOT unary | "(" or ")" | term
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(proc *ProcessDetail, now time.Time) bool {
+			return l(proc, now) || right(proc, now)
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokEOF, tokOr, tokRParen:
+			return left, nil
+		case tokAnd:
+			p.next()
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(proc *ProcessDetail, now time.Time) bool {
+			return l(proc, now) && right(proc, now)
+		}
+	}
+}
+
+func (p *filterParser) parseUnary() (predicate, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNot:
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(proc *ProcessDetail, now time.Time) bool { return !inner(proc, now) }, nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.pos+1)
+		}
+		return inner, nil
+	case tokTerm:
+		return parseTerm(tok)
+	case tokEOF:
+		return nil, fmt.Errorf("query ends where a term is expected")
+	}
+	return nil, fmt.Errorf("expected a term at position %d", tok.pos+1)
+}
+
+// textFields and numericFields map field names, including aliases, to the
+// values they read
+var textFields = map[string]func(p *ProcessDetail) string{
+	"name":    func(p *ProcessDetail) string { return p.Name },
+	"user":    func(p *ProcessDetail) string { return p.Username },
+	"cmd":     func(p *ProcessDetail) string { return p.CmdLine },
+	"cmdline": func(p *ProcessDetail) string { return p.CmdLine },
+	"cgroup":  func(p *ProcessDetail) string { return p.Cgroup },
+}
+
+// numericField reads a number from a process; ok is false when the value
+// is not available, in which case no comparison matches
+type numericField struct {
+	value func(p *ProcessDetail, now time.Time) (v float64, ok bool)
+	parse func(s string) (float64, error)
+}
+
+var numericFields = map[string]numericField{
+	"pid":     {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.PID), true }, parse: parseNumber},
+	"ppid":    {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.PPID), true }, parse: parseNumber},
+	"cpu":     {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return p.CPUPercent, true }, parse: parsePercent},
+	"life":    {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return p.CPULifetime, true }, parse: parsePercent},
+	"mem":     {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.MemPercent), true }, parse: parsePercent},
+	"rss":     {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.MemRSS), true }, parse: parseSize},
+	"vms":     {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.MemVMS), true }, parse: parseSize},
+	"threads": {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.NumThreads), true }, parse: parseNumber},
+	"nice":    {value: func(p *ProcessDetail, _ time.Time) (float64, bool) { return float64(p.Nice), true }, parse: parseNumber},
+	"read": {value: func(p *ProcessDetail, _ time.Time) (float64, bool) {
+		if p.IO == nil {
+			return 0, false
+		}
+		return float64(p.IO.ReadBytes), true
+	}, parse: parseSize},
+	"write": {value: func(p *ProcessDetail, _ time.Time) (float64, bool) {
+		if p.IO == nil {
+			return 0, false
+		}
+		return float64(p.IO.WriteBytes), true
+	}, parse: parseSize},
+	"age": {value: func(p *ProcessDetail, now time.Time) (float64, bool) {
+		if p.CreatedAt.IsZero() || p.CreatedAt.UnixMilli() == 0 {
+			return 0, false
+		}
+		return now.Sub(p.CreatedAt).Seconds(), true
+	}, parse: parseAge},
+}
+
+// fieldAliases maps alternative field names to their canonical names
+var fieldAliases = map[string]string{
+	"command":      "cmd",
+	"username":     "user",
+	"cpu_lifetime": "life",
+	"status":       "state",
+}
+
+// parseTerm builds the predicate of a single term
+func parseTerm(tok filterToken) (predicate, error) {
+	if tok.op == "" {
+		word := strings.ToLower(tok.value)
+		return func(p *ProcessDetail, _ time.Time) bool {
+			return strings.Contains(strings.ToLower(p.Name), word) ||
+				strings.Contains(strings.ToLower(p.Username), word) ||
+				strings.Contains(strings.ToLower(p.CmdLine), word)
+		}, nil
+	}
+
+	field := tok.field
+	if alias, ok := fieldAliases[field]; ok {
+		field = alias
+	}
+	if field == "state" {
+		return stateTerm(tok)
+	}
+	if get, ok := textFields[field]; ok {
+		return textTerm(tok, func(p *ProcessDetail) []string { return []string{get(p)} })
+	}
+	if nf, ok := numericFields[field]; ok {
+		return numericTerm(tok, nf)
+	}
+	return nil, fmt.Errorf("unknown field %q", tok.field)
+}
+
+// textTerm compares the strings read by get with the value of tok; the term
+// matches when any of them does
+func textTerm(tok filterToken, get func(p *ProcessDetail) []string) (predicate, error) {
+	value := strings.ToLower(tok.value)
+	var match func(s string) bool
+	switch tok.op {
+	case ":":
+		match = func(s string) bool { return strings.Contains(strings.ToLower(s), value) }
+	case "=", "!=":
+		match = func(s string) bool { return strings.EqualFold(s, tok.value) }
+	case "~", "!~":
+		re, err := regexp.Compile(tok.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression for %s: %v", tok.field, err)
+		}
+		match = re.MatchString
+	default:
+		return nil, fmt.Errorf("%s%s: text fields support :, =, !=, ~ and !~", tok.field, tok.op)
+	}
+
+	negate := strings.HasPrefix(tok.op, "!")
+	return func(p *ProcessDetail, _ time.Time) bool {
+		for _, s := range get(p) {
+			if match(s) {
+				return !negate
+			}
+		}
+		return negate
+	}, nil
+}
+
+// stateTerm matches the process state by word ("sleep") or ps letter ("S")
+func stateTerm(tok filterToken) (predicate, error) {
+	if tok.op == ":" {
+		tok.op = "="
+	}
+	return textTerm(tok, func(p *ProcessDetail) []string {
+		states := make([]string, 0, 2*len(p.Status))
+		for _, s := range p.Status {
+			states = append(states, s, stateLetter(s))
+		}
+		return states
+	})
+}
+
+// stateLetter returns the ps letter of a status word
+func stateLetter(status string) string {
+	switch status {
+	case "running":
+		return "R"
+	case "sleep":
+		return "S"
+	case "blocked":
+		return "D"
+	case "idle":
+		return "I"
+	case "stop":
+		return "T"
+	case "wait":
+		return "W"
+	case "zombie":
+		return "Z"
+	}
+	return status
+}
+
+// numericTerm compares a numeric field with the value of tok
+func numericTerm(tok filterToken, nf numericField) (predicate, error) {
+	want, err := nf.parse(tok.value)
+	if err != nil {
+		return nil, fmt.Errorf("%s%s%s: %v", tok.field, tok.op, tok.value, err)
+	}
+	var cmp func(v float64) bool
+	switch tok.op {
+	case ":", "=":
+		cmp = func(v float64) bool { return v == want }
+	case "!=":
+		cmp = func(v float64) bool { return v != want }
+	case ">":
+		cmp = func(v float64) bool { return v > want }
+	case ">=":
+		cmp = func(v float64) bool { return v >= want }
+	case "<":
+		cmp = func(v float64) bool { return v < want }
+	case "<=":
+		cmp = func(v float64) bool { return v <= want }
+	default:
+		return nil, fmt.Errorf("%s%s: numeric fields support :, =, !=, <, <=, > and >=", tok.field, tok.op)
+	}
+	return func(p *ProcessDetail, now time.Time) bool {
+		v, ok := nf.value(p, now)
+		return ok && cmp(v)
+	}, nil
+}
+
+// parseNumber parses a plain number
+func parseNumber(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return v, nil
+}
+
+// parsePercent parses a number with an optional % sign
+func parsePercent(s string) (float64, error) {
+	return parseNumber(strings.TrimSuffix(s, "%"))
+}
+
+// parseSize parses a byte count with an optional binary unit such as 512M
+// or 2GiB
+func parseSize(s string) (float64, error) {
+	upper := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+	multiplier := 1.0
+	if n := len(upper); n > 0 {
+		if i := strings.IndexByte("KMGT", upper[n-1]); i >= 0 {
+			multiplier = float64(uint64(1) << (10 * (i + 1)))
+			upper = upper[:n-1]
+		}
+	}
+	v, err := strconv.ParseFloat(upper, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	return v * multiplier, nil
+}
+
+// parseAge parses a duration such as 90s, 1h30m or 2d; plain numbers are
+// seconds
+func parseAge(s string) (float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if v, err := strconv.ParseFloat(days, 64); err == nil {
+			return v * 24 * 3600, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	return d.Seconds(), nil
+}
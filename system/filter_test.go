@@ -0,0 +1,106 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessFilter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	processes := []ProcessDetail{
+		{PID: 1, Name: "systemd", Username: "root", Status: []string{"sleep"}, CPUPercent: 0.1, MemRSS: 12 << 20,
+			NumThreads: 1, CmdLine: "/sbin/init splash", CreatedAt: now.Add(-72 * time.Hour)},
+		{PID: 4242, PPID: 1, Name: "postgres", Username: "postgres", Status: []string{"blocked"}, CPUPercent: 35, CPULifetime: 4,
+			MemPercent: 3, MemRSS: 240 << 20, NumThreads: 8, CmdLine: "postgres -D /var/lib/postgresql",
+			CreatedAt: now.Add(-2 * time.Hour), IO: &ProcessIO{ReadBytes: 3 << 30}, Cgroup: "/system.slice/postgresql.service"},
+		{PID: 4243, PPID: 4242, Name: "java", Username: "app", Status: []string{"running"}, CPUPercent: 60, CPULifetime: 20,
+			MemPercent: 12.5, MemRSS: 1 << 30, NumThreads: 40, Nice: 5, CmdLine: "/usr/bin/java -jar app.jar",
+			CreatedAt: now.Add(-10 * time.Minute)},
+	}
+
+	tests := []struct {
+		query string
+		want  string // matching PIDs
+	}{
+		{"", "1 4242 4243"},
+		{"post", "4242"},
+		{"user:postgres", "4242"},
+		{"user=POSTGRES", "4242"},
+		{"user:gres", "4242"},
+		{"user=gres", ""},
+		{"cpu>10", "4242 4243"},
+		{"cpu>=60%", "4243"},
+		{"life<5", "1 4242"},
+		{"state:D", "4242"},
+		{"state:sleep", "1"},
+		{"state!=R", "1 4242"},
+		{"name~^java", "4243"},
+		{"name!~^(java|postgres)$", "1"},
+		{"cmd:/usr/bin", "4243"},
+		{"user:postgres cpu>10 state:D", "4242"},
+		{"user:postgres AND cpu>50", ""},
+		{"user:root OR user:app", "1 4243"},
+		{"user:root | nice>0", "1 4243"},
+		{"!user:root", "4242 4243"},
+		{"-java", "1 4242"},
+		{"NOT (user:root OR user:app)", "4242"},
+		{"(name~^(java|systemd)$) threads>1", "4243"},
+		{"rss>=1G", "4243"},
+		{"rss<100MiB", "1"},
+		{"read>1g", "4242"},
+		{"read<1g", ""},
+		{"age>1d", "1"},
+		{"age<1h", "4243"},
+		{"pid=1 OR ppid:4242", "1 4243"},
+		{`cmd:"-jar app"`, "4243"},
+		{`"init splash"`, "1"},
+		{"cgroup:postgresql.service", "4242"},
+	}
+	for _, tt := range tests {
+		f, err := ParseProcessFilter(tt.query)
+		if err != nil {
+			t.Errorf("ParseProcessFilter(%q) = %v", tt.query, err)
+			continue
+		}
+		if f != nil {
+			f.now = func() time.Time { return now }
+		}
+		var pids []string
+		for _, p := range f.Filter(processes) {
+			pids = append(pids, fmt.Sprint(p.PID))
+		}
+		if got := strings.Join(pids, " "); got != tt.want {
+			t.Errorf("%q matched %q, want %q", tt.query, got, tt.want)
+		}
+		if got := f.String(); got != tt.query {
+			t.Errorf("String() = %q, want %q", got, tt.query)
+		}
+	}
+}
+
+func TestProcessFilterErrors(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"usr:postgres", `unknown field "usr"`},
+		{"cpu>", "missing value after cpu>"},
+		{"cpu>lots", `"lots" is not a number`},
+		{"cpu~1", "numeric fields support"},
+		{"name>java", "text fields support"},
+		{"name~(java", "invalid regular expression"},
+		{"(user:root", "missing )"},
+		{"user:root)", "unexpected )"},
+		{"user:root OR", "query ends where a term is expected"},
+		{"OR user:root", "expected a term at position 1"},
+		{`cmd:"open`, "unterminated quote"},
+		{"age>soon", `"soon" is not a duration`},
+	}
+	for _, tt := range tests {
+		_, err := ParseProcessFilter(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseProcessFilter(%q) = %v, want an error containing %q", tt.query, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package system
+
+import "sort"
+
+// GroupBy selects how processes are aggregated
+type GroupBy string
+
+// Process grouping modes
+const (
+	GroupByNone   GroupBy = ""
+	GroupByUser   GroupBy = "user"
+	GroupByName   GroupBy = "name"
+	GroupByCgroup GroupBy = "cgroup" // systemd unit, or the cgroup path outside systemd
+)
+
+// ProcessGroup aggregates the processes that share a user, name or cgroup
+type ProcessGroup struct {
+	Key         string
+	Members     []ProcessDetail // in process list order
+	CPUPercent  float64
+	CPULifetime float64
+	MemPercent  float32
+	MemRSS      uint64
+	MemVMS      uint64
+	NumThreads  int32
+	IO          *ProcessIO // sum over the members whose counters could be read
+}
+
+// Key returns the group a process belongs to
+func (by GroupBy) Key(p *ProcessDetail) string {
+	switch by {
+	case GroupByUser:
+		return p.Username
+	case GroupByName:
+		return p.Name
+	case GroupByCgroup:
+		if p.Cgroup == "" {
+			return "unknown"
+		}
+		return CgroupUnit(p.Cgroup)
+	}
+	return ""
+}
+
+// Summary returns the group as a process carrying the summed values, with
+// the key as its name, for sorting and display
+func (g *ProcessGroup) Summary(by GroupBy) ProcessDetail {
+	p := ProcessDetail{
+		Name:        g.Key,
+		CPUPercent:  g.CPUPercent,
+		CPULifetime: g.CPULifetime,
+		MemPercent:  g.MemPercent,
+		MemRSS:      g.MemRSS,
+		MemVMS:      g.MemVMS,
+		NumThreads:  g.NumThreads,
+		IO:          g.IO,
+	}
+	switch by {
+	case GroupByUser:
+		p.Username = g.Key
+	case GroupByCgroup:
+		p.Cgroup = g.Key
+	}
+	return p
+}
+
+// GroupProcesses aggregates processes by the given mode. Groups are sorted
+// by their summed values like processes are; sort types without a sum,
+// such as PID, order groups by key.
+func GroupProcesses(processes []ProcessDetail, by GroupBy, sortBy SortType) []ProcessGroup {
+	index := make(map[string]int)
+	var groups []ProcessGroup
+	for _, p := range processes {
+		key := by.Key(&p)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ProcessGroup{Key: key})
+		}
+		g := &groups[i]
+		g.Members = append(g.Members, p)
+		g.CPUPercent += p.CPUPercent
+		g.CPULifetime += p.CPULifetime
+		g.MemPercent += p.MemPercent
+		g.MemRSS += p.MemRSS
+		g.MemVMS += p.MemVMS
+		g.NumThreads += p.NumThreads
+		if p.IO != nil {
+			if g.IO == nil {
+				g.IO = &ProcessIO{}
+			}
+			g.IO.ReadSyscalls += p.IO.ReadSyscalls
+			g.IO.WriteSyscalls += p.IO.WriteSyscalls
+			g.IO.ReadBytes += p.IO.ReadBytes
+			g.IO.WriteBytes += p.IO.WriteBytes
+		}
+	}
+
+	summaries := make([]ProcessDetail, len(groups))
+	for i := range groups {
+		summaries[i] = groups[i].Summary(by)
+	}
+	order := make([]int, len(groups))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := &summaries[order[i]], &summaries[order[j]]
+		if c := compareProcesses(sortBy, a, b); c != 0 {
+			return c < 0
+		}
+		return compareFold(a.Name, b.Name) < 0
+	})
+	sorted := make([]ProcessGroup, len(groups))
+	for i, j := range order {
+		sorted[i] = groups[j]
+	}
+	return sorted
+}
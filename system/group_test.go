@@ -0,0 +1,50 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGroupProcesses(t *testing.T) {
+	processes := []ProcessDetail{
+		{PID: 1, Name: "systemd", Username: "root", CPUPercent: 0.5, MemRSS: 10, NumThreads: 1, Cgroup: "/init.scope"},
+		{PID: 20, Name: "postgres", Username: "postgres", CPUPercent: 10, MemPercent: 2, MemRSS: 200, NumThreads: 4,
+			Cgroup: "/system.slice/postgresql@14-main.service", IO: &ProcessIO{ReadBytes: 5}},
+		{PID: 21, Name: "postgres", Username: "postgres", CPUPercent: 15, MemPercent: 1, MemRSS: 100, NumThreads: 2,
+			Cgroup: "/system.slice/postgresql@14-main.service", IO: &ProcessIO{ReadBytes: 7}},
+		{PID: 30, Name: "java", Username: "root", CPUPercent: 20, MemRSS: 1000, NumThreads: 40, Cgroup: "/system.slice/app.service"},
+		{PID: 40, Name: "kworker", Username: "root", Cgroup: "/"},
+		{PID: 50, Name: "bash", Username: "alice", Cgroup: "/user.slice/user-1000.slice/session-2.scope"},
+	}
+
+	describe := func(groups []ProcessGroup) string {
+		var parts []string
+		for _, g := range groups {
+			parts = append(parts, fmt.Sprintf("%s:%d", g.Key, len(g.Members)))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		by     GroupBy
+		sortBy SortType
+		want   string
+	}{
+		{GroupByName, SortByCPU, "postgres:2 java:1 systemd:1 bash:1 kworker:1"},
+		{GroupByUser, SortByCPU, "postgres:2 root:3 alice:1"},
+		{GroupByUser, SortByThreads, "root:3 postgres:2 alice:1"},
+		{GroupByUser, SortByPID, "alice:1 postgres:2 root:3"}, // no sum: by key
+		{GroupByCgroup, SortByRSS, "app.service:1 postgresql@14-main.service:2 init.scope:1 /:1 session-2.scope:1"},
+	}
+	for _, tt := range tests {
+		if got := describe(GroupProcesses(processes, tt.by, tt.sortBy)); got != tt.want {
+			t.Errorf("by %s sorted by %s: %s, want %s", tt.by, tt.sortBy, got, tt.want)
+		}
+	}
+
+	g := GroupProcesses(processes, GroupByName, SortByCPU)[0]
+	if g.CPUPercent != 25 || g.MemPercent != 3 || g.MemRSS != 300 || g.NumThreads != 6 || g.IO == nil || g.IO.ReadBytes != 12 {
+		t.Errorf("postgres group sums = %+v", g)
+	}
+}
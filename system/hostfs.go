@@ -0,0 +1,50 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/shirou/gopsutil/v3/common"
+)
+
+// RootContext returns a context that makes the built-in sources read host
+// files (proc, sys, etc, ...) from below root instead of the live system.
+// Pointing root at a recorded directory tree gives deterministic metrics.
+func RootContext(ctx context.Context, root string) context.Context {
+	if root == "" || root == "/" {
+		return ctx
+	}
+	return context.WithValue(ctx, common.EnvKey, common.EnvMap{
+		common.HostRootEnvKey: root,
+		common.HostProcEnvKey: filepath.Join(root, "proc"),
+		common.HostSysEnvKey:  filepath.Join(root, "sys"),
+		common.HostEtcEnvKey:  filepath.Join(root, "etc"),
+		common.HostVarEnvKey:  filepath.Join(root, "var"),
+		common.HostRunEnvKey:  filepath.Join(root, "run"),
+		common.HostDevEnvKey:  filepath.Join(root, "dev"),
+	})
+}
+
+// hostPath returns the path of a host file below the directory configured
+// for key by RootContext, or by the matching HOST_* environment variable
+// gopsutil also honours, falling back to def
+func hostPath(ctx context.Context, key common.EnvKeyType, def string, parts ...string) string {
+	dir := def
+	if env, ok := ctx.Value(common.EnvKey).(common.EnvMap); ok && env[key] != "" {
+		dir = env[key]
+	} else if v := os.Getenv(string(key)); v != "" {
+		dir = v
+	}
+	return filepath.Join(append([]string{dir}, parts...)...)
+}
+
+// hostProc returns the path of a file below the host's proc directory
+func hostProc(ctx context.Context, parts ...string) string {
+	return hostPath(ctx, common.HostProcEnvKey, "/proc", parts...)
+}
+
+// hostSys returns the path of a file below the host's sys directory
+func hostSys(ctx context.Context, parts ...string) string {
+	return hostPath(ctx, common.HostSysEnvKey, "/sys", parts...)
+}
@@ -0,0 +1,88 @@
+package system
+
+import "context"
+
+// ProcessInspection holds details of a single process that are too costly
+// to collect for every process on each refresh. They are read on demand
+// when a process is inspected. Sections that could not be read, usually
+// because the process belongs to another user, are listed in Errors.
+type ProcessInspection struct {
+	PID         int32
+	Exe         string
+	Cwd         string
+	Environ     []string
+	FDs         []FileDescriptor
+	Maps        MemoryMapSummary
+	IO          ProcessIO
+	CtxSwitches ContextSwitches
+	Cgroups     []string
+	Namespaces  map[string]string // namespace type -> identity, e.g. "net" -> "net:[4026531840]"
+	Errors      map[string]string // section -> error
+}
+
+// Sections of a ProcessInspection, as used in its Errors
+const (
+	InspectExe         = "exe"
+	InspectCwd         = "cwd"
+	InspectEnviron     = "environ"
+	InspectFDs         = "fds"
+	InspectMaps        = "maps"
+	InspectIO          = "io"
+	InspectCtxSwitches = "ctx_switches"
+	InspectCgroups     = "cgroup"
+	InspectNamespaces  = "namespaces"
+)
+
+// FileDescriptor is an open file descriptor and what it refers to
+type FileDescriptor struct {
+	FD     int
+	Target string // path, or e.g. "socket:[12345]" or "pipe:[678]"
+}
+
+// MemoryMapSummary summarizes the memory mappings of a process. Sizes are
+// virtual sizes in bytes; RSS, PSS and Swap are zero when unavailable.
+type MemoryMapSummary struct {
+	Mappings  int
+	Files     int // distinct mapped files
+	Size      uint64
+	FileSize  uint64
+	AnonSize  uint64
+	HeapSize  uint64
+	StackSize uint64
+	RSS       uint64
+	PSS       uint64
+	Swap      uint64
+}
+
+// ProcessIO holds the I/O counters of a process
+type ProcessIO struct {
+	ReadChars           uint64 // bytes read, including from the page cache
+	WriteChars          uint64
+	ReadSyscalls        uint64
+	WriteSyscalls       uint64
+	ReadBytes           uint64 // bytes fetched from storage
+	WriteBytes          uint64 // bytes sent to storage
+	CancelledWriteBytes uint64
+}
+
+// ContextSwitches counts how often a process gave up the CPU
+type ContextSwitches struct {
+	Voluntary   int64
+	Involuntary int64
+}
+
+// InspectProcess reads the details of process pid, from below c.Root if set
+func (c *Collector) InspectProcess(ctx context.Context, pid int32) *ProcessInspection {
+	return inspectProcess(RootContext(ctx, c.Root), pid)
+}
+
+// fail records the error of a section
+func (pi *ProcessInspection) fail(section string, err error) {
+	if err == nil {
+		return
+	}
+	if pi.Errors == nil {
+		pi.Errors = make(map[string]string)
+	}
+	pi.Errors[section] = err.Error()
+}
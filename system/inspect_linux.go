@@ -0,0 +1,221 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// inspectProcess reads the details of a process from the host's proc
+// directory
+func inspectProcess(ctx context.Context, pid int32) *ProcessInspection {
+	dir := hostProc(ctx, strconv.Itoa(int(pid)))
+	pi := &ProcessInspection{PID: pid}
+
+	var err error
+	pi.Exe, err = os.Readlink(filepath.Join(dir, "exe"))
+	pi.fail(InspectExe, err)
+	pi.Cwd, err = os.Readlink(filepath.Join(dir, "cwd"))
+	pi.fail(InspectCwd, err)
+	pi.Environ, err = readEnviron(filepath.Join(dir, "environ"))
+	pi.fail(InspectEnviron, err)
+	pi.FDs, err = readFDs(filepath.Join(dir, "fd"))
+	pi.fail(InspectFDs, err)
+	pi.Maps, err = readMaps(dir)
+	pi.fail(InspectMaps, err)
+	pi.IO, err = readProcessIO(filepath.Join(dir, "io"))
+	pi.fail(InspectIO, err)
+	pi.CtxSwitches, err = readCtxSwitches(filepath.Join(dir, "status"))
+	pi.fail(InspectCtxSwitches, err)
+	pi.Cgroups, err = readLines(filepath.Join(dir, "cgroup"))
+	pi.fail(InspectCgroups, err)
+	pi.Namespaces, err = readNamespaces(filepath.Join(dir, "ns"))
+	pi.fail(InspectNamespaces, err)
+
+	return pi
+}
+
+// readEnviron reads the NUL-separated environment of a process
+func readEnviron(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	for _, v := range bytes.Split(data, []byte{0}) {
+		if len(v) > 0 {
+			env = append(env, string(v))
+		}
+	}
+	return env, nil
+}
+
+// readFDs lists the open file descriptors in a fd directory, in order
+func readFDs(dir string) ([]FileDescriptor, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fds := make([]FileDescriptor, 0, len(entries))
+	for _, e := range entries {
+		fd, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue // closed in the meantime
+		}
+		fds = append(fds, FileDescriptor{FD: fd, Target: target})
+	}
+	sort.Slice(fds, func(i, j int) bool { return fds[i].FD < fds[j].FD })
+	return fds, nil
+}
+
+// readMaps summarizes the maps file of a process directory, adding resident
+// sizes from smaps_rollup when it is readable
+func readMaps(dir string) (MemoryMapSummary, error) {
+	var sum MemoryMapSummary
+	f, err := os.Open(filepath.Join(dir, "maps"))
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	files := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// address perms offset dev inode [pathname]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		lo, hi, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			continue
+		}
+		start, err1 := strconv.ParseUint(lo, 16, 64)
+		end, err2 := strconv.ParseUint(hi, 16, 64)
+		if err1 != nil || err2 != nil || end < start {
+			continue
+		}
+		size := end - start
+		sum.Mappings++
+		sum.Size += size
+
+		path := ""
+		if len(fields) > 5 {
+			path = strings.Join(fields[5:], " ")
+		}
+		switch {
+		case path == "[heap]":
+			sum.HeapSize += size
+		case strings.HasPrefix(path, "[stack"):
+			sum.StackSize += size
+		case strings.HasPrefix(path, "/"):
+			sum.FileSize += size
+			files[path] = true
+		case path == "":
+			sum.AnonSize += size
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return sum, err
+	}
+	sum.Files = len(files)
+
+	if rollup, err := readKeyValues(filepath.Join(dir, "smaps_rollup")); err == nil {
+		sum.RSS = rollup["Rss"] * 1024
+		sum.PSS = rollup["Pss"] * 1024
+		sum.Swap = rollup["Swap"] * 1024
+	}
+	return sum, nil
+}
+
+// readProcessIO reads the io file of a process
+func readProcessIO(path string) (ProcessIO, error) {
+	v, err := readKeyValues(path)
+	if err != nil {
+		return ProcessIO{}, err
+	}
+	return ProcessIO{
+		ReadChars:           v["rchar"],
+		WriteChars:          v["wchar"],
+		ReadSyscalls:        v["syscr"],
+		WriteSyscalls:       v["syscw"],
+		ReadBytes:           v["read_bytes"],
+		WriteBytes:          v["write_bytes"],
+		CancelledWriteBytes: v["cancelled_write_bytes"],
+	}, nil
+}
+
+// readCtxSwitches reads the context switch counts from a status file
+func readCtxSwitches(path string) (ContextSwitches, error) {
+	v, err := readKeyValues(path)
+	if err != nil {
+		return ContextSwitches{}, err
+	}
+	return ContextSwitches{
+		Voluntary:   int64(v["voluntary_ctxt_switches"]),
+		Involuntary: int64(v["nonvoluntary_ctxt_switches"]),
+	}, nil
+}
+
+// readNamespaces reads the namespace links in a ns directory
+func readNamespaces(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ns := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if target, err := os.Readlink(filepath.Join(dir, e.Name())); err == nil {
+			ns[e.Name()] = target
+		}
+	}
+	return ns, nil
+}
+
+// readLines returns the non-empty lines of a file
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// readKeyValues parses "key: value [kB]" lines with numeric values;
+// other lines are skipped
+func readKeyValues(path string) (map[string]uint64, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]uint64, len(lines))
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		if n, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			values[strings.TrimSpace(key)] = n
+		}
+	}
+	return values, nil
+}
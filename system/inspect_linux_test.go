@@ -0,0 +1,51 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInspectProcessFixture(t *testing.T) {
+	c, _ := newFixtureCollector(t, "frame1")
+	pi := c.InspectProcess(context.Background(), 4243)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "exe=%s cwd=%s\n", pi.Exe, pi.Cwd)
+	for _, env := range pi.Environ {
+		fmt.Fprintf(&b, "env %s\n", env)
+	}
+	for _, fd := range pi.FDs {
+		fmt.Fprintf(&b, "fd %d -> %s\n", fd.FD, fd.Target)
+	}
+	fmt.Fprintf(&b, "maps %+v\n", pi.Maps)
+	fmt.Fprintf(&b, "io %+v\n", pi.IO)
+	fmt.Fprintf(&b, "ctx %+v\n", pi.CtxSwitches)
+	fmt.Fprintf(&b, "cgroups %q\n", pi.Cgroups)
+	var ns []string
+	for kind, id := range pi.Namespaces {
+		ns = append(ns, kind+"="+id)
+	}
+	sort.Strings(ns)
+	fmt.Fprintf(&b, "namespaces %s\n", strings.Join(ns, " "))
+	fmt.Fprintf(&b, "errors %v\n", pi.Errors)
+
+	assertGolden(t, "inspect", []byte(b.String()))
+}
+
+func TestInspectProcessMissingSections(t *testing.T) {
+	// The fixture for PID 4242 has no fd, io or ns entries
+	c, _ := newFixtureCollector(t, "frame1")
+	pi := c.InspectProcess(context.Background(), 4242)
+
+	for _, section := range []string{InspectExe, InspectFDs, InspectIO, InspectNamespaces} {
+		if _, ok := pi.Errors[section]; !ok {
+			t.Errorf("missing %s is not reported in Errors: %v", section, pi.Errors)
+		}
+	}
+	if _, ok := pi.Errors[InspectCtxSwitches]; ok {
+		t.Errorf("context switches from status reported as failed: %v", pi.Errors)
+	}
+}
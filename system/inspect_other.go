@@ -0,0 +1,55 @@
+//go:build !linux
+
+package system
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// inspectProcess reads the details of a process that gopsutil supports on
+// this platform; the other sections report errors.ErrUnsupported
+func inspectProcess(ctx context.Context, pid int32) *ProcessInspection {
+	p := &process.Process{Pid: pid}
+	pi := &ProcessInspection{PID: pid}
+
+	var err error
+	pi.Exe, err = p.ExeWithContext(ctx)
+	pi.fail(InspectExe, err)
+	pi.Cwd, err = p.CwdWithContext(ctx)
+	pi.fail(InspectCwd, err)
+	pi.Environ, err = p.EnvironWithContext(ctx)
+	pi.fail(InspectEnviron, err)
+
+	if files, err := p.OpenFilesWithContext(ctx); err == nil {
+		for _, f := range files {
+			pi.FDs = append(pi.FDs, FileDescriptor{FD: int(f.Fd), Target: f.Path})
+		}
+	} else {
+		pi.fail(InspectFDs, err)
+	}
+
+	if io, err := p.IOCountersWithContext(ctx); err == nil {
+		pi.IO = ProcessIO{
+			ReadSyscalls:  io.ReadCount,
+			WriteSyscalls: io.WriteCount,
+			ReadBytes:     io.ReadBytes,
+			WriteBytes:    io.WriteBytes,
+		}
+	} else {
+		pi.fail(InspectIO, err)
+	}
+
+	if cs, err := p.NumCtxSwitchesWithContext(ctx); err == nil {
+		pi.CtxSwitches = ContextSwitches{Voluntary: cs.Voluntary, Involuntary: cs.Involuntary}
+	} else {
+		pi.fail(InspectCtxSwitches, err)
+	}
+
+	pi.fail(InspectMaps, errors.ErrUnsupported)
+	pi.fail(InspectCgroups, errors.ErrUnsupported)
+	pi.fail(InspectNamespaces, errors.ErrUnsupported)
+	return pi
+}
@@ -0,0 +1,126 @@
+package system
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SourcePressure is the name of the pressure stall information source
+const SourcePressure = "pressure"
+
+// PressureStats is one line of a PSI file: the share of wall time in which
+// tasks were stalled, averaged over 10, 60 and 300 seconds
+type PressureStats struct {
+	Avg10  float64 // percent
+	Avg60  float64 // percent
+	Avg300 float64 // percent
+	Total  time.Duration
+}
+
+// Pressure holds the stall times of a resource. Some counts time in which
+// at least one task was stalled, Full time in which all non-idle tasks
+// were stalled at once.
+type Pressure struct {
+	Some PressureStats
+	Full PressureStats
+}
+
+// PressureInfo holds the system-wide pressure stall information from
+// /proc/pressure. The histories record Some.Avg10 of every cycle.
+type PressureInfo struct {
+	Available     bool // false when the kernel does not provide PSI
+	CPU           Pressure
+	Memory        Pressure
+	IO            Pressure
+	CPUHistory    TimeSeries
+	MemoryHistory TimeSeries
+	IOHistory     TimeSeries
+}
+
+// PressureSource reads /proc/pressure. PSI needs Linux 4.20 or later built
+// with CONFIG_PSI; elsewhere the source reports it as unavailable.
+type PressureSource struct {
+	BaseSource
+}
+
+// NewPressureSource creates the pressure stall information source
+func NewPressureSource() *PressureSource {
+	return &PressureSource{BaseSource: BaseSource{SourceName: SourcePressure}}
+}
+
+// Collect reads the pressure of the CPU, memory and I/O
+func (s *PressureSource) Collect(ctx context.Context) (Update, error) {
+	var info PressureInfo
+	cpu, errCPU := readPressure(hostProc(ctx, "pressure", "cpu"))
+	memory, errMemory := readPressure(hostProc(ctx, "pressure", "memory"))
+	io, errIO := readPressure(hostProc(ctx, "pressure", "io"))
+	if errCPU == nil && errMemory == nil && errIO == nil {
+		info = PressureInfo{Available: true, CPU: cpu, Memory: memory, IO: io}
+	}
+
+	return func(c *Collector) {
+		info.CPUHistory = c.Pressure.CPUHistory
+		info.MemoryHistory = c.Pressure.MemoryHistory
+		info.IOHistory = c.Pressure.IOHistory
+		c.Pressure = info
+	}, nil
+}
+
+// readPressure reads a PSI file such as /proc/pressure/memory or the
+// memory.pressure file of a cgroup
+func readPressure(path string) (Pressure, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pressure{}, err
+	}
+	return parsePressure(string(data)), nil
+}
+
+// parsePressure parses the contents of a PSI file:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//
+// The full line is missing for the CPU on kernels before 5.13.
+func parsePressure(data string) Pressure {
+	var p Pressure
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var stats *PressureStats
+		switch fields[0] {
+		case "some":
+			stats = &p.Some
+		case "full":
+			stats = &p.Full
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "avg10":
+				stats.Avg10 = v
+			case "avg60":
+				stats.Avg60 = v
+			case "avg300":
+				stats.Avg300 = v
+			case "total":
+				stats.Total = time.Duration(v) * time.Microsecond
+			}
+		}
+	}
+	return p
+}
@@ -0,0 +1,23 @@
+package system
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePressure(t *testing.T) {
+	p := parsePressure("some avg10=1.03 avg60=1.63 avg300=1.53 total=93334862\nfull avg10=0.50 avg60=0.25 avg300=0.10 total=3678156\n")
+	want := Pressure{
+		Some: PressureStats{Avg10: 1.03, Avg60: 1.63, Avg300: 1.53, Total: 93334862 * time.Microsecond},
+		Full: PressureStats{Avg10: 0.50, Avg60: 0.25, Avg300: 0.10, Total: 3678156 * time.Microsecond},
+	}
+	if p != want {
+		t.Errorf("parsePressure = %+v, want %+v", p, want)
+	}
+
+	// Kernels before 5.13 have no full line for the CPU
+	p = parsePressure("some avg10=2.00 avg60=1.00 avg300=0.50 total=10\n")
+	if p.Some.Avg10 != 2 || p.Full != (PressureStats{}) {
+		t.Errorf("parsePressure without full line = %+v", p)
+	}
+}
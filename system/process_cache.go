@@ -0,0 +1,148 @@
+package system
+
+import (
+	"context"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// procStat holds the values of a process that are read every cycle
+type procStat struct {
+	Comm       string // command name as the kernel reports it
+	Status     string
+	PPID       int32
+	Nice       int32
+	NumThreads int32
+	Start      uint64  // start time in a platform unit; changes when a PID is reused
+	CPUTime    float64 // user plus system CPU time in seconds
+	RSS        uint64
+	VMS        uint64
+}
+
+// ProcessCollectStats describes the cost of a process collection
+type ProcessCollectStats struct {
+	Duration time.Duration // time spent reading the process table
+	Scanned  int           // PIDs listed
+	Cached   int           // processes whose static attributes came from the cache
+	Loaded   int           // processes whose static attributes were read
+	Evicted  int           // cache entries dropped because the process exited
+	Partial  bool          // the walk missed its deadline and lists only some processes
+}
+
+// processEntry holds the attributes of a process that do not change while
+// it runs
+type processEntry struct {
+	start     uint64
+	comm      string
+	name      string
+	username  string
+	cmdLine   string
+	createdAt time.Time
+	seen      uint64 // cycle the process was last listed in
+
+	// CPU time at the previous sample, for the usage over the interval
+	cpuTime   float64
+	sampledAt time.Time
+}
+
+// processCache keeps the static attributes of running processes between
+// cycles. An entry is only used while the start time and command name of
+// its PID match, so a reused PID or an exec loads the process afresh.
+type processCache struct {
+	entries map[int32]*processEntry
+	cycle   uint64
+}
+
+// newProcessCache creates an empty cache
+func newProcessCache() *processCache {
+	return &processCache{entries: make(map[int32]*processEntry)}
+}
+
+// lookup returns the entry of a process, loading it if it is not cached or
+// the PID now belongs to another program. loaded reports whether the
+// attributes were read.
+func (pc *processCache) lookup(ctx context.Context, p *process.Process, st procStat) (entry *processEntry, loaded bool, err error) {
+	entry, ok := pc.entries[p.Pid]
+	if !ok || entry.start != st.Start || entry.comm != st.Comm {
+		if entry, err = loadProcessEntry(ctx, p, st); err != nil {
+			return nil, false, err
+		}
+		pc.entries[p.Pid] = entry
+		loaded = true
+	}
+	entry.seen = pc.cycle
+	return entry, loaded, nil
+}
+
+// evict drops the entries of processes not listed in the current cycle and
+// returns how many were dropped
+func (pc *processCache) evict() int {
+	evicted := 0
+	for pid, entry := range pc.entries {
+		if entry.seen != pc.cycle {
+			delete(pc.entries, pid)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// loadProcessEntry reads the static attributes of a process
+func loadProcessEntry(ctx context.Context, p *process.Process, st procStat) (*processEntry, error) {
+	name, err := p.NameWithContext(ctx)
+	if err != nil {
+		return nil, err // the process has exited
+	}
+
+	username, err := p.UsernameWithContext(ctx)
+	if err != nil {
+		username = "unknown"
+	}
+
+	cmdLine, err := p.CmdlineWithContext(ctx)
+	if err != nil {
+		cmdLine = ""
+	}
+
+	// The start time stays unknown (zero) if it cannot be read, rather than
+	// showing as the epoch
+	var createdAt time.Time
+	if createTime, err := p.CreateTimeWithContext(ctx); err == nil {
+		createdAt = time.UnixMilli(createTime)
+	}
+
+	return &processEntry{
+		start:     st.Start,
+		comm:      st.Comm,
+		name:      name,
+		username:  username,
+		cmdLine:   cmdLine,
+		createdAt: createdAt,
+	}, nil
+}
+
+// intervalCPUPercent returns the CPU usage of the process since its
+// previous sample and records the new one. A process sampled for the first
+// time has no interval yet and reports its lifetime average.
+func (e *processEntry) intervalCPUPercent(cpuTime float64, now time.Time, lifetime float64) float64 {
+	percent := lifetime
+	if !e.sampledAt.IsZero() {
+		percent = 0
+		if cpuTime >= e.cpuTime {
+			percent = 100 * (cpuTime - e.cpuTime) / rateInterval(e.sampledAt, now)
+		}
+	}
+	e.cpuTime, e.sampledAt = cpuTime, now
+	return percent
+}
+
+// lifetimeCPUPercent returns the CPU usage of a process averaged over its
+// lifetime
+func lifetimeCPUPercent(cpuTime float64, createdAt, now time.Time) float64 {
+	elapsed := now.Sub(createdAt).Seconds()
+	if createdAt.IsZero() || elapsed <= 0 {
+		return 0
+	}
+	return 100 * cpuTime / elapsed
+}
@@ -0,0 +1,119 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessCache(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, "proc", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// start writes a process started at the given clock tick
+	start := func(pid int, name string, ticks int) {
+		dir := fmt.Sprint(pid)
+		write(dir+"/stat", fmt.Sprintf("%d (%s) S 1 %d %d 0 -1 0 0 0 0 0 100 50 0 0 20 0 1 0 %d 1000 10", pid, name, pid, pid, ticks))
+		write(dir+"/status", fmt.Sprintf("Name:\t%s\nState:\tS (sleeping)\nPPid:\t1\nUid:\t0\t0\t0\t0\n", name))
+		write(dir+"/cmdline", "/usr/bin/"+name+"\x00--serve\x00")
+		write(dir+"/cgroup", "0::/system.slice/"+name+".service\n")
+	}
+	write("stat", "btime 1704164645\n")
+	start(100, "worker", 500)
+
+	s := NewProcessSource()
+	now := time.Unix(1704164645, 0).Add(time.Hour)
+	collect := func() ProcessInfo {
+		t.Helper()
+		now = now.Add(2 * time.Second)
+		update, err := s.Collect(withCollectTime(RootContext(context.Background(), root), now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &Collector{}
+		update(c)
+		return c.Process
+	}
+	check := func(info ProcessInfo, cached, loaded, evicted int) {
+		t.Helper()
+		st := info.Stats
+		if st.Cached != cached || st.Loaded != loaded || st.Evicted != evicted {
+			t.Errorf("stats = cached %d, loaded %d, evicted %d; want %d, %d, %d",
+				st.Cached, st.Loaded, st.Evicted, cached, loaded, evicted)
+		}
+	}
+
+	info := collect()
+	check(info, 0, 1, 0)
+	if p := info.Processes[0]; p.Name != "worker" || p.CmdLine != "/usr/bin/worker --serve" || p.Cgroup != "/system.slice/worker.service" {
+		t.Errorf("process = %+v", p)
+	}
+	// Without a previous sample the usage is the lifetime average
+	if p := info.Processes[0]; p.CPUPercent != p.CPULifetime || p.CPULifetime <= 0 {
+		t.Errorf("first sample CPU%% = %v, lifetime %v; want equal and positive", p.CPUPercent, p.CPULifetime)
+	}
+
+	// Volatile fields are read again while the static ones are cached
+	write("100/stat", "100 (worker) R 1 100 100 0 -1 0 0 0 0 0 200 50 0 0 20 3 2 0 500 1000 10")
+	info = collect()
+	check(info, 1, 0, 0)
+	if p := info.Processes[0]; p.Status[0] != "running" || p.Nice != 3 || p.NumThreads != 2 {
+		t.Errorf("volatile fields not refreshed: %+v", p)
+	}
+	// One more second of CPU time over the two second interval
+	if p := info.Processes[0]; p.CPUPercent != 50 {
+		t.Errorf("interval CPU%% = %v, want 50", p.CPUPercent)
+	}
+
+	// A process moved to another cgroup shows its new cgroup
+	write("100/cgroup", "0::/user.slice/user-1000.slice/session-2.scope\n")
+	info = collect()
+	check(info, 1, 0, 0)
+	if p := info.Processes[0]; p.Cgroup != "/user.slice/user-1000.slice/session-2.scope" {
+		t.Errorf("cgroup after the move = %q", p.Cgroup)
+	}
+
+	// A reused PID is loaded afresh
+	start(100, "backup", 900)
+	info = collect()
+	check(info, 0, 1, 0)
+	if p := info.Processes[0]; p.Name != "backup" || p.CPUPercent != p.CPULifetime {
+		t.Errorf("after PID reuse name = %q, CPU%% = %v; want backup and the lifetime average", p.Name, p.CPUPercent)
+	}
+
+	// Exited processes are evicted
+	if err := os.RemoveAll(filepath.Join(root, "proc", "100")); err != nil {
+		t.Fatal(err)
+	}
+	start(200, "worker", 1000)
+	info = collect()
+	check(info, 0, 1, 1)
+	if len(s.cache.entries) != 1 || s.cache.entries[200] == nil {
+		t.Errorf("cache holds %v, want only PID 200", s.cache.entries)
+	}
+
+	// A walk that misses its deadline stops with the context's error, but
+	// still delivers what it read and keeps the cache
+	ctx, cancel := context.WithCancel(RootContext(context.Background(), root))
+	cancel()
+	update, err := s.Collect(withCollectTime(ctx, now))
+	if err != context.Canceled || update == nil {
+		t.Fatalf("Collect after cancel = %v, want a partial update and %v", err, context.Canceled)
+	}
+	c := &Collector{}
+	update(c)
+	if !c.Process.Stats.Partial || s.cache.entries[200] == nil {
+		t.Errorf("partial walk: stats %+v, cache %v", c.Process.Stats, s.cache.entries)
+	}
+}
@@ -0,0 +1,92 @@
+package system
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProcessKey identifies a process across collection cycles. The start time
+// tells a new process apart from an exited one whose PID was reused.
+type ProcessKey struct {
+	PID        int32
+	CreateTime int64 // milliseconds since the epoch
+}
+
+// Key returns the key of the process
+func (p ProcessDetail) Key() ProcessKey {
+	return ProcessKey{PID: p.PID, CreateTime: p.CreatedAt.UnixMilli()}
+}
+
+// String formats the key as "pid@createtime"
+func (k ProcessKey) String() string {
+	return fmt.Sprintf("%d@%d", k.PID, k.CreateTime)
+}
+
+// ProcessHistory holds the recent samples of a single process
+type ProcessHistory struct {
+	CPU       TimeSeries // CPU%
+	RSS       TimeSeries // resident memory in bytes
+	ReadRate  TimeSeries // bytes read from storage per second
+	WriteRate TimeSeries // bytes written to storage per second
+	lastIO    time.Time  // time of the I/O counters the rates continue from
+	lastRead  uint64
+	lastWrite uint64
+}
+
+// HistoryOf returns the history of a process, if any
+func (pi ProcessInfo) HistoryOf(p ProcessDetail) (ProcessHistory, bool) {
+	h, ok := pi.History[p.Key()]
+	return h, ok
+}
+
+// updateProcessHistory appends the current samples of every process to its
+// history and drops the history of processes that have exited.
+//
+// Snapshots share the history map and series with the collector, so both
+// are treated as immutable: every cycle builds a new map, and series are
+// only appended to or re-sliced, which never changes the points a snapshot
+// can see.
+func (c *Collector) updateProcessHistory() {
+	now := c.System.LastUpdated
+	history := make(map[ProcessKey]ProcessHistory, len(c.Process.Processes))
+
+	for _, p := range c.Process.Processes {
+		key := p.Key()
+		h := c.Process.History[key]
+
+		h.CPU = appendPoint(h.CPU, now, p.CPUPercent, c.MaxHistoryPoints)
+		h.RSS = appendPoint(h.RSS, now, float64(p.MemRSS), c.MaxHistoryPoints)
+
+		// I/O counters are cumulative; rates need a previous sample
+		if p.IO != nil {
+			if !h.lastIO.IsZero() {
+				elapsed := rateInterval(h.lastIO, now)
+				h.ReadRate = appendPoint(h.ReadRate, now, counterRate(p.IO.ReadBytes, h.lastRead, elapsed), c.MaxHistoryPoints)
+				h.WriteRate = appendPoint(h.WriteRate, now, counterRate(p.IO.WriteBytes, h.lastWrite, elapsed), c.MaxHistoryPoints)
+			}
+			h.lastIO, h.lastRead, h.lastWrite = now, p.IO.ReadBytes, p.IO.WriteBytes
+		}
+
+		history[key] = h
+	}
+
+	c.Process.History = history
+}
+
+// appendPoint adds a point to ts, keeping at most max points
+func appendPoint(ts TimeSeries, at time.Time, value float64, max int) TimeSeries {
+	ts.Points = append(ts.Points, TimeSeriesPoint{Timestamp: at, Value: value})
+	if max > 0 && len(ts.Points) > max {
+		ts.Points = ts.Points[len(ts.Points)-max:]
+	}
+	return ts
+}
+
+// counterRate returns the per-second rate of a cumulative counter, treating
+// a counter that went backwards as reset
+func counterRate(current, previous uint64, seconds float64) float64 {
+	if current < previous {
+		return 0
+	}
+	return float64(current-previous) / seconds
+}
@@ -0,0 +1,52 @@
+package system
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcessHistory(t *testing.T) {
+	c := NewCollector(60, 85, 90, 80, 1000, "pid", 15, 100)
+	c.MaxHistoryPoints = 3
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	worker := ProcessDetail{PID: 100, CreatedAt: start, CPUPercent: 10, MemRSS: 1000, IO: &ProcessIO{ReadBytes: 0}}
+
+	cycle := func(at time.Time, processes ...ProcessDetail) *Snapshot {
+		c.System.LastUpdated = at
+		c.Process.Processes = processes
+		c.updateProcessHistory()
+		return c.snapshot()
+	}
+
+	first := cycle(start, worker)
+	for i := 1; i <= 4; i++ {
+		worker.CPUPercent = float64(10 + i)
+		worker.IO = &ProcessIO{ReadBytes: uint64(i) * 2048}
+		cycle(start.Add(time.Duration(i)*time.Second), worker)
+	}
+
+	h, ok := c.Process.HistoryOf(worker)
+	if !ok {
+		t.Fatal("no history for the worker")
+	}
+	if got := seriesValues(h.CPU); len(got) != 3 || got[0] != 12 || got[2] != 14 {
+		t.Errorf("CPU history = %v, want the last 3 samples 12..14", got)
+	}
+	if got := seriesValues(h.ReadRate); len(got) != 3 || got[2] != 2048 {
+		t.Errorf("read rate history = %v, want 2048 B/s", got)
+	}
+	if h, _ := first.Process.HistoryOf(worker); len(h.CPU.Points) != 1 || h.CPU.Points[0].Value != 10 {
+		t.Errorf("first snapshot history changed after later cycles: %v", seriesValues(h.CPU))
+	}
+
+	// The PID is reused by a new process: its history starts over and the
+	// exited process is dropped
+	reused := ProcessDetail{PID: 100, CreatedAt: start.Add(time.Minute), CPUPercent: 99}
+	cycle(start.Add(time.Minute), reused)
+	if h, _ := c.Process.HistoryOf(reused); len(h.CPU.Points) != 1 {
+		t.Errorf("reused PID inherited %d points", len(h.CPU.Points))
+	}
+	if _, ok := c.Process.HistoryOf(worker); ok || len(c.Process.History) != 1 {
+		t.Errorf("history of the exited process was kept: %v", c.Process.History)
+	}
+}
@@ -0,0 +1,110 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in the stat file. It is
+// 100 on every mainstream Linux ABI, which gopsutil assumes as well.
+const clockTicks = 100
+
+// readProcStat reads the per-cycle values of a process from the single stat
+// file below the host's proc directory. gopsutil would read stat, statm and
+// status several times over, and reports the raw getpriority result
+// (20 - nice) as the nice value of the live process.
+func readProcStat(ctx context.Context, p *process.Process) (procStat, error) {
+	data, err := os.ReadFile(hostProc(ctx, strconv.Itoa(int(p.Pid)), "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+	return parseProcStat(string(data))
+}
+
+// readProcCgroup returns the cgroup of a process, or "" when it cannot be
+// read. It is read every cycle rather than cached, as a process can be moved
+// to another cgroup while it runs.
+func readProcCgroup(ctx context.Context, p *process.Process) string {
+	data, err := os.ReadFile(hostProc(ctx, strconv.Itoa(int(p.Pid)), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	return parseProcCgroup(string(data))
+}
+
+// parseProcStat parses the contents of /proc/<pid>/stat
+func parseProcStat(stat string) (procStat, error) {
+	// The command name may contain spaces and parentheses; the fields after
+	// it start at the last closing parenthesis
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return procStat{}, fmt.Errorf("malformed stat line")
+	}
+	fields := strings.Fields(stat[end+1:])
+
+	// fields[0] is field 3 of proc(5), the state
+	field := func(n int) string { return fields[n-3] }
+	const lastField = 24 // rss
+	if len(fields) < lastField-2 {
+		return procStat{}, fmt.Errorf("stat line has %d fields, want at least %d", len(fields)+2, lastField)
+	}
+
+	var st procStat
+	var errs []error
+	num := func(n int) uint64 {
+		v, err := strconv.ParseUint(field(n), 10, 64)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return v
+	}
+	signed := func(n int) int64 {
+		v, err := strconv.ParseInt(field(n), 10, 64)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return v
+	}
+
+	st.Comm = stat[open+1 : end]
+	st.Status = statusWord(field(3))
+	st.PPID = int32(signed(4))
+	st.CPUTime = float64(num(14)+num(15)) / clockTicks
+	st.Nice = int32(signed(19))
+	st.NumThreads = int32(signed(20))
+	st.Start = num(22)
+	st.VMS = num(23)
+	st.RSS = num(24) * uint64(os.Getpagesize())
+	if len(errs) > 0 {
+		return procStat{}, fmt.Errorf("parsing stat line: %w", errs[0])
+	}
+	return st, nil
+}
+
+// statusWord converts a state letter of the stat file to the status word
+// gopsutil reports
+func statusWord(letter string) string {
+	switch letter {
+	case "R":
+		return process.Running
+	case "S":
+		return process.Sleep
+	case "D":
+		return process.Blocked
+	case "I":
+		return process.Idle
+	case "T", "t":
+		return process.Stop
+	case "W":
+		return process.Wait
+	case "Z":
+		return process.Zombie
+	}
+	return process.UnknownState
+}
@@ -0,0 +1,38 @@
+package system
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseProcStat(t *testing.T) {
+	stat := "4243 (my (odd) app) S 1 4243 4243 0 -1 4194560 100 0 0 0 50 30 0 0 25 5 4 0 1000 8192000 300 18446744073709551615"
+	st, err := parseProcStat(stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := procStat{
+		Comm:       "my (odd) app",
+		Status:     "sleep",
+		PPID:       1,
+		Nice:       5,
+		NumThreads: 4,
+		Start:      1000,
+		CPUTime:    0.8,
+		VMS:        8192000,
+		RSS:        300 * uint64(os.Getpagesize()),
+	}
+	if st != want {
+		t.Errorf("parseProcStat = %+v, want %+v", st, want)
+	}
+
+	for _, bad := range []string{
+		"4243 (short) S 1",
+		"4243 no command name",
+		"4243 (app) S 1 4243 4243 0 -1 4194560 100 0 0 0 x 30 0 0 25 5 4 0 1000 8192000 300",
+	} {
+		if _, err := parseProcStat(bad); err == nil {
+			t.Errorf("parseProcStat(%q) succeeded", bad)
+		}
+	}
+}
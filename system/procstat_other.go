@@ -0,0 +1,43 @@
+//go:build !linux
+
+package system
+
+import (
+	"context"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// readProcStat reads the per-cycle values of a process through gopsutil
+func readProcStat(ctx context.Context, p *process.Process) (procStat, error) {
+	var st procStat
+	var err error
+
+	if st.Comm, err = p.NameWithContext(ctx); err != nil {
+		return st, err
+	}
+	createTime, err := p.CreateTimeWithContext(ctx)
+	if err != nil {
+		return st, err
+	}
+	st.Start = uint64(createTime)
+
+	if status, err := p.StatusWithContext(ctx); err == nil && len(status) > 0 {
+		st.Status = status[0]
+	}
+	st.PPID, _ = p.PpidWithContext(ctx)
+	st.Nice, _ = p.NiceWithContext(ctx)
+	st.NumThreads, _ = p.NumThreadsWithContext(ctx)
+	if times, err := p.TimesWithContext(ctx); err == nil {
+		st.CPUTime = times.User + times.System
+	}
+	if mem, err := p.MemoryInfoWithContext(ctx); err == nil && mem != nil {
+		st.RSS, st.VMS = mem.RSS, mem.VMS
+	}
+	return st, nil
+}
+
+// readProcCgroup returns "": cgroups exist only on Linux
+func readProcCgroup(ctx context.Context, p *process.Process) string {
+	return ""
+}
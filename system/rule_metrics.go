@@ -0,0 +1,210 @@
+package system
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ruleMetrics returns the series of every metric alert rules can select,
+// by name. Metrics without a value in a cycle, such as the load average
+// where it is not available, return no series.
+var ruleMetrics = withPressureMetrics(map[string]func(c *Collector) []metricSeries{
+	"cpu.usage": func(c *Collector) []metricSeries { return single(c.CPU.Usage) },
+	"cpu.core_usage": func(c *Collector) []metricSeries {
+		series := make([]metricSeries, len(c.CPU.UsagePerCPU))
+		for i, usage := range c.CPU.UsagePerCPU {
+			series[i] = metricSeries{labels: map[string]string{"cpu": strconv.Itoa(i)}, value: usage}
+		}
+		return series
+	},
+	"cpu.load1":       func(c *Collector) []metricSeries { return loadValue(c, 1) },
+	"cpu.load5":       func(c *Collector) []metricSeries { return loadValue(c, 5) },
+	"cpu.load15":      func(c *Collector) []metricSeries { return loadValue(c, 15) },
+	"cpu.temperature": func(c *Collector) []metricSeries { return nonZero(c.CPU.Temperature) },
+
+	"mem.used_percent": func(c *Collector) []metricSeries { return single(c.Memory.UsedPercent) },
+	"mem.used":         func(c *Collector) []metricSeries { return single(float64(c.Memory.Used)) },
+	"mem.free":         func(c *Collector) []metricSeries { return single(float64(c.Memory.Free)) },
+	"swap.used_percent": func(c *Collector) []metricSeries {
+		if c.Memory.SwapTotal == 0 {
+			return nil
+		}
+		return single(c.Memory.SwapPercent)
+	},
+	"swap.used": func(c *Collector) []metricSeries {
+		if c.Memory.SwapTotal == 0 {
+			return nil
+		}
+		return single(float64(c.Memory.SwapUsed))
+	},
+
+	"disk.used_percent": func(c *Collector) []metricSeries {
+		return mountSeries(c, func(used, free uint64, percent float64) float64 { return percent })
+	},
+	"disk.used": func(c *Collector) []metricSeries {
+		return mountSeries(c, func(used, free uint64, percent float64) float64 { return float64(used) })
+	},
+	"disk.free": func(c *Collector) []metricSeries {
+		return mountSeries(c, func(used, free uint64, percent float64) float64 { return float64(free) })
+	},
+	"disk.read_rate":  func(c *Collector) []metricSeries { return rateSeries("device", c.Disk.ReadRate) },
+	"disk.write_rate": func(c *Collector) []metricSeries { return rateSeries("device", c.Disk.WriteRate) },
+
+	"net.recv_rate":   func(c *Collector) []metricSeries { return rateSeries("iface", c.Network.RecvRate) },
+	"net.sent_rate":   func(c *Collector) []metricSeries { return rateSeries("iface", c.Network.SentRate) },
+	"net.connections": func(c *Collector) []metricSeries { return single(float64(len(c.Network.Connections))) },
+
+	"proc.cpu":     processSeries(func(p *ProcessDetail) float64 { return p.CPUPercent }),
+	"proc.mem":     processSeries(func(p *ProcessDetail) float64 { return float64(p.MemPercent) }),
+	"proc.rss":     processSeries(func(p *ProcessDetail) float64 { return float64(p.MemRSS) }),
+	"proc.vms":     processSeries(func(p *ProcessDetail) float64 { return float64(p.MemVMS) }),
+	"proc.threads": processSeries(func(p *ProcessDetail) float64 { return float64(p.NumThreads) }),
+
+	"cgroup.cpu_percent":       cgroupSeries(func(g *CgroupStats) (float64, bool) { return g.CPUPercent, true }),
+	"cgroup.throttled_percent": cgroupSeries(func(g *CgroupStats) (float64, bool) { return g.ThrottledPercent, true }),
+	"cgroup.memory":            cgroupSeries(func(g *CgroupStats) (float64, bool) { return float64(g.MemoryCurrent), true }),
+	"cgroup.memory_percent": cgroupSeries(func(g *CgroupStats) (float64, bool) {
+		return g.MemoryPercent(), g.MemoryMax > 0
+	}),
+	"cgroup.oom_kills": cgroupSeries(func(g *CgroupStats) (float64, bool) { return float64(g.OOMKills), true }),
+	"cgroup.pids":      cgroupSeries(func(g *CgroupStats) (float64, bool) { return float64(g.PidsCurrent), true }),
+	// Share of the last 10 seconds in which some tasks of the cgroup stalled
+	"cgroup.cpu_pressure":    cgroupSeries(func(g *CgroupStats) (float64, bool) { return g.CPUPressure.Some.Avg10, true }),
+	"cgroup.memory_pressure": cgroupSeries(func(g *CgroupStats) (float64, bool) { return g.MemoryPressure.Some.Avg10, true }),
+	"cgroup.io_pressure":     cgroupSeries(func(g *CgroupStats) (float64, bool) { return g.IOPressure.Some.Avg10, true }),
+})
+
+// withPressureMetrics adds the system-wide pressure metrics to metrics,
+// named pressure.<cpu|memory|io>.<some|full>_<avg10|avg60|avg300>
+func withPressureMetrics(metrics map[string]func(c *Collector) []metricSeries) map[string]func(c *Collector) []metricSeries {
+	resources := map[string]func(p *PressureInfo) Pressure{
+		"cpu":    func(p *PressureInfo) Pressure { return p.CPU },
+		"memory": func(p *PressureInfo) Pressure { return p.Memory },
+		"io":     func(p *PressureInfo) Pressure { return p.IO },
+	}
+	kinds := map[string]func(p Pressure) PressureStats{
+		"some": func(p Pressure) PressureStats { return p.Some },
+		"full": func(p Pressure) PressureStats { return p.Full },
+	}
+	windows := map[string]func(s PressureStats) float64{
+		"avg10":  func(s PressureStats) float64 { return s.Avg10 },
+		"avg60":  func(s PressureStats) float64 { return s.Avg60 },
+		"avg300": func(s PressureStats) float64 { return s.Avg300 },
+	}
+	for resource, pressure := range resources {
+		for kind, stats := range kinds {
+			for window, avg := range windows {
+				metrics["pressure."+resource+"."+kind+"_"+window] = func(c *Collector) []metricSeries {
+					if !c.Pressure.Available {
+						return nil
+					}
+					return single(avg(stats(pressure(&c.Pressure))))
+				}
+			}
+		}
+	}
+	return metrics
+}
+
+// RuleMetrics returns the names of the metrics alert rules can select
+func RuleMetrics() []string {
+	names := make([]string, 0, len(ruleMetrics))
+	for name := range ruleMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// single returns one unlabelled series
+func single(v float64) []metricSeries {
+	return []metricSeries{{value: v}}
+}
+
+// nonZero returns one series unless v is 0, which means unknown
+func nonZero(v float64) []metricSeries {
+	if v == 0 {
+		return nil
+	}
+	return single(v)
+}
+
+// loadValue returns the load average over the given minutes
+func loadValue(c *Collector, minutes int) []metricSeries {
+	if c.CPU.LoadAvg == nil {
+		return nil
+	}
+	switch minutes {
+	case 1:
+		return single(c.CPU.LoadAvg.Load1)
+	case 5:
+		return single(c.CPU.LoadAvg.Load5)
+	}
+	return single(c.CPU.LoadAvg.Load15)
+}
+
+// mountSeries returns a series per mounted filesystem labelled by mount
+func mountSeries(c *Collector, get func(used, free uint64, percent float64) float64) []metricSeries {
+	series := make([]metricSeries, 0, len(c.Disk.UsageStats))
+	for _, mount := range sortedKeys(c.Disk.UsageStats) {
+		u := c.Disk.UsageStats[mount]
+		series = append(series, metricSeries{
+			labels: map[string]string{"mount": mount},
+			value:  get(u.Used, u.Free, u.UsedPercent),
+		})
+	}
+	return series
+}
+
+// rateSeries returns a series per device or interface of a rate map
+func rateSeries(label string, rates map[string]float64) []metricSeries {
+	series := make([]metricSeries, 0, len(rates))
+	for _, name := range sortedKeys(rates) {
+		series = append(series, metricSeries{labels: map[string]string{label: name}, value: rates[name]})
+	}
+	return series
+}
+
+// processSeries returns a metric with a series per process labelled by
+// pid, name and user
+func processSeries(get func(p *ProcessDetail) float64) func(c *Collector) []metricSeries {
+	return func(c *Collector) []metricSeries {
+		series := make([]metricSeries, len(c.Process.Processes))
+		for i := range c.Process.Processes {
+			p := &c.Process.Processes[i]
+			series[i] = metricSeries{
+				labels: map[string]string{"pid": strconv.Itoa(int(p.PID)), "name": p.Name, "user": p.Username},
+				value:  get(p),
+			}
+		}
+		return series
+	}
+}
+
+// cgroupSeries returns a metric with a series per cgroup labelled by path
+// and systemd unit. Cgroups for which get reports no value are left out.
+func cgroupSeries(get func(g *CgroupStats) (float64, bool)) func(c *Collector) []metricSeries {
+	return func(c *Collector) []metricSeries {
+		var series []metricSeries
+		for i := range c.Cgroups.Groups {
+			g := &c.Cgroups.Groups[i]
+			if v, ok := get(g); ok {
+				series = append(series, metricSeries{
+					labels: map[string]string{"path": g.Path, "unit": CgroupUnit(g.Path)},
+					value:  v,
+				})
+			}
+		}
+		return series
+	}
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
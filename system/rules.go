@@ -0,0 +1,586 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// AlertRule raises an alert when a metric crosses a threshold. Metric is a
+// selector such as
+//
+//	disk.used_percent{mount=/var}
+//	net.recv_rate{iface=eth0}
+//	proc.rss{name~^java$}
+//
+// Labels are matched with = and != (equal) or ~ and !~ (regular
+// expression). Every series the selector matches is checked on its own.
+type AlertRule struct {
+	Name      string
+	Metric    string
+	Op        string // >, >=, < or <=
+	Threshold float64
+	// For is how long the condition has to hold before the alert fires
+	For   time.Duration
+	Level AlertLevel
+	// Hysteresis is how far the value has to move back past the threshold
+	// before a firing alert resolves
+	Hysteresis float64
+	// Message is a text/template rendered with the fields of RuleContext;
+	// empty uses a generic message
+	Message  string
+	Disabled bool
+}
+
+// RuleContext is the data available to the message template of a rule
+type RuleContext struct {
+	Rule      string
+	Metric    string
+	Labels    map[string]string
+	Value     float64
+	Op        string
+	Threshold float64
+	For       time.Duration
+}
+
+// AlertThresholds are the limits of the default alert rules; 0 disables a
+// rule
+type AlertThresholds struct {
+	CPU            float64
+	Memory         float64
+	Disk           float64
+	Swap           float64
+	CPUPressure    float64
+	MemoryPressure float64
+	IOPressure     float64
+}
+
+// DefaultAlertRules returns the built-in usage and pressure rules
+func DefaultAlertRules(t AlertThresholds) []AlertRule {
+	rules := []AlertRule{
+		{Name: "cpu_usage", Metric: "cpu.usage", Op: ">=", Threshold: t.CPU, Hysteresis: 10, Level: CriticalLevel,
+			Message: `CPU usage is high ({{printf "%.1f" .Value}}%)`},
+		{Name: "memory_usage", Metric: "mem.used_percent", Op: ">=", Threshold: t.Memory, Hysteresis: 10, Level: CriticalLevel,
+			Message: `Memory usage is high ({{printf "%.1f" .Value}}%)`},
+		{Name: "swap_usage", Metric: "swap.used_percent", Op: ">=", Threshold: t.Swap, Hysteresis: 10, Level: WarningLevel,
+			Message: `Swap usage is high ({{printf "%.1f" .Value}}%)`},
+		{Name: "disk_usage", Metric: "disk.used_percent", Op: ">=", Threshold: t.Disk, Hysteresis: 5, Level: WarningLevel,
+			Message: `Disk usage on {{.Labels.mount}} is high ({{printf "%.1f" .Value}}%)`},
+	}
+	for _, p := range []struct {
+		name, title string
+		threshold   float64
+	}{
+		{"cpu", "CPU", t.CPUPressure},
+		{"memory", "Memory", t.MemoryPressure},
+		{"io", "I/O", t.IOPressure},
+	} {
+		// Resolve once the pressure has halved
+		rules = append(rules,
+			AlertRule{Name: p.name + "_pressure", Metric: "pressure." + p.name + ".some_avg10", Op: ">=",
+				Threshold: p.threshold, Hysteresis: p.threshold / 2, Level: WarningLevel,
+				Message: p.title + ` pressure is high (tasks stalled {{printf "%.1f" .Value}}% of the last 10s)`},
+			AlertRule{Name: p.name + "_pressure_full", Metric: "pressure." + p.name + ".full_avg10", Op: ">=",
+				Threshold: p.threshold, Hysteresis: p.threshold / 2, Level: CriticalLevel,
+				Message: p.title + ` pressure is critical (all tasks stalled {{printf "%.1f" .Value}}% of the last 10s)`},
+		)
+	}
+
+	enabled := rules[:0]
+	for _, r := range rules {
+		if r.Threshold > 0 {
+			enabled = append(enabled, r)
+		}
+	}
+	return enabled
+}
+
+// MergeAlertRules returns the base rules with those of the same name
+// replaced by the overrides, followed by the new ones. Disabled rules are
+// left out.
+func MergeAlertRules(base, overrides []AlertRule) []AlertRule {
+	byName := make(map[string]int, len(base))
+	merged := append([]AlertRule(nil), base...)
+	for i, r := range merged {
+		byName[r.Name] = i
+	}
+	for _, r := range overrides {
+		if i, ok := byName[r.Name]; ok {
+			merged[i] = r
+			continue
+		}
+		byName[r.Name] = len(merged)
+		merged = append(merged, r)
+	}
+
+	enabled := merged[:0]
+	for _, r := range merged {
+		if !r.Disabled {
+			enabled = append(enabled, r)
+		}
+	}
+	return enabled
+}
+
+// compiledRule is a validated rule with its parsed selector and template
+type compiledRule struct {
+	AlertRule
+	selector metricSelector
+	message  *template.Template
+}
+
+// ruleState tracks a rule for one series between checks
+type ruleState struct {
+	rule   string    // name of the rule
+	since  time.Time // when the condition started to hold; zero when it does not
+	firing bool
+}
+
+// compileRule validates a rule. An empty level defaults to warning.
+func compileRule(r AlertRule) (compiledRule, error) {
+	c := compiledRule{AlertRule: r}
+	if r.Name == "" {
+		return c, fmt.Errorf("alert rule without a name")
+	}
+	switch r.Op {
+	case ">", ">=", "<", "<=":
+	default:
+		return c, fmt.Errorf("alert rule %s: unknown operator %q (want >, >=, < or <=)", r.Name, r.Op)
+	}
+	switch r.Level {
+	case "":
+		c.Level = WarningLevel
+	case InfoLevel, WarningLevel, CriticalLevel:
+	default:
+		return c, fmt.Errorf("alert rule %s: unknown severity %q", r.Name, r.Level)
+	}
+	if r.For < 0 || r.Hysteresis < 0 {
+		return c, fmt.Errorf("alert rule %s: negative duration or hysteresis", r.Name)
+	}
+
+	var err error
+	if c.selector, err = parseSelector(r.Metric); err != nil {
+		return c, fmt.Errorf("alert rule %s: %w", r.Name, err)
+	}
+	message := r.Message
+	if message == "" {
+		message = `{{.Metric}} is {{number .Value}} ({{.Op}} {{number .Threshold}})`
+	}
+	if c.message, err = template.New(r.Name).Funcs(ruleFuncs).Option("missingkey=zero").Parse(message); err != nil {
+		return c, fmt.Errorf("alert rule %s: %w", r.Name, err)
+	}
+	return c, nil
+}
+
+// ruleFuncs are the functions available to message templates
+var ruleFuncs = template.FuncMap{
+	"bytes":  formatRuleBytes,
+	"number": formatRuleNumber,
+}
+
+// formatRuleNumber formats a value with at most two decimals
+func formatRuleNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// formatRuleBytes formats a byte count with binary units
+func formatRuleBytes(v float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", v, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i])
+}
+
+// holds reports whether value meets the condition of the rule with the
+// threshold moved back by slack
+func (r *compiledRule) holds(value, slack float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold-slack
+	case ">=":
+		return value >= r.Threshold-slack
+	case "<":
+		return value < r.Threshold+slack
+	case "<=":
+		return value <= r.Threshold+slack
+	}
+	return false
+}
+
+// source returns the alert source of a series: the rule name followed by
+// its labels, e.g. disk_usage{mount=/var}
+func (r *compiledRule) source(labels map[string]string) string {
+	if len(labels) == 0 {
+		return r.Name
+	}
+	return r.Name + formatLabels(labels)
+}
+
+// render renders the message of the rule for a series
+func (r *compiledRule) render(s metricSeries) string {
+	var b strings.Builder
+	err := r.message.Execute(&b, RuleContext{
+		Rule:      r.Name,
+		Metric:    r.selector.name + formatLabels(s.labels),
+		Labels:    s.labels,
+		Value:     s.value,
+		Op:        r.Op,
+		Threshold: r.Threshold,
+		For:       r.For,
+	})
+	if err != nil {
+		return fmt.Sprintf("%s%s is %.2f (message: %v)", r.selector.name, formatLabels(s.labels), s.value, err)
+	}
+	return b.String()
+}
+
+// SetRules replaces the alert rules. Invalid rules are left out and
+// reported in the returned error. Rules are evaluated in order. A rule
+// whose name, metric, operator and threshold are unchanged keeps its
+// pending and firing alerts; those of the rules removed or changed resolve.
+func (am *AlertManager) SetRules(rules []AlertRule) error {
+	var errs []error
+	compiled := make([]compiledRule, 0, len(rules))
+	names := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		c, err := compileRule(r)
+		if err == nil && names[r.Name] {
+			err = fmt.Errorf("alert rule %s is defined twice", r.Name)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		names[r.Name] = true
+		compiled = append(compiled, c)
+	}
+
+	am.mu.Lock()
+	am.replaceRules(compiled, time.Now())
+	events, handlers := am.events, am.handlers
+	am.events = nil
+	am.mu.Unlock()
+
+	notify(events, handlers)
+	return errors.Join(errs...)
+}
+
+// replaceRules installs the compiled rules, carrying over the state of the
+// series of unchanged rules and resolving the others at now. The caller
+// must hold am.mu.
+func (am *AlertManager) replaceRules(rules []compiledRule, now time.Time) {
+	kept := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		for _, old := range am.rules {
+			if old.Name == r.Name && old.Metric == r.Metric && old.Op == r.Op && old.Threshold == r.Threshold {
+				kept[r.Name] = true
+			}
+		}
+	}
+
+	am.rules = rules
+	for source, state := range am.states {
+		if kept[state.rule] {
+			continue
+		}
+		if state.firing || !state.since.IsZero() {
+			am.resolve(source, now)
+		}
+		delete(am.states, source)
+	}
+}
+
+// Rules returns the alert rules in evaluation order
+func (am *AlertManager) Rules() []AlertRule {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	rules := make([]AlertRule, len(am.rules))
+	for i, r := range am.rules {
+		rules[i] = r.AlertRule
+	}
+	return rules
+}
+
+// evaluateRules checks every rule against the collected metrics. Series
+// that are no longer reported resolve their alerts. The caller must hold
+// am.mu.
+func (am *AlertManager) evaluateRules(metrics *Collector) {
+	now := metrics.System.LastUpdated
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if am.states == nil {
+		am.states = make(map[string]*ruleState)
+	}
+
+	seen := make(map[string]bool)
+	for i := range am.rules {
+		r := &am.rules[i]
+		for _, s := range r.selector.series(metrics) {
+			source := r.source(s.labels)
+			seen[source] = true
+			state := am.states[source]
+			if state == nil {
+				state = &ruleState{rule: r.Name}
+				am.states[source] = state
+			}
+			am.evaluate(r, state, source, s, now)
+		}
+	}
+
+	for source, state := range am.states {
+		if !seen[source] {
+			if state.firing || !state.since.IsZero() {
+				am.resolve(source, now)
+			}
+			delete(am.states, source)
+		}
+	}
+}
+
+// evaluate checks one series against a rule. A firing alert stays until
+// the value moves back past the threshold by the hysteresis; a new one is
+// pending until the condition has held for the rule's duration, then fires.
+func (am *AlertManager) evaluate(r *compiledRule, state *ruleState, source string, s metricSeries, now time.Time) {
+	if state.firing {
+		if r.holds(s.value, r.Hysteresis) {
+			if am.track(r, source, s, now) {
+				// The alert went away while its rule fired, for example
+				// resolved by hand; raise it again as firing
+				am.raise(source, now)
+			}
+			am.updateSuppression(source, now)
+			return
+		}
+		state.firing = false
+		state.since = time.Time{}
+		am.resolve(source, now)
+		return
+	}
+
+	if !r.holds(s.value, 0) {
+		if !state.since.IsZero() {
+			// Drops the pending alert
+			am.resolve(source, now)
+		}
+		state.since = time.Time{}
+		return
+	}
+	if state.since.IsZero() {
+		state.since = now
+	}
+	am.track(r, source, s, now)
+	if now.Sub(state.since) >= r.For {
+		state.firing = true
+		am.raise(source, now)
+	}
+}
+
+// raise fires the pending alert of a source and records the event
+func (am *AlertManager) raise(source string, now time.Time) {
+	if _, ok := am.fire(source, now); !ok {
+		return
+	}
+	i := am.activeIndex(source)
+	am.Alerts[i].SuppressedBy = am.suppression(source, now)
+	am.events = append(am.events, AlertEvent{Alert: am.Alerts[i], Time: now})
+}
+
+// track records the value of a series on its alert, raising the alert as
+// pending if it is new, and reports whether it is pending. The message
+// follows the value until the alert fires.
+func (am *AlertManager) track(r *compiledRule, source string, s metricSeries, now time.Time) bool {
+	message := r.render(s)
+	a := &am.Alerts[am.observe(source, r.Level, message, s.value, now)]
+	if a.State == AlertPending {
+		a.Message = message
+	}
+	if r.further(s.value, a.Peak) {
+		a.Peak = s.value
+	}
+	return a.State == AlertPending
+}
+
+// further reports whether value is further past the threshold than peak
+func (r *compiledRule) further(value, peak float64) bool {
+	if r.Op == "<" || r.Op == "<=" {
+		return value < peak
+	}
+	return value > peak
+}
+
+// resolve resolves the alert of a series and records the event. A pending
+// alert is dropped without an event.
+func (am *AlertManager) resolve(source string, now time.Time) {
+	if alert, ok := am.resolveAlert(source, now); ok {
+		am.events = append(am.events, AlertEvent{Alert: alert, Time: now})
+	}
+}
+
+// metricSeries is one labelled value of a metric
+type metricSeries struct {
+	labels map[string]string
+	value  float64
+}
+
+// labelMatcher matches the value of a label
+type labelMatcher struct {
+	label  string
+	value  string
+	re     *regexp.Regexp // for ~ and !~
+	negate bool
+}
+
+// matches reports whether the labels satisfy the matcher. A missing label
+// is matched as an empty value.
+func (m labelMatcher) matches(labels map[string]string) bool {
+	v := labels[m.label]
+	if m.re != nil {
+		return m.re.MatchString(v) != m.negate
+	}
+	return (v == m.value) != m.negate
+}
+
+// metricSelector selects the series of a metric by their labels
+type metricSelector struct {
+	name     string
+	matchers []labelMatcher
+}
+
+// parseSelector parses a metric selector such as net.recv_rate{iface=eth0}.
+// Label values may be quoted.
+func parseSelector(s string) (metricSelector, error) {
+	s = strings.TrimSpace(s)
+	name, rest, hasLabels := strings.Cut(s, "{")
+	sel := metricSelector{name: strings.TrimSpace(name)}
+	if _, ok := ruleMetrics[sel.name]; !ok {
+		return sel, fmt.Errorf("unknown metric %q", sel.name)
+	}
+	if !hasLabels {
+		return sel, nil
+	}
+	terms, tail, ok := splitMatchers(rest)
+	if !ok {
+		return sel, fmt.Errorf("metric %q: missing }", s)
+	}
+	if strings.TrimSpace(tail) != "" {
+		return sel, fmt.Errorf("metric %q: unexpected %q after }", s, tail)
+	}
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		i := strings.IndexAny(term, "=!~")
+		if i <= 0 {
+			return sel, fmt.Errorf("metric %q: invalid label matcher %q", s, term)
+		}
+		label, expr := strings.TrimSpace(term[:i]), term[i:]
+		var op string
+		for _, candidate := range []string{"!=", "!~", "=", "~"} {
+			if strings.HasPrefix(expr, candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return sel, fmt.Errorf("metric %q: invalid label matcher %q", s, term)
+		}
+		value := strings.TrimSpace(expr[len(op):])
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return sel, fmt.Errorf("metric %q: invalid quoted value %s", s, value)
+			}
+			value = unquoted
+		}
+
+		m := labelMatcher{label: label, value: value, negate: op[0] == '!'}
+		if strings.HasSuffix(op, "~") {
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return sel, fmt.Errorf("metric %q: %v", s, err)
+			}
+			m.re = re
+		}
+		sel.matchers = append(sel.matchers, m)
+	}
+	return sel, nil
+}
+
+// splitMatchers splits the label matchers following the { of a selector
+// at the commas between them, up to the closing }, and returns what
+// follows it. Commas and braces inside quoted values or inside braces of
+// their own, such as those of a regex quantifier, belong to the value.
+func splitMatchers(s string) (terms []string, tail string, ok bool) {
+	start, depth, quoted := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted:
+			if c == '\\' {
+				i++ // skip the escaped character
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '{':
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case c == '}':
+			return append(terms, s[start:i]), s[i+1:], true
+		case c == ',' && depth == 0:
+			terms = append(terms, s[start:i])
+			start = i + 1
+		}
+	}
+	return nil, "", false
+}
+
+// series returns the series of the metric whose labels match
+func (sel metricSelector) series(c *Collector) []metricSeries {
+	var matched []metricSeries
+	for _, s := range ruleMetrics[sel.name](c) {
+		ok := true
+		for _, m := range sel.matchers {
+			if !m.matches(s.labels) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// formatLabels renders labels as {a=1,b=2} in name order
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + labels[name]
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
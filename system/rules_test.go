@@ -0,0 +1,218 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+func TestParseSelector(t *testing.T) {
+	c := &Collector{
+		Network: NetworkInfo{RecvRate: map[string]float64{"eth0": 10, "eth1": 20, "lo": 30}},
+		Disk: DiskInfo{UsageStats: map[string]*disk.UsageStat{
+			"/":         {UsedPercent: 40},
+			"/var":      {UsedPercent: 95},
+			"/mnt/a,b":  {UsedPercent: 50},
+			"/boot/efi": {UsedPercent: 10},
+		}},
+	}
+
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{"net.recv_rate", "[{iface=eth0}=10 {iface=eth1}=20 {iface=lo}=30]"},
+		{"net.recv_rate{iface=eth0}", "[{iface=eth0}=10]"},
+		{"net.recv_rate{iface!=lo}", "[{iface=eth0}=10 {iface=eth1}=20]"},
+		{"net.recv_rate{iface~^eth}", "[{iface=eth0}=10 {iface=eth1}=20]"},
+		{"net.recv_rate{iface!~^eth, }", "[{iface=lo}=30]"},
+		{`disk.used_percent{mount="/var"}`, "[{mount=/var}=95]"},
+		{`disk.used_percent{mount~"^/(boot|var)"}`, "[{mount=/boot/efi}=10 {mount=/var}=95]"},
+		{"disk.used_percent{device=sda}", "[]"},
+		{`disk.used_percent{mount="/mnt/a,b"}`, "[{mount=/mnt/a,b}=50]"},
+		{`disk.used_percent{mount~"^/[a-z]{3,4}$"}`, "[{mount=/var}=95]"},
+		{`disk.used_percent{mount~^/[a-z]{3,4}$, mount!="/"}`, "[{mount=/var}=95]"},
+		{`disk.used_percent{mount~"^/[^}]+$"}`, "[{mount=/boot/efi}=10 {mount=/mnt/a,b}=50 {mount=/var}=95]"},
+	}
+	for _, tt := range tests {
+		sel, err := parseSelector(tt.selector)
+		if err != nil {
+			t.Errorf("parseSelector(%q): %v", tt.selector, err)
+			continue
+		}
+		var got []string
+		for _, s := range sel.series(c) {
+			got = append(got, fmt.Sprintf("%s=%g", formatLabels(s.labels), s.value))
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%s selects %v, want %s", tt.selector, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"net.recv", "net.recv_rate{iface=eth0", "net.recv_rate{iface}", "net.recv_rate{iface~(}", `net.recv_rate{iface="eth0}`, "net.recv_rate{iface=eth0}x"} {
+		if _, err := parseSelector(bad); err == nil {
+			t.Errorf("parseSelector(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestAlertRules(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 20)
+	err := am.SetRules([]AlertRule{
+		{Name: "eth_saturated", Metric: "net.recv_rate{iface~^eth}", Op: ">", Threshold: 100 << 20, For: 2 * time.Minute,
+			Hysteresis: 10 << 20, Level: CriticalLevel, Message: "{{.Labels.iface}} receives {{bytes .Value}}/s"},
+		{Name: "java_rss", Metric: "proc.rss{name=java}", Op: ">=", Threshold: 1 << 30},
+		{Name: "low_free", Metric: "mem.free", Op: "<", Threshold: 1 << 30, Hysteresis: 256 << 20},
+	})
+	if err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	steps := []struct {
+		at        time.Duration
+		eth0      float64
+		javaRSS   uint64 // 0 when java is not running
+		freeBytes uint64
+	}{
+		{0, 200 << 20, 2 << 30, 2 << 30},            // eth0 pending, java fires
+		{time.Minute, 200 << 20, 2 << 30, 2 << 30},  // eth0 still pending
+		{2 * time.Minute, 200 << 20, 0, 512 << 20},  // eth0 fires, java exits, memory low
+		{3 * time.Minute, 95 << 20, 0, 1100 << 20},  // both inside hysteresis
+		{4 * time.Minute, 80 << 20, 0, 1400 << 20},  // both resolve
+		{5 * time.Minute, 200 << 20, 0, 1400 << 20}, // eth0 pending again
+	}
+
+	var b strings.Builder
+	for _, step := range steps {
+		c := &Collector{
+			System:  SystemInfo{LastUpdated: start.Add(step.at)},
+			Memory:  MemoryInfo{Free: step.freeBytes},
+			Network: NetworkInfo{RecvRate: map[string]float64{"eth0": step.eth0, "lo": 500 << 20}},
+		}
+		if step.javaRSS > 0 {
+			c.Process.Processes = []ProcessDetail{{PID: 4243, Name: "java", Username: "root", MemRSS: step.javaRSS}}
+		}
+		am.CheckResourceAlerts(c)
+
+		fmt.Fprintf(&b, "# %s\n", step.at)
+		for _, a := range am.Snapshot() {
+			fmt.Fprintf(&b, "%-8s %-40s %-8s seen=%s..%s count=%d peak=%s %s\n", a.Level, a.Source, a.State,
+				a.FirstSeen.Sub(start), a.LastSeen.Sub(start), a.Count, formatRuleNumber(a.Peak), a.Message)
+		}
+	}
+
+	assertGolden(t, "rules", []byte(b.String()))
+}
+
+func TestSetRulesErrors(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	err := am.SetRules([]AlertRule{
+		{Name: "ok", Metric: "cpu.usage", Op: ">", Threshold: 50},
+		{Name: "ok", Metric: "cpu.usage", Op: ">", Threshold: 60},
+		{Name: "bad_op", Metric: "cpu.usage", Op: "=", Threshold: 50},
+		{Name: "bad_metric", Metric: "cpu.nope", Op: ">", Threshold: 50},
+		{Name: "bad_level", Metric: "cpu.usage", Op: ">", Level: "fatal"},
+		{Name: "bad_template", Metric: "cpu.usage", Op: ">", Message: "{{.Value"},
+		{Metric: "cpu.usage", Op: ">"},
+	})
+	if err == nil {
+		t.Fatal("SetRules accepted invalid rules")
+	}
+	for _, want := range []string{"ok is defined twice", "bad_op", "unknown metric \"cpu.nope\"", "bad_level", "bad_template", "without a name"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	rules := am.Rules()
+	if len(rules) != 1 || rules[0].Name != "ok" || rules[0].Level != WarningLevel {
+		t.Errorf("valid rules = %+v, want only ok at warning level", rules)
+	}
+}
+
+func TestSetRulesKeepsState(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	var events []string
+	am.Subscribe(func(ev AlertEvent) {
+		events = append(events, fmt.Sprintf("%s:%s", ev.Alert.Source, ev.Alert.State))
+	})
+	rules := []AlertRule{
+		{Name: "cpu_high", Metric: "cpu.usage", Op: ">", Threshold: 50},
+		{Name: "free_low", Metric: "mem.free", Op: "<", Threshold: 1 << 30},
+		{Name: "cpu_busy", Metric: "cpu.usage", Op: ">", Threshold: 40, For: 5 * time.Minute},
+	}
+	if err := am.SetRules(rules); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	check := func(at time.Duration) {
+		am.CheckResourceAlerts(&Collector{
+			System: SystemInfo{LastUpdated: start.Add(at)},
+			CPU:    CPUInfo{Usage: 60},
+			Memory: MemoryInfo{Free: 512 << 20},
+		})
+	}
+	// active returns the active alert of a source
+	active := func(source string) Alert {
+		for _, a := range am.Snapshot() {
+			if a.Source == source && !a.Resolved {
+				return a
+			}
+		}
+		return Alert{}
+	}
+	check(0)
+	fired := active("cpu_high")
+	if got := strings.Join(events, " "); got != "cpu_high:firing free_low:firing" {
+		t.Fatalf("events = %s", got)
+	}
+
+	// cpu_high only changes its level, free_low is removed and cpu_busy
+	// gets a new threshold
+	rules[0].Level = CriticalLevel
+	rules[2].Threshold = 45
+	if err := am.SetRules([]AlertRule{rules[0], rules[2]}); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	if got := strings.Join(events, " "); got != "cpu_high:firing free_low:firing free_low:resolved" {
+		t.Errorf("events after SetRules = %s, want free_low resolved", got)
+	}
+
+	check(time.Minute)
+	if got := strings.Join(events, " "); got != "cpu_high:firing free_low:firing free_low:resolved" {
+		t.Errorf("events after the next check = %s, want no new events", got)
+	}
+	cpuHigh, cpuBusy := active("cpu_high"), active("cpu_busy")
+	if cpuHigh.ID != fired.ID || cpuHigh.State != AlertFiring || cpuHigh.Count != 2 || cpuHigh.Level != CriticalLevel {
+		t.Errorf("cpu_high after SetRules = %+v, want the same firing alert at critical level", cpuHigh)
+	}
+	// The changed rule starts over
+	if cpuBusy.State != AlertPending || !cpuBusy.FirstSeen.Equal(start.Add(time.Minute)) {
+		t.Errorf("cpu_busy after SetRules = %+v, want pending since the last check", cpuBusy)
+	}
+}
+
+func TestMergeAlertRules(t *testing.T) {
+	defaults := DefaultAlertRules(AlertThresholds{CPU: 85, Memory: 85, Disk: 90})
+	merged := MergeAlertRules(defaults, []AlertRule{
+		{Name: "disk_usage", Metric: "disk.used_percent{mount!=/boot}", Op: ">=", Threshold: 80},
+		{Name: "memory_usage", Disabled: true},
+		{Name: "eth0", Metric: "net.recv_rate{iface=eth0}", Op: ">", Threshold: 1e8},
+	})
+
+	var got []string
+	for _, r := range merged {
+		got = append(got, fmt.Sprintf("%s:%s", r.Name, r.Metric))
+	}
+	want := "[cpu_usage:cpu.usage disk_usage:disk.used_percent{mount!=/boot} eth0:net.recv_rate{iface=eth0}]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("merged rules = %v, want %s", got, want)
+	}
+	if len(defaults) != 3 || defaults[2].Metric != "disk.used_percent" {
+		t.Errorf("merging changed the default rules: %+v", defaults)
+	}
+}
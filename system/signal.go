@@ -0,0 +1,28 @@
+package system
+
+import (
+	"fmt"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// SignalNames lists the signals that can be sent to a process from the UI,
+// in the order they are offered
+var SignalNames = []string{"TERM", "KILL", "HUP", "INT", "STOP", "CONT", "USR1", "USR2"}
+
+// SourceSignal is the alert source of process signal events
+const SourceSignal = "signal"
+
+// checkProcess reports an error unless the process of key still runs under
+// its PID. Its start time tells it apart from a process started after it
+// exited that reuses the PID, so such a process is never acted on.
+func checkProcess(key ProcessKey) error {
+	created, err := (&process.Process{Pid: key.PID}).CreateTime()
+	if err != nil {
+		return fmt.Errorf("process %d no longer exists: %w", key.PID, err)
+	}
+	if created != key.CreateTime {
+		return fmt.Errorf("process %d has exited and its PID now belongs to another process", key.PID)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !unix
+
+package system
+
+import (
+	"fmt"
+	"os"
+)
+
+// SendSignal terminates the process of key, unless its PID now belongs to
+// another process. Only KILL is supported on this platform; it ends the
+// process without giving it a chance to clean up.
+func SendSignal(key ProcessKey, name string) error {
+	if name != "KILL" {
+		return fmt.Errorf("SIG%s is not supported on this platform", name)
+	}
+	if err := checkProcess(key); err != nil {
+		return err
+	}
+	pid := key.PID
+	proc, err := os.FindProcess(int(pid))
+	if err != nil {
+		return fmt.Errorf("finding process %d: %w", pid, err)
+	}
+	if err := proc.Kill(); err != nil {
+		return fmt.Errorf("killing %d: %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package system
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// signals maps the names in SignalNames to signal numbers
+var signals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"STOP": syscall.SIGSTOP,
+	"CONT": syscall.SIGCONT,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// SendSignal sends the named signal (e.g. "TERM") to the process of key,
+// unless its PID now belongs to another process. Permission failures match
+// os.ErrPermission with errors.Is.
+func SendSignal(key ProcessKey, name string) error {
+	sig, ok := signals[name]
+	if !ok {
+		return fmt.Errorf("unknown signal %q", name)
+	}
+	if key.PID <= 0 {
+		return fmt.Errorf("invalid pid %d", key.PID)
+	}
+	if err := checkProcess(key); err != nil {
+		return err
+	}
+	if err := syscall.Kill(int(key.PID), sig); err != nil {
+		return fmt.Errorf("sending SIG%s to %d: %w", name, key.PID, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package system
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func TestSendSignal(t *testing.T) {
+	self := &process.Process{Pid: int32(os.Getpid())}
+	created, err := self.CreateTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ProcessKey{PID: self.Pid, CreateTime: created}
+
+	// SIGCONT to a running process is harmless
+	if err := SendSignal(key, "CONT"); err != nil {
+		t.Errorf("SendSignal(self, CONT) = %v", err)
+	}
+	if err := SendSignal(key, "BOGUS"); err == nil {
+		t.Error("SendSignal with an unknown signal succeeded")
+	}
+	if err := SendSignal(ProcessKey{}, "TERM"); err == nil {
+		t.Error("SendSignal to pid 0 succeeded")
+	}
+	// A process started at another time has reused the PID
+	reused := ProcessKey{PID: key.PID, CreateTime: created - 1000}
+	if err := SendSignal(reused, "CONT"); err == nil || !strings.Contains(err.Error(), "another process") {
+		t.Errorf("SendSignal to a reused PID = %v", err)
+	}
+}
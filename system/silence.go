@@ -0,0 +1,185 @@
+package system
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow suppresses matching alerts at a recurring time, such
+// as during a nightly backup. Suppressed alerts are still raised and
+// recorded but are not notified or counted as active.
+type MaintenanceWindow struct {
+	Name     string
+	Days     []time.Weekday // days the window opens on; every day when empty
+	Start    time.Duration  // time of day the window opens, in local time
+	Duration time.Duration
+	// Alerts are patterns matched against the rule name and the alert
+	// source, with * matching any text; an empty list matches every alert
+	Alerts []string
+
+	patterns []*regexp.Regexp
+}
+
+// Active reports whether the window is open at t. A window may run past
+// midnight into the next day.
+func (w *MaintenanceWindow) Active(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for back := 0; time.Duration(back)*24*time.Hour < w.Start+w.Duration; back++ {
+		day := midnight.AddDate(0, 0, -back)
+		if !w.opensOn(day.Weekday()) {
+			continue
+		}
+		open := day.Add(w.Start)
+		if !t.Before(open) && t.Before(open.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// opensOn reports whether the window opens on a weekday
+func (w *MaintenanceWindow) opensOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the window applies to an alert source
+func (w *MaintenanceWindow) Matches(source string) bool {
+	if len(w.Alerts) == 0 {
+		return true
+	}
+	if w.patterns == nil {
+		for _, p := range w.Alerts {
+			w.patterns = append(w.patterns, globPattern(p))
+		}
+	}
+	rule, _, _ := strings.Cut(source, "{")
+	for _, re := range w.patterns {
+		if re.MatchString(rule) || re.MatchString(source) {
+			return true
+		}
+	}
+	return false
+}
+
+// globPattern compiles a pattern where * matches any text
+func globPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// SetMaintenanceWindows replaces the maintenance windows
+func (am *AlertManager) SetMaintenanceWindows(windows []MaintenanceWindow) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.windows = append([]MaintenanceWindow(nil), windows...)
+}
+
+// Acknowledge marks the active alert of a source as seen, so that it no
+// longer counts as needing attention, and reports whether there was one.
+// An alert that fires again later is not acknowledged.
+func (am *AlertManager) Acknowledge(source string) bool {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	i := am.activeIndex(source)
+	if i < 0 {
+		return false
+	}
+	am.Alerts[i].Acknowledged = true
+	return true
+}
+
+// Silence suppresses the alerts of a source until the given time,
+// including the one firing now
+func (am *AlertManager) Silence(source string, until time.Time) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if am.silences == nil {
+		am.silences = make(map[string]time.Time)
+	}
+	am.silences[source] = until
+	if i := am.activeIndex(source); i >= 0 {
+		am.Alerts[i].SuppressedBy = silenceReason(until)
+	}
+}
+
+// Unsilence lifts the silence of a source. An alert of the source that is
+// still firing is notified again on the next check.
+func (am *AlertManager) Unsilence(source string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	delete(am.silences, source)
+}
+
+// Silenced returns when the silence of a source ends, if it has one
+func (am *AlertManager) Silenced(source string) (time.Time, bool) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	until, ok := am.silences[source]
+	return until, ok
+}
+
+// suppression returns why alerts of a source are suppressed at now, or ""
+// when they are not. Expired silences are dropped. The caller must hold
+// am.mu.
+func (am *AlertManager) suppression(source string, now time.Time) string {
+	if until, ok := am.silences[source]; ok {
+		if now.Before(until) {
+			return silenceReason(until)
+		}
+		delete(am.silences, source)
+	}
+	for i := range am.windows {
+		if w := &am.windows[i]; w.Matches(source) && w.Active(now) {
+			return "maintenance " + w.Name
+		}
+	}
+	return ""
+}
+
+// silenceReason describes a silence for Alert.SuppressedBy
+func silenceReason(until time.Time) string {
+	return fmt.Sprintf("silenced until %s", until.Format("Jan 2 15:04"))
+}
+
+// updateSuppression applies the silences and maintenance windows in force
+// at now to the active alert of a source. An alert that stops being
+// suppressed is notified as firing. The caller must hold am.mu.
+func (am *AlertManager) updateSuppression(source string, now time.Time) {
+	i := am.activeIndex(source)
+	if i < 0 {
+		return
+	}
+	reason := am.suppression(source, now)
+	if reason == am.Alerts[i].SuppressedBy {
+		return
+	}
+	wasSuppressed := am.Alerts[i].SuppressedBy != ""
+	am.Alerts[i].SuppressedBy = reason
+	if wasSuppressed && reason == "" {
+		am.events = append(am.events, AlertEvent{Alert: am.Alerts[i], Time: now})
+	}
+}
+
+// activeIndex returns the index of the unresolved alert of a source, or
+// -1; the caller must hold am.mu
+func (am *AlertManager) activeIndex(source string) int {
+	for i, alert := range am.Alerts {
+		if alert.Source == source && !alert.Resolved {
+			return i
+		}
+	}
+	return -1
+}
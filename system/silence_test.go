@@ -0,0 +1,142 @@
+package system
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	// Saturdays from 23:00 for three hours
+	w := MaintenanceWindow{Name: "backup", Days: []time.Weekday{time.Saturday}, Start: 23 * time.Hour, Duration: 3 * time.Hour}
+	sat := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{sat.Add(22*time.Hour + 59*time.Minute), false},
+		{sat.Add(23 * time.Hour), true},
+		{sat.Add(25*time.Hour + 59*time.Minute), true}, // Sunday 01:59
+		{sat.Add(26 * time.Hour), false},
+		{sat.Add(-time.Hour), false}, // Friday 23:00
+	}
+	for _, tt := range tests {
+		if got := w.Active(tt.at); got != tt.want {
+			t.Errorf("Active(%s) = %t, want %t", tt.at.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+
+	daily := MaintenanceWindow{Start: 2 * time.Hour, Duration: 30 * time.Minute}
+	if !daily.Active(sat.AddDate(0, 0, 3).Add(2*time.Hour + 10*time.Minute)) {
+		t.Error("window without days is not active every day")
+	}
+}
+
+func TestMaintenanceWindowMatches(t *testing.T) {
+	w := MaintenanceWindow{Alerts: []string{"disk_usage", "net_*{iface=eth0}"}}
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"disk_usage{mountpoint=/}", true},
+		{"disk_usage", true},
+		{"net_saturated{iface=eth0}", true},
+		{"net_saturated{iface=eth1}", false},
+		{"memory_usage", false},
+	}
+	for _, tt := range tests {
+		if got := w.Matches(tt.source); got != tt.want {
+			t.Errorf("Matches(%q) = %t, want %t", tt.source, got, tt.want)
+		}
+	}
+	if !(&MaintenanceWindow{}).Matches("memory_usage") {
+		t.Error("window without alerts does not match every alert")
+	}
+}
+
+func TestAlertSuppression(t *testing.T) {
+	am := NewAlertManager(85, 85, 90, 80, 10)
+	var got []string
+	am.Subscribe(func(ev AlertEvent) {
+		got = append(got, fmt.Sprintf("%s resolved=%t suppressed=%q", ev.Alert.Source, ev.Alert.Resolved, ev.Alert.SuppressedBy))
+	})
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	am.SetMaintenanceWindows([]MaintenanceWindow{{Name: "patching", Start: 3 * time.Hour, Duration: 10 * time.Minute, Alerts: []string{"memory_*"}}})
+	check := func(at time.Duration, memory float64) {
+		am.CheckResourceAlerts(&Collector{
+			System: SystemInfo{LastUpdated: start.Add(at)},
+			Memory: MemoryInfo{UsedPercent: memory},
+		})
+	}
+	active := func() Alert {
+		for _, a := range am.Snapshot() {
+			if a.Source == "memory_usage" && !a.Resolved {
+				return a
+			}
+		}
+		t.Fatal("memory_usage is not firing")
+		return Alert{}
+	}
+
+	// Fires during the window: recorded, but needs no attention
+	check(0, 90)
+	if a := active(); a.SuppressedBy != "maintenance patching" || a.NeedsAttention() {
+		t.Errorf("alert in window: SuppressedBy = %q, NeedsAttention = %t", a.SuppressedBy, a.NeedsAttention())
+	}
+	// Notified once the window closes
+	check(10*time.Minute, 90)
+	if a := active(); a.SuppressedBy != "" || !a.NeedsAttention() {
+		t.Errorf("alert after window: SuppressedBy = %q, NeedsAttention = %t", a.SuppressedBy, a.NeedsAttention())
+	}
+
+	if !am.Acknowledge("memory_usage") {
+		t.Error("Acknowledge found no firing alert")
+	}
+	if active().NeedsAttention() {
+		t.Error("acknowledged alert needs attention")
+	}
+	if am.Acknowledge("cpu_usage") {
+		t.Error("Acknowledge of an alert that is not firing succeeded")
+	}
+
+	// A silence covers the alert firing now and those that fire later
+	until := start.Add(time.Hour)
+	am.Silence("memory_usage", until)
+	if a := active(); a.SuppressedBy == "" {
+		t.Error("silenced alert is not suppressed")
+	}
+	check(11*time.Minute, 70)
+	check(12*time.Minute, 90)
+	if a := active(); a.Acknowledged || a.SuppressedBy == "" {
+		t.Errorf("alert firing again: Acknowledged = %t, SuppressedBy = %q", a.Acknowledged, a.SuppressedBy)
+	}
+	if got, ok := am.Silenced("memory_usage"); !ok || !got.Equal(until) {
+		t.Errorf("Silenced = %s, %t, want %s", got, ok, until)
+	}
+
+	am.Unsilence("memory_usage")
+	check(13*time.Minute, 90)
+	if a := active(); a.SuppressedBy != "" {
+		t.Errorf("unsilenced alert: SuppressedBy = %q", a.SuppressedBy)
+	}
+
+	// Silences expire
+	am.Silence("memory_usage", start.Add(14*time.Minute))
+	check(14*time.Minute, 90)
+	if _, ok := am.Silenced("memory_usage"); ok {
+		t.Error("expired silence is still in force")
+	}
+
+	want := fmt.Sprint([]string{
+		`memory_usage resolved=false suppressed="maintenance patching"`,
+		`memory_usage resolved=false suppressed=""`,
+		`memory_usage resolved=true suppressed="silenced until Jan 2 04:00"`,
+		`memory_usage resolved=false suppressed="silenced until Jan 2 04:00"`,
+		`memory_usage resolved=false suppressed=""`, // unsilenced
+		`memory_usage resolved=false suppressed=""`, // silence expired
+	})
+	if fmt.Sprint(got) != want {
+		t.Errorf("events =\n%v\nwant\n%v", got, want)
+	}
+}
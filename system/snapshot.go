@@ -0,0 +1,50 @@
+package system
+
+// Snapshot is a consistent, read-only view of the collector state at the end
+// of a collection cycle. Collect hands one out after every cycle so the UI
+// can render without sharing memory with the collector; nothing in a
+// Snapshot is modified after it has been created.
+type Snapshot struct {
+	System       SystemInfo
+	CPU          CPUInfo
+	Memory       MemoryInfo
+	Disk         DiskInfo
+	Network      NetworkInfo
+	Process      ProcessInfo
+	Cgroups      CgroupInfo
+	Pressure     PressureInfo
+	Alerts       []Alert
+	MaxProcesses int
+	Report       CollectReport
+}
+
+// snapshot copies the current collector state. Sources replace their maps
+// and slices wholesale on every cycle, so only the values the collector
+// mutates in place are copied here.
+func (c *Collector) snapshot() *Snapshot {
+	snap := &Snapshot{
+		System:       c.System,
+		CPU:          c.CPU,
+		Memory:       c.Memory,
+		Disk:         c.Disk,
+		Network:      c.Network,
+		Process:      c.Process,
+		Cgroups:      c.Cgroups,
+		Pressure:     c.Pressure,
+		MaxProcesses: c.MaxProcesses,
+		Report:       c.LastReport,
+	}
+
+	snap.CPU.History = c.CPU.History.Copy()
+	snap.Memory.History = c.Memory.History.Copy()
+	snap.Pressure.CPUHistory = c.Pressure.CPUHistory.Copy()
+	snap.Pressure.MemoryHistory = c.Pressure.MemoryHistory.Copy()
+	snap.Pressure.IOHistory = c.Pressure.IOHistory.Copy()
+	snap.Process.Processes = append([]ProcessDetail(nil), c.Process.Processes...)
+
+	if c.AlertManager != nil {
+		snap.Alerts = c.AlertManager.Snapshot()
+	}
+
+	return snap
+}
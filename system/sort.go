@@ -0,0 +1,109 @@
+package system
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+)
+
+// SortType defines process sorting methods
+type SortType string
+
+// Process sort types. Numbers sort largest first, text alphabetically and
+// IDs in ascending order.
+const (
+	SortByCPU         SortType = "cpu"          // CPU usage since the previous cycle
+	SortByCPULifetime SortType = "cpu_lifetime" // CPU usage averaged over the process lifetime
+	SortByMemory      SortType = "memory"
+	SortByPID         SortType = "pid"
+	SortByName        SortType = "name"
+	SortByPPID        SortType = "ppid"
+	SortByUser        SortType = "user"
+	SortByState       SortType = "state"
+	SortByNice        SortType = "nice"
+	SortByThreads     SortType = "threads"
+	SortByRSS         SortType = "rss"
+	SortByVMS         SortType = "vms"
+	SortByStart       SortType = "start" // newest first
+	SortByRead        SortType = "read"
+	SortByWrite       SortType = "write"
+	SortByCommand     SortType = "cmd"
+	SortByCgroup      SortType = "cgroup"
+)
+
+// processOrder compares two processes for each sort type; a negative
+// result sorts a first
+var processOrder = map[SortType]func(a, b *ProcessDetail) int{
+	SortByCPU:         func(a, b *ProcessDetail) int { return cmp.Compare(b.CPUPercent, a.CPUPercent) },
+	SortByCPULifetime: func(a, b *ProcessDetail) int { return cmp.Compare(b.CPULifetime, a.CPULifetime) },
+	SortByMemory:      func(a, b *ProcessDetail) int { return cmp.Compare(b.MemPercent, a.MemPercent) },
+	SortByPID:         func(a, b *ProcessDetail) int { return cmp.Compare(a.PID, b.PID) },
+	SortByName:        func(a, b *ProcessDetail) int { return compareFold(a.Name, b.Name) },
+	SortByPPID:        func(a, b *ProcessDetail) int { return cmp.Compare(a.PPID, b.PPID) },
+	SortByUser:        func(a, b *ProcessDetail) int { return compareFold(a.Username, b.Username) },
+	SortByState: func(a, b *ProcessDetail) int {
+		return compareFold(strings.Join(a.Status, ","), strings.Join(b.Status, ","))
+	},
+	SortByNice:    func(a, b *ProcessDetail) int { return cmp.Compare(a.Nice, b.Nice) },
+	SortByThreads: func(a, b *ProcessDetail) int { return cmp.Compare(b.NumThreads, a.NumThreads) },
+	SortByRSS:     func(a, b *ProcessDetail) int { return cmp.Compare(b.MemRSS, a.MemRSS) },
+	SortByVMS:     func(a, b *ProcessDetail) int { return cmp.Compare(b.MemVMS, a.MemVMS) },
+	SortByStart:   func(a, b *ProcessDetail) int { return b.CreatedAt.Compare(a.CreatedAt) },
+	SortByRead:    func(a, b *ProcessDetail) int { return cmp.Compare(ioBytes(b, true), ioBytes(a, true)) },
+	SortByWrite:   func(a, b *ProcessDetail) int { return cmp.Compare(ioBytes(b, false), ioBytes(a, false)) },
+	SortByCommand: func(a, b *ProcessDetail) int { return compareFold(a.CmdLine, b.CmdLine) },
+	SortByCgroup:  func(a, b *ProcessDetail) int { return compareFold(a.Cgroup, b.Cgroup) },
+}
+
+// ParseSortType returns the sort type named s
+func ParseSortType(s string) (SortType, bool) {
+	_, ok := processOrder[SortType(s)]
+	return SortType(s), ok
+}
+
+// compareProcesses orders two processes by sortBy, falling back to CPU
+// usage for unknown sort types
+func compareProcesses(sortBy SortType, a, b *ProcessDetail) int {
+	order, ok := processOrder[sortBy]
+	if !ok {
+		order = processOrder[SortByCPU]
+	}
+	return order(a, b)
+}
+
+// SortProcessList sorts the processes according to the specified sort
+// type. Ties keep PID order.
+func SortProcessList(processes []ProcessDetail, sortBy SortType) {
+	sort.SliceStable(processes, func(i, j int) bool {
+		a, b := &processes[i], &processes[j]
+		if c := compareProcesses(sortBy, a, b); c != 0 {
+			return c < 0
+		}
+		return a.PID < b.PID
+	})
+}
+
+// compareFold compares strings ignoring case
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// ioBytes returns the bytes a process read or wrote, or 0 when unknown
+func ioBytes(p *ProcessDetail, read bool) uint64 {
+	switch {
+	case p.IO == nil:
+		return 0
+	case read:
+		return p.IO.ReadBytes
+	}
+	return p.IO.WriteBytes
+}
+
+// Descending reports whether the sort type puts the largest values first
+func (s SortType) Descending() bool {
+	switch s {
+	case SortByPID, SortByPPID, SortByNice, SortByName, SortByUser, SortByState, SortByCommand, SortByCgroup:
+		return false
+	}
+	return true
+}
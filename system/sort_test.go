@@ -0,0 +1,45 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortProcessList(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	processes := []ProcessDetail{
+		{PID: 30, PPID: 1, Name: "java", Username: "app", Nice: 5, MemRSS: 1 << 30, CreatedAt: now.Add(-time.Hour), IO: &ProcessIO{ReadBytes: 10}},
+		{PID: 1, Name: "Systemd", Username: "root", MemRSS: 8 << 20, CreatedAt: now.Add(-72 * time.Hour)},
+		{PID: 20, PPID: 1, Name: "postgres", Username: "postgres", Nice: -5, MemRSS: 8 << 20, CreatedAt: now, IO: &ProcessIO{ReadBytes: 99}},
+	}
+
+	tests := []struct {
+		sortBy SortType
+		want   string // PIDs in order
+	}{
+		{SortByPID, "1 20 30"},
+		{SortByPPID, "1 20 30"},
+		{SortByName, "30 20 1"},
+		{SortByUser, "30 20 1"},
+		{SortByNice, "20 1 30"},
+		{SortByRSS, "30 1 20"}, // equal RSS keeps PID order
+		{SortByStart, "20 30 1"},
+		{SortByRead, "20 30 1"},
+	}
+	for _, tt := range tests {
+		SortProcessList(processes, tt.sortBy)
+		var pids []string
+		for _, p := range processes {
+			pids = append(pids, fmt.Sprint(p.PID))
+		}
+		if got := strings.Join(pids, " "); got != tt.want {
+			t.Errorf("sorted by %s: %s, want %s", tt.sortBy, got, tt.want)
+		}
+	}
+
+	if _, ok := ParseSortType("bogus"); ok {
+		t.Error(`ParseSortType("bogus") succeeded`)
+	}
+}
@@ -0,0 +1,100 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Names of the built-in metric sources
+const (
+	SourceSystem  = "system"
+	SourceCPU     = "cpu"
+	SourceMemory  = "memory"
+	SourceDisk    = "disk"
+	SourceNetwork = "network"
+	SourceProcess = "process"
+)
+
+// Update stores the values gathered by a Source on the collector.
+// Sources return one from Collect so that gathering metrics never touches
+// collector state directly; the collector decides when the result lands.
+type Update func(c *Collector)
+
+// Source is a pluggable provider of metrics polled by the Collector on every
+// refresh. The host subsystems are covered by built-in sources; additional
+// sources can be added with Collector.RegisterSource.
+type Source interface {
+	// Name returns the unique name of the source
+	Name() string
+	// Enabled reports whether the collector should poll the source
+	Enabled() bool
+	// SetEnabled turns polling of the source on or off
+	SetEnabled(enabled bool)
+	// Collect gathers the source's metrics. The returned Update may be nil
+	// when there is nothing to store.
+	Collect(ctx context.Context) (Update, error)
+}
+
+// BaseSource implements the naming and enable/disable part of Source.
+// Embed it in a custom source and provide Collect.
+type BaseSource struct {
+	SourceName string
+	disabled   atomic.Bool
+}
+
+// Name returns the source name
+func (b *BaseSource) Name() string {
+	return b.SourceName
+}
+
+// Enabled reports whether the source is enabled
+func (b *BaseSource) Enabled() bool {
+	return !b.disabled.Load()
+}
+
+// SetEnabled enables or disables the source
+func (b *BaseSource) SetEnabled(enabled bool) {
+	b.disabled.Store(!enabled)
+}
+
+// RegisterSource adds a source to the collector. Sources are polled in
+// registration order and names must be unique.
+func (c *Collector) RegisterSource(src Source) error {
+	if src == nil {
+		return fmt.Errorf("source is nil")
+	}
+	if c.Source(src.Name()) != nil {
+		return fmt.Errorf("source %q is already registered", src.Name())
+	}
+	c.sources = append(c.sources, src)
+	return nil
+}
+
+// Sources returns the registered sources in polling order
+func (c *Collector) Sources() []Source {
+	sources := make([]Source, len(c.sources))
+	copy(sources, c.sources)
+	return sources
+}
+
+// Source returns the registered source with the given name, or nil
+func (c *Collector) Source(name string) Source {
+	for _, src := range c.sources {
+		if src.Name() == name {
+			return src
+		}
+	}
+	return nil
+}
+
+// SetSourceEnabled enables or disables a source by name. It reports whether
+// a source with that name exists.
+func (c *Collector) SetSourceEnabled(name string, enabled bool) bool {
+	src := c.Source(name)
+	if src == nil {
+		return false
+	}
+	src.SetEnabled(enabled)
+	return true
+}
@@ -0,0 +1,462 @@
+package system
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// DefaultSources returns the built-in host metric sources
+func DefaultSources() []Source {
+	return []Source{
+		NewSystemSource(),
+		NewCPUSource(),
+		NewMemorySource(),
+		NewDiskSource(),
+		NewNetworkSource(),
+		NewProcessSource(),
+		NewCgroupSource(),
+		NewPressureSource(),
+	}
+}
+
+// SystemSource gathers host information
+type SystemSource struct {
+	BaseSource
+}
+
+// NewSystemSource creates the host information source
+func NewSystemSource() *SystemSource {
+	return &SystemSource{BaseSource: BaseSource{SourceName: SourceSystem}}
+}
+
+// Collect gathers system information
+func (s *SystemSource) Collect(ctx context.Context) (Update, error) {
+	info, err := host.InfoWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *Collector) {
+		c.System.Hostname = info.Hostname
+		c.System.Platform = info.Platform
+		c.System.OS = info.OS
+		c.System.KernelVer = info.KernelVersion
+		c.System.Uptime = time.Duration(info.Uptime) * time.Second
+	}, nil
+}
+
+// CPUSource gathers CPU metrics. Usage is computed from the change in CPU
+// times since the previous sample, or since boot on the first sample.
+type CPUSource struct {
+	BaseSource
+	prevTotal  cpu.TimesStat
+	prevPerCPU []cpu.TimesStat
+}
+
+// NewCPUSource creates the CPU metrics source
+func NewCPUSource() *CPUSource {
+	return &CPUSource{BaseSource: BaseSource{SourceName: SourceCPU}}
+}
+
+// Collect gathers CPU metrics
+func (s *CPUSource) Collect(ctx context.Context) (Update, error) {
+	var info CPUInfo
+
+	// Get CPU usage (overall)
+	total, err := cpu.TimesWithContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(total) > 0 {
+		info.Usage = busyPercent(s.prevTotal, total[0])
+		s.prevTotal = total[0]
+	}
+
+	// Get per-CPU usage
+	perCPU, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	info.UsagePerCPU = make([]float64, len(perCPU))
+	for i, times := range perCPU {
+		var prev cpu.TimesStat
+		if i < len(s.prevPerCPU) {
+			prev = s.prevPerCPU[i]
+		}
+		info.UsagePerCPU[i] = busyPercent(prev, times)
+	}
+	s.prevPerCPU = perCPU
+	info.Cores = len(perCPU)
+
+	// Get load average
+	loadAvg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		// Not critical, just log and continue
+		log.Printf("Warning: Could not get load average: %v", err)
+	} else {
+		info.LoadAvg = loadAvg
+	}
+
+	// Try to get temperature (might not work on all systems)
+	// This is a simplified approach - real implementation might need to be platform-specific
+	temps, err := host.SensorsTemperaturesWithContext(ctx)
+	if err == nil {
+		for _, temp := range temps {
+			if temp.SensorKey == "coretemp_packageid0_input" ||
+				temp.SensorKey == "k10temp_tdie" ||
+				temp.SensorKey == "cpu_thermal_input" {
+				info.Temperature = temp.Temperature
+				break
+			}
+		}
+	}
+
+	return func(c *Collector) {
+		info.History = c.CPU.History
+		c.CPU = info
+	}, nil
+}
+
+// MemorySource gathers memory and swap metrics
+type MemorySource struct {
+	BaseSource
+}
+
+// NewMemorySource creates the memory metrics source
+func NewMemorySource() *MemorySource {
+	return &MemorySource{BaseSource: BaseSource{SourceName: SourceMemory}}
+}
+
+// Collect gathers memory metrics
+func (s *MemorySource) Collect(ctx context.Context) (Update, error) {
+	// Get virtual memory stats
+	vmem, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get swap memory stats
+	swap, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *Collector) {
+		c.Memory.Total = vmem.Total
+		c.Memory.Used = vmem.Used
+		c.Memory.Free = vmem.Free
+		c.Memory.UsedPercent = vmem.UsedPercent
+
+		c.Memory.SwapTotal = swap.Total
+		c.Memory.SwapUsed = swap.Used
+		c.Memory.SwapFree = swap.Free
+		c.Memory.SwapPercent = swap.UsedPercent
+	}, nil
+}
+
+// DiskSource gathers partition usage and disk I/O rates
+type DiskSource struct {
+	BaseSource
+	// Usage reports the usage of a mountpoint; it defaults to statfs on the
+	// live filesystem and can be replaced when replaying recorded hosts
+	Usage          func(ctx context.Context, path string) (*disk.UsageStat, error)
+	prevIOCounters map[string]disk.IOCountersStat
+	lastSample     time.Time
+}
+
+// NewDiskSource creates the disk metrics source
+func NewDiskSource() *DiskSource {
+	return &DiskSource{BaseSource: BaseSource{SourceName: SourceDisk}}
+}
+
+// Collect gathers disk metrics
+func (s *DiskSource) Collect(ctx context.Context) (Update, error) {
+	// Get partitions
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	usageFunc := s.Usage
+	if usageFunc == nil {
+		usageFunc = disk.UsageWithContext
+	}
+
+	// Get usage for each partition
+	usageStats := make(map[string]*disk.UsageStat)
+	for _, partition := range partitions {
+		usage, err := usageFunc(ctx, partition.Mountpoint)
+		if err != nil {
+			log.Printf("Warning: Could not get usage for %s: %v", partition.Mountpoint, err)
+			continue
+		}
+		usageStats[partition.Mountpoint] = usage
+	}
+
+	info := DiskInfo{
+		Partitions: partitions,
+		UsageStats: usageStats,
+	}
+
+	// Get IO counters
+	ioCounters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		log.Printf("Warning: Could not get disk IO counters: %v", err)
+	} else {
+		now := CollectTime(ctx)
+		// Calculate read/write rates
+		if s.prevIOCounters != nil {
+			timeDelta := rateInterval(s.lastSample, now)
+			info.ReadRate = make(map[string]float64)
+			info.WriteRate = make(map[string]float64)
+
+			for name, counter := range ioCounters {
+				if prev, ok := s.prevIOCounters[name]; ok {
+					readDelta := float64(counter.ReadBytes - prev.ReadBytes)
+					writeDelta := float64(counter.WriteBytes - prev.WriteBytes)
+					info.ReadRate[name] = readDelta / timeDelta
+					info.WriteRate[name] = writeDelta / timeDelta
+				}
+			}
+		}
+		info.PrevIOCounters = s.prevIOCounters
+		info.IOCounters = ioCounters
+		s.prevIOCounters = ioCounters
+		s.lastSample = now
+	}
+
+	return func(c *Collector) {
+		c.Disk = info
+	}, nil
+}
+
+// NetworkSource gathers interface throughput and connections
+type NetworkSource struct {
+	BaseSource
+	prevIOCounters map[string]net.IOCountersStat
+	lastSample     time.Time
+}
+
+// NewNetworkSource creates the network metrics source
+func NewNetworkSource() *NetworkSource {
+	return &NetworkSource{BaseSource: BaseSource{SourceName: SourceNetwork}}
+}
+
+// Collect gathers network metrics
+func (s *NetworkSource) Collect(ctx context.Context) (Update, error) {
+	// Get network interfaces
+	interfaces, err := net.InterfacesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := NetworkInfo{
+		Interfaces: interfaces,
+	}
+
+	// Get network IO counters
+	ioCounters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		log.Printf("Warning: Could not get network IO counters: %v", err)
+	} else {
+		now := CollectTime(ctx)
+		countersMap := make(map[string]net.IOCountersStat)
+		for _, ioc := range ioCounters {
+			countersMap[ioc.Name] = ioc
+		}
+
+		// Calculate recv/sent rates
+		if s.prevIOCounters != nil {
+			timeDelta := rateInterval(s.lastSample, now)
+			info.RecvRate = make(map[string]float64)
+			info.SentRate = make(map[string]float64)
+
+			for name, counter := range countersMap {
+				if prev, ok := s.prevIOCounters[name]; ok {
+					recvDelta := float64(counter.BytesRecv - prev.BytesRecv)
+					sentDelta := float64(counter.BytesSent - prev.BytesSent)
+					info.RecvRate[name] = recvDelta / timeDelta
+					info.SentRate[name] = sentDelta / timeDelta
+				}
+			}
+		}
+		info.PrevIOCounters = s.prevIOCounters
+		info.IOCounters = countersMap
+		s.prevIOCounters = countersMap
+		s.lastSample = now
+	}
+
+	// Get network connections (might require elevated privileges)
+	connections, err := net.ConnectionsWithContext(ctx, "all")
+	if err != nil {
+		log.Printf("Warning: Could not get network connections: %v", err)
+	} else {
+		info.Connections = connections
+	}
+
+	return func(c *Collector) {
+		c.Network = info
+	}, nil
+}
+
+// ProcessSource gathers per-process metrics. The static attributes of a
+// process are read once and cached; each cycle only reads the values that
+// change.
+type ProcessSource struct {
+	BaseSource
+	cache *processCache
+}
+
+// NewProcessSource creates the process metrics source
+func NewProcessSource() *ProcessSource {
+	return &ProcessSource{BaseSource: BaseSource{SourceName: SourceProcess}, cache: newProcessCache()}
+}
+
+// Collect gathers process metrics
+func (s *ProcessSource) Collect(ctx context.Context) (Update, error) {
+	began := time.Now()
+	now := CollectTime(ctx)
+
+	// Get all processes
+	pids, err := process.PidsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Memory percentages are relative to the physical memory, read once
+	var memTotal uint64
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		memTotal = vm.Total
+	}
+
+	s.cache.cycle++
+	stats := ProcessCollectStats{Scanned: len(pids)}
+	processes := make([]ProcessDetail, 0, len(pids))
+	var walkErr error
+	for _, pid := range pids {
+		// Stop once the source has missed its deadline rather than keep
+		// walking the process list for a cycle that has moved on; the
+		// processes read so far are still delivered
+		if walkErr = ctx.Err(); walkErr != nil {
+			stats.Partial = true
+			break
+		}
+		// The PID list comes from the proc directory in use, so the process
+		// is read from there directly rather than probed on the live system
+		p := &process.Process{Pid: pid}
+
+		st, err := readProcStat(ctx, p)
+		if err != nil {
+			continue // Skip processes that have exited
+		}
+
+		entry, loaded, err := s.cache.lookup(ctx, p, st)
+		if err != nil {
+			continue
+		}
+		if loaded {
+			stats.Loaded++
+		} else {
+			stats.Cached++
+		}
+
+		lifetime := lifetimeCPUPercent(st.CPUTime, entry.createdAt, now)
+		cpuPercent := entry.intervalCPUPercent(st.CPUTime, now, lifetime)
+
+		var memPercent float32
+		if memTotal > 0 {
+			memPercent = 100 * float32(st.RSS) / float32(memTotal)
+		}
+
+		// Get I/O counters; reading them needs access to the process
+		var procIO *ProcessIO
+		if counters, err := p.IOCountersWithContext(ctx); err == nil && counters != nil {
+			procIO = &ProcessIO{
+				ReadSyscalls:  counters.ReadCount,
+				WriteSyscalls: counters.WriteCount,
+				ReadBytes:     counters.ReadBytes,
+				WriteBytes:    counters.WriteBytes,
+			}
+		}
+
+		processes = append(processes, ProcessDetail{
+			PID:         pid,
+			Name:        entry.name,
+			Username:    entry.username,
+			Status:      []string{st.Status},
+			CPUPercent:  cpuPercent,
+			CPULifetime: lifetime,
+			MemPercent:  memPercent,
+			CreatedAt:   entry.createdAt,
+			NumThreads:  st.NumThreads,
+			CmdLine:     entry.cmdLine,
+			PPID:        st.PPID,
+			Nice:        st.Nice,
+			MemRSS:      st.RSS,
+			MemVMS:      st.VMS,
+			IO:          procIO,
+			Cgroup:      readProcCgroup(ctx, p),
+		})
+	}
+	if !stats.Partial {
+		// Processes not reached by a partial walk may still be running
+		stats.Evicted = s.cache.evict()
+	}
+	stats.Duration = time.Since(began)
+
+	return func(c *Collector) {
+		// Sort the processes according to the sort type
+		c.Process.SortBy = c.SortBy()
+		c.SortProcesses(processes)
+
+		c.Process.Total = len(processes)
+
+		// Store processes; keep full list but UI will respect MaxProcesses when rendering
+		c.Process.Processes = processes
+		c.Process.Stats = stats
+	}, walkErr
+}
+
+// busyPercent returns the share of non-idle CPU time between two samples
+func busyPercent(prev, cur cpu.TimesStat) float64 {
+	prevTotal, prevBusy := cpuTotals(prev)
+	curTotal, curBusy := cpuTotals(cur)
+	if curTotal <= prevTotal {
+		return 0
+	}
+	percent := (curBusy - prevBusy) / (curTotal - prevTotal) * 100
+	if percent < 0 {
+		return 0
+	}
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// cpuTotals returns the total and busy CPU time of a sample. Guest time is
+// already accounted for in user time on Linux.
+func cpuTotals(t cpu.TimesStat) (total, busy float64) {
+	total = t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	busy = total - t.Idle - t.Iowait
+	return total, busy
+}
+
+// rateInterval returns the number of seconds between two samples for
+// per-second rate calculations
+func rateInterval(last, now time.Time) float64 {
+	timeDelta := now.Sub(last).Seconds()
+	if timeDelta <= 0 {
+		timeDelta = 1 // Avoid division by zero
+	}
+	return timeDelta
+}
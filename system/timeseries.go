@@ -12,3 +12,8 @@ type TimeSeriesPoint struct {
 type TimeSeries struct {
     Points []TimeSeriesPoint
 }
+
+// Copy returns a TimeSeries that does not share its points with ts
+func (ts TimeSeries) Copy() TimeSeries {
+    return TimeSeries{Points: append([]TimeSeriesPoint(nil), ts.Points...)}
+}
@@ -0,0 +1,127 @@
+package system
+
+import (
+	"cmp"
+	"sort"
+)
+
+// ProcessNode is a process in the process tree along with the totals of the
+// subtree rooted at it
+type ProcessNode struct {
+	Process  ProcessDetail
+	Children []*ProcessNode
+
+	// Totals of the process and all its descendants
+	TreeCPU         float64
+	TreeCPULifetime float64
+	TreeMem         float32
+	TreeRSS         uint64
+	TreeCount       int
+}
+
+// BuildProcessTree arranges processes by parent PID. Processes whose parent
+// is not in the list become roots. Roots and siblings are sorted by sortBy;
+// CPU, memory and RSS sorting use the subtree totals, so the busiest
+// subtree comes first.
+func BuildProcessTree(processes []ProcessDetail, sortBy SortType) []*ProcessNode {
+	nodes := make(map[int32]*ProcessNode, len(processes))
+	for _, p := range processes {
+		nodes[p.PID] = &ProcessNode{Process: p}
+	}
+
+	var roots []*ProcessNode
+	for _, p := range processes {
+		node := nodes[p.PID]
+		parent, ok := nodes[p.PPID]
+		if !ok || p.PPID == p.PID {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	// PID reuse between reads can make a parent chain loop back on itself;
+	// such processes are unreachable from the roots, so make each loop a root
+	visited := make(map[int32]bool, len(processes))
+	for _, root := range roots {
+		markVisited(root, visited)
+	}
+	for _, p := range processes {
+		if visited[p.PID] {
+			continue
+		}
+		node := nodes[p.PID]
+		parent := nodes[p.PPID]
+		for i, child := range parent.Children {
+			if child == node {
+				parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+				break
+			}
+		}
+		roots = append(roots, node)
+		markVisited(node, visited)
+	}
+
+	for _, root := range roots {
+		sumTree(root)
+	}
+	sortTree(roots, sortBy)
+	return roots
+}
+
+// markVisited records node and its descendants in visited
+func markVisited(node *ProcessNode, visited map[int32]bool) {
+	visited[node.Process.PID] = true
+	for _, child := range node.Children {
+		markVisited(child, visited)
+	}
+}
+
+// sumTree computes the subtree totals of node and its descendants
+func sumTree(node *ProcessNode) {
+	node.TreeCPU = node.Process.CPUPercent
+	node.TreeCPULifetime = node.Process.CPULifetime
+	node.TreeMem = node.Process.MemPercent
+	node.TreeRSS = node.Process.MemRSS
+	node.TreeCount = 1
+	for _, child := range node.Children {
+		sumTree(child)
+		node.TreeCPU += child.TreeCPU
+		node.TreeCPULifetime += child.TreeCPULifetime
+		node.TreeMem += child.TreeMem
+		node.TreeRSS += child.TreeRSS
+		node.TreeCount += child.TreeCount
+	}
+}
+
+// sortTree sorts nodes and, recursively, the children of each node. CPU
+// and memory order by subtree totals; other sort types by the process
+// itself.
+func sortTree(nodes []*ProcessNode, sortBy SortType) {
+	if _, ok := processOrder[sortBy]; !ok {
+		sortBy = SortByCPU
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		var c int
+		switch sortBy {
+		case SortByCPU:
+			c = cmp.Compare(b.TreeCPU, a.TreeCPU)
+		case SortByCPULifetime:
+			c = cmp.Compare(b.TreeCPULifetime, a.TreeCPULifetime)
+		case SortByMemory:
+			c = cmp.Compare(b.TreeMem, a.TreeMem)
+		case SortByRSS:
+			c = cmp.Compare(b.TreeRSS, a.TreeRSS)
+		default:
+			c = compareProcesses(sortBy, &a.Process, &b.Process)
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return a.Process.PID < b.Process.PID
+	})
+	for _, node := range nodes {
+		sortTree(node.Children, sortBy)
+	}
+}
@@ -0,0 +1,65 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// treeString renders a process tree as "pid(children...)" for comparison
+func treeString(nodes []*ProcessNode) string {
+	var parts []string
+	for _, n := range nodes {
+		s := fmt.Sprint(n.Process.PID)
+		if len(n.Children) > 0 {
+			s += "(" + treeString(n.Children) + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildProcessTree(t *testing.T) {
+	processes := []ProcessDetail{
+		{PID: 1, PPID: 0, Name: "init", CPUPercent: 1, MemPercent: 1, MemRSS: 10},
+		{PID: 10, PPID: 1, Name: "nginx", CPUPercent: 0, MemPercent: 1, MemRSS: 10},
+		{PID: 11, PPID: 10, Name: "worker", CPUPercent: 30, CPULifetime: 1, MemPercent: 2, MemRSS: 20},
+		{PID: 12, PPID: 10, Name: "worker", CPUPercent: 40, CPULifetime: 2, MemPercent: 2, MemRSS: 20},
+		{PID: 20, PPID: 1, Name: "db", CPUPercent: 50, CPULifetime: 80, MemPercent: 20, MemRSS: 200},
+		{PID: 99, PPID: 98, Name: "orphan", CPUPercent: 5},
+	}
+
+	roots := BuildProcessTree(processes, SortByCPU)
+	// nginx's workers together outweigh db, so nginx sorts first
+	if got, want := treeString(roots), "1(10(12 11) 20) 99"; got != want {
+		t.Errorf("tree by CPU = %s, want %s", got, want)
+	}
+	init := roots[0]
+	if init.TreeCPU != 121 || init.TreeMem != 26 || init.TreeRSS != 260 || init.TreeCount != 5 {
+		t.Errorf("init totals = cpu %v mem %v rss %v count %d", init.TreeCPU, init.TreeMem, init.TreeRSS, init.TreeCount)
+	}
+
+	// db has been busy for longer, which only the lifetime average shows
+	if got, want := treeString(BuildProcessTree(processes, SortByCPULifetime)), "1(20 10(12 11)) 99"; got != want {
+		t.Errorf("tree by lifetime CPU = %s, want %s", got, want)
+	}
+	if got, want := treeString(BuildProcessTree(processes, SortByMemory)), "1(20 10(11 12)) 99"; got != want {
+		t.Errorf("tree by memory = %s, want %s", got, want)
+	}
+	if got, want := treeString(BuildProcessTree(processes, SortByName)), "1(20 10(11 12)) 99"; got != want {
+		t.Errorf("tree by name = %s, want %s", got, want)
+	}
+}
+
+func TestBuildProcessTreeCycle(t *testing.T) {
+	// A PID reused between reads can make two processes each other's parent
+	processes := []ProcessDetail{
+		{PID: 5, PPID: 6},
+		{PID: 6, PPID: 5},
+		{PID: 7, PPID: 7},
+	}
+	roots := BuildProcessTree(processes, SortByPID)
+	if got, want := treeString(roots), "5(6) 7"; got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+}
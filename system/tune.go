@@ -0,0 +1,129 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range of nice values
+const (
+	MinNice = -20
+	MaxNice = 19
+)
+
+// ErrTuneUnsupported is returned when renicing or changing the CPU affinity
+// is not available on this platform
+var ErrTuneUnsupported = errors.New("not supported on this platform")
+
+// Source names of renice and affinity events in the alert log
+const (
+	SourceRenice   = "renice"
+	SourceAffinity = "affinity"
+)
+
+// ValidateRenice checks that nice is in range and that the current user is
+// allowed to change the nice value of process pid from current to nice.
+// Permission problems match os.ErrPermission with errors.Is.
+func ValidateRenice(pid int32, current, nice int) error {
+	if nice < MinNice || nice > MaxNice {
+		return fmt.Errorf("nice value %d is outside %d..%d", nice, MinNice, MaxNice)
+	}
+	return checkRenicePermission(pid, current, nice)
+}
+
+// ValidateAffinity checks that cpus is a non-empty set of online CPUs and
+// that the current user is allowed to change the affinity of process pid.
+// Permission problems match os.ErrPermission with errors.Is. Where the
+// online CPUs cannot be read, CPUs that do not exist are left for the
+// kernel to reject.
+func ValidateAffinity(pid int32, cpus []int) error {
+	if len(cpus) == 0 {
+		return fmt.Errorf("affinity mask must contain at least one CPU")
+	}
+	online, err := onlineCPUs()
+	isOnline := make(map[int]bool, len(online))
+	for _, cpu := range online {
+		isOnline[cpu] = true
+	}
+	for _, cpu := range cpus {
+		if cpu < 0 {
+			return fmt.Errorf("invalid CPU %d", cpu)
+		}
+		if err == nil && !isOnline[cpu] {
+			return fmt.Errorf("CPU %d is not online (online CPUs: %s)", cpu, FormatCPUList(online))
+		}
+	}
+	return checkOwner(pid)
+}
+
+// onlineCPUs returns the CPUs the kernel lists as online. Their numbers
+// need not be contiguous, and they are not limited to the CPUs this
+// process may run on.
+func onlineCPUs() ([]int, error) {
+	data, err := os.ReadFile(hostSys(context.Background(), "devices", "system", "cpu", "online"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCPUList(strings.TrimSpace(string(data)))
+}
+
+// ParseCPUList parses a CPU list such as "0-3,6" into sorted CPU numbers
+func ParseCPUList(s string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU %q", lo)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("invalid CPU %q", hi)
+			}
+		}
+		if first < 0 || last < first {
+			return nil, fmt.Errorf("invalid CPU range %q", part)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			seen[cpu] = true
+		}
+	}
+
+	cpus := make([]int, 0, len(seen))
+	for cpu := range seen {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// FormatCPUList formats CPU numbers as a compact list such as "0-3,6"
+func FormatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] <= sorted[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
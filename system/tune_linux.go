@@ -0,0 +1,197 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// capSysNice is the capability that allows renicing and changing the
+// affinity of any process
+const capSysNice = 23
+
+// Renice sets the nice value of the process of key, unless its PID now
+// belongs to another process. On Linux the nice value belongs to a thread,
+// so with allThreads every thread of the process is changed; otherwise only
+// the main thread is.
+func Renice(key ProcessKey, nice int, allThreads bool) error {
+	if err := checkProcess(key); err != nil {
+		return err
+	}
+	pid := key.PID
+	for _, tid := range tuneTargets(pid, allThreads) {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, int(tid), nice); err != nil {
+			if errors.Is(err, unix.ESRCH) && tid != pid {
+				continue // thread exited in the meantime
+			}
+			return fmt.Errorf("setting nice %d on %d: %w", nice, tid, describeTuneError(err))
+		}
+	}
+	return nil
+}
+
+// Affinity returns the CPUs process pid may run on
+func Affinity(pid int32) ([]int, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(int(pid), &set); err != nil {
+		return nil, fmt.Errorf("reading affinity of %d: %w", pid, describeTuneError(err))
+	}
+	var cpus []int
+	for cpu := 0; cpu < len(set)*64; cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// SetAffinity restricts the process of key to the given CPUs, unless its
+// PID now belongs to another process. Like the nice value, affinity is per
+// thread; allThreads applies it to every thread.
+func SetAffinity(key ProcessKey, cpus []int, allThreads bool) error {
+	if err := checkProcess(key); err != nil {
+		return err
+	}
+	pid := key.PID
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	for _, tid := range tuneTargets(pid, allThreads) {
+		if err := unix.SchedSetaffinity(int(tid), &set); err != nil {
+			if errors.Is(err, unix.ESRCH) && tid != pid {
+				continue
+			}
+			return fmt.Errorf("setting affinity %s on %d: %w", FormatCPUList(cpus), tid, describeTuneError(err))
+		}
+	}
+	return nil
+}
+
+// tuneTargets returns the thread IDs to change: the main thread, or all
+// threads listed in /proc/<pid>/task
+func tuneTargets(pid int32, allThreads bool) []int32 {
+	if !allThreads {
+		return []int32{pid}
+	}
+	entries, err := os.ReadDir(hostProc(context.Background(), strconv.Itoa(int(pid)), "task"))
+	if err != nil {
+		return []int32{pid}
+	}
+	tids := []int32{pid}
+	for _, e := range entries {
+		tid, err := strconv.Atoi(e.Name())
+		if err == nil && int32(tid) != pid {
+			tids = append(tids, int32(tid))
+		}
+	}
+	return tids
+}
+
+// checkRenicePermission reports whether the current user may change the
+// nice value of pid from current to nice
+func checkRenicePermission(pid int32, current, nice int) error {
+	if err := checkOwner(pid); err != nil {
+		return err
+	}
+	if nice >= current || privileged() {
+		return nil
+	}
+	// Unprivileged users may lower the nice value down to 20 - RLIMIT_NICE
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NICE, &limit); err == nil {
+		if floor := 20 - int(limit.Cur); nice >= floor {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: lowering the nice value (raising priority) needs root or CAP_SYS_NICE", os.ErrPermission)
+}
+
+// checkOwner reports whether the current user may tune pid: the process
+// must belong to the user unless they are privileged
+func checkOwner(pid int32) error {
+	if privileged() {
+		return nil
+	}
+	ruid, euid, err := processUIDs(pid)
+	if err != nil {
+		return err
+	}
+	if me := os.Geteuid(); me != ruid && me != euid {
+		return fmt.Errorf("%w: process %d belongs to uid %d, you are uid %d", os.ErrPermission, pid, ruid, me)
+	}
+	return nil
+}
+
+// privileged reports whether the current user is root or has CAP_SYS_NICE
+func privileged() bool {
+	if os.Geteuid() == 0 {
+		return true
+	}
+	caps, err := statusField("self", "CapEff")
+	if err != nil {
+		return false
+	}
+	mask, err := strconv.ParseUint(caps, 16, 64)
+	return err == nil && mask&(1<<capSysNice) != 0
+}
+
+// processUIDs returns the real and effective user IDs of a process
+func processUIDs(pid int32) (ruid, euid int, err error) {
+	uids, err := statusField(strconv.Itoa(int(pid)), "Uid")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(uids)
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("malformed Uid line for %d", pid)
+	}
+	if ruid, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if euid, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	return ruid, euid, nil
+}
+
+// statusField returns a field of /proc/<pid>/status
+func statusField(pid, name string) (string, error) {
+	path := hostProc(context.Background(), pid, "status")
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), ":"); ok && key == name {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no %s field in %s", name, path)
+}
+
+// describeTuneError makes kernel errors from renice and affinity calls
+// readable while keeping them matchable with errors.Is
+func describeTuneError(err error) error {
+	switch {
+	case errors.Is(err, unix.ESRCH):
+		return fmt.Errorf("process no longer exists: %w", err)
+	case errors.Is(err, unix.EPERM), errors.Is(err, unix.EACCES):
+		return fmt.Errorf("%w (needs root or CAP_SYS_NICE): %w", os.ErrPermission, err)
+	case errors.Is(err, unix.EINVAL):
+		return fmt.Errorf("no allowed CPU in the mask: %w", err)
+	}
+	return err
+}
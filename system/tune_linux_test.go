@@ -0,0 +1,81 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func TestReniceAndAffinity(t *testing.T) {
+	// Tune a child process rather than the test binary, whose nice value
+	// an unprivileged user could not lower again
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("starting sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	child := &process.Process{Pid: int32(cmd.Process.Pid)}
+	created, err := child.CreateTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ProcessKey{PID: child.Pid, CreateTime: created}
+
+	// Raising the nice value of our own processes is always allowed
+	nice, err := processNiceOf(key.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nice < MaxNice {
+		if err := ValidateRenice(key.PID, int(nice), int(nice)+1); err != nil {
+			t.Fatalf("ValidateRenice(child) = %v", err)
+		}
+		if err := Renice(key, int(nice)+1, true); err != nil {
+			t.Fatalf("Renice(child) = %v", err)
+		}
+		if got, _ := processNiceOf(key.PID); got != nice+1 {
+			t.Errorf("nice after Renice = %d, want %d", got, nice+1)
+		}
+	}
+
+	// Setting the affinity to the current mask is a no-op
+	cpus, err := Affinity(key.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAffinity(key, cpus, false); err != nil {
+		t.Fatalf("SetAffinity(child) = %v", err)
+	}
+	if got, _ := Affinity(key.PID); !reflect.DeepEqual(got, cpus) {
+		t.Errorf("Affinity after SetAffinity = %v, want %v", got, cpus)
+	}
+
+	// A PID that now belongs to another process is left alone
+	reused := ProcessKey{PID: key.PID, CreateTime: created - 1000}
+	if err := Renice(reused, MaxNice, false); err == nil {
+		t.Error("Renice of a reused PID succeeded")
+	}
+	if err := SetAffinity(reused, cpus, false); err == nil {
+		t.Error("SetAffinity of a reused PID succeeded")
+	}
+	if err := Renice(ProcessKey{PID: 0x7fffffff}, 0, false); err == nil {
+		t.Error("Renice of a missing process succeeded")
+	}
+}
+
+// processNiceOf reads the nice value of a live process
+func processNiceOf(pid int32) (int32, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	st, err := parseProcStat(string(data))
+	return st.Nice, err
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package system
+
+// Renice sets the nice value of a process
+func Renice(key ProcessKey, nice int, allThreads bool) error {
+	return ErrTuneUnsupported
+}
+
+// Affinity returns the CPUs process pid may run on
+func Affinity(pid int32) ([]int, error) {
+	return nil, ErrTuneUnsupported
+}
+
+// SetAffinity restricts a process to the given CPUs
+func SetAffinity(key ProcessKey, cpus []int, allThreads bool) error {
+	return ErrTuneUnsupported
+}
+
+// checkRenicePermission leaves permission checks to the operating system
+func checkRenicePermission(pid int32, current, nice int) error {
+	return nil
+}
+
+// checkOwner leaves permission checks to the operating system
+func checkOwner(pid int32) error {
+	return nil
+}
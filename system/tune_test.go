@@ -0,0 +1,89 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+		ok   bool
+	}{
+		{"0", []int{0}, true},
+		{"0-3,6", []int{0, 1, 2, 3, 6}, true},
+		{" 5, 1-2 ,2", []int{1, 2, 5}, true},
+		{"", []int{}, true},
+		{"3-1", nil, false},
+		{"-1", nil, false},
+		{"a", nil, false},
+		{"1-x", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPUList(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseCPUList(%q) error = %v, want ok=%v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCPUList(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCPUList(t *testing.T) {
+	tests := []struct {
+		in   []int
+		want string
+	}{
+		{nil, ""},
+		{[]int{0}, "0"},
+		{[]int{3, 0, 1, 2, 6}, "0-3,6"},
+		{[]int{1, 1, 2, 4, 5}, "1-2,4-5"},
+	}
+	for _, tt := range tests {
+		if got := FormatCPUList(tt.in); got != tt.want {
+			t.Errorf("FormatCPUList(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRange(t *testing.T) {
+	if err := ValidateRenice(1, 0, MaxNice+1); err == nil {
+		t.Errorf("ValidateRenice accepted nice %d", MaxNice+1)
+	}
+	if err := ValidateRenice(1, 0, MinNice-1); err == nil {
+		t.Errorf("ValidateRenice accepted nice %d", MinNice-1)
+	}
+	if err := ValidateAffinity(1, nil); err == nil {
+		t.Error("ValidateAffinity accepted an empty mask")
+	}
+}
+
+func TestValidateAffinityOnline(t *testing.T) {
+	// CPUs 1 and 4 and up are offline
+	root := t.TempDir()
+	dir := filepath.Join(root, "devices", "system", "cpu")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "online"), []byte("0,2-3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOST_SYS", root)
+
+	pid := int32(os.Getpid())
+	if err := ValidateAffinity(pid, []int{0, 3}); err != nil {
+		t.Errorf("ValidateAffinity(0,3) = %v", err)
+	}
+	for _, cpu := range []int{1, 4, 1 << 20} {
+		err := ValidateAffinity(pid, []int{cpu})
+		if err == nil || !strings.Contains(err.Error(), "online CPUs: 0,2-3") {
+			t.Errorf("ValidateAffinity(%d) = %v, want an offline CPU error", cpu, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/alertlog"
+	"go_system_monitor/system"
+)
+
+// historyRanges are the time ranges [ and ] step through, as
+// alertlog.ParseRange expressions
+var historyRanges = []struct {
+	label string
+	expr  string
+}{
+	{"last hour", "1h"},
+	{"last 24 hours", "24h"},
+	{"last 7 days", "7d"},
+	{"last 30 days", "30d"},
+	{"all time", "all"},
+}
+
+// AlertHistoryView lists the alerts of the persistent alert history that
+// were active in a time range, with how long they fired
+type AlertHistoryView struct {
+	width     int
+	height    int
+	available bool // whether an alert history is kept
+	active    bool // shown instead of the current alerts
+	preset    int  // index into historyRanges, or -1 for a custom range
+	custom    string
+	entries   []alertlog.Entry
+	now       time.Time
+	scrollPos int
+	note      string     // outcome of the last export
+	rangeBar  *FilterBar // edits a custom range
+}
+
+// NewAlertHistoryView creates the view showing the last 24 hours
+func NewAlertHistoryView() *AlertHistoryView {
+	bar := NewFilterBar()
+	bar.SetHint("Enter: Apply • Esc: Cancel • Ctrl+U: Clear • e.g. 12h, 7d..1d, 2024-01-02, 2024-01-02..2024-01-05")
+	return &AlertHistoryView{preset: 1, rangeBar: bar}
+}
+
+// SetSize updates the view dimensions
+func (v *AlertHistoryView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
+
+// SetAvailable records whether an alert history is kept
+func (v *AlertHistoryView) SetAvailable(available bool) {
+	v.available = available
+	if !available {
+		v.active = false
+	}
+}
+
+// Available reports whether an alert history is kept
+func (v *AlertHistoryView) Available() bool {
+	return v.available
+}
+
+// Toggle switches the Alerts tab between the current alerts and the
+// history
+func (v *AlertHistoryView) Toggle() {
+	v.active = v.available && !v.active
+	v.note = ""
+}
+
+// Active reports whether the history is shown
+func (v *AlertHistoryView) Active() bool {
+	return v.active
+}
+
+// Range returns the selected time range as an alertlog.ParseRange
+// expression
+func (v *AlertHistoryView) Range() string {
+	if v.preset < 0 {
+		return v.custom
+	}
+	return historyRanges[v.preset].expr
+}
+
+// rangeLabel describes the selected time range
+func (v *AlertHistoryView) rangeLabel() string {
+	if v.preset < 0 {
+		return v.custom
+	}
+	return historyRanges[v.preset].label
+}
+
+// NextRange selects the next longer preset range
+func (v *AlertHistoryView) NextRange() {
+	v.selectPreset(v.preset + 1)
+}
+
+// PrevRange selects the next shorter preset range
+func (v *AlertHistoryView) PrevRange() {
+	if v.preset < 0 {
+		v.selectPreset(len(historyRanges) - 1)
+		return
+	}
+	v.selectPreset(v.preset - 1)
+}
+
+// selectPreset selects preset i, clamped to the presets
+func (v *AlertHistoryView) selectPreset(i int) {
+	v.preset = max(0, min(i, len(historyRanges)-1))
+	v.scrollPos = 0
+}
+
+// SetCustomRange selects a range typed in the range bar
+func (v *AlertHistoryView) SetCustomRange(expr string) {
+	v.preset, v.custom = -1, expr
+	for i, r := range historyRanges {
+		if r.expr == expr {
+			v.preset = i
+		}
+	}
+	v.scrollPos = 0
+}
+
+// RangeBar returns the bar used to type a custom range
+func (v *AlertHistoryView) RangeBar() *FilterBar {
+	return v.rangeBar
+}
+
+// SetEntries replaces the alerts shown; durations of active alerts run up
+// to now
+func (v *AlertHistoryView) SetEntries(entries []alertlog.Entry, now time.Time) {
+	v.entries = entries
+	v.now = now
+	v.scrollPos = min(v.scrollPos, max(0, len(entries)-1))
+}
+
+// Entries returns the alerts shown
+func (v *AlertHistoryView) Entries() []alertlog.Entry {
+	return v.entries
+}
+
+// SetNote shows the outcome of an export below the list
+func (v *AlertHistoryView) SetNote(note string) {
+	v.note = note
+}
+
+// ScrollUp scrolls the list up by one alert
+func (v *AlertHistoryView) ScrollUp() {
+	v.scrollPos = max(0, v.scrollPos-1)
+}
+
+// ScrollDown scrolls the list down by one alert
+func (v *AlertHistoryView) ScrollDown() {
+	v.scrollPos = max(0, min(v.scrollPos+1, len(v.entries)-v.pageSize()))
+}
+
+// pageSize returns the number of alerts shown at once
+func (v *AlertHistoryView) pageSize() int {
+	if v.height-8 < 5 {
+		return 5
+	}
+	return v.height - 8
+}
+
+// Render draws the alerts of the selected range, most recent first
+func (v *AlertHistoryView) Render() string {
+	title := fmt.Sprintf("Alert history: %s (%s alerts)", v.rangeLabel(), FormatNumber(len(v.entries)))
+	lines := []string{title}
+
+	if len(v.entries) == 0 {
+		lines = append(lines, "", "No alerts in this range")
+	} else {
+		lines = append(lines, TableHeaderStyle.Render(fmt.Sprintf("%-19s  %9s  %-8s  %-8s  %s", "FIRED", "DURATION", "STATUS", "LEVEL", "ALERT")))
+		end := min(v.scrollPos+v.pageSize(), len(v.entries))
+		for _, e := range v.entries[v.scrollPos:end] {
+			style := normalValueStyle
+			switch e.Level {
+			case system.WarningLevel:
+				style = warnValueStyle
+			case system.CriticalLevel:
+				style = criticalValueStyle
+			}
+			status := e.Status()
+			if e.Interrupted {
+				// The monitor stopped while the alert was firing
+				status = "stopped"
+			}
+			row := fmt.Sprintf("%-19s  %9s  %-8s  %-8s  %s: %s",
+				e.Fired.In(v.now.Location()).Format("2006-01-02 15:04:05"),
+				formatDuration(e.Duration(v.now)), status, strings.ToUpper(string(e.Level)), e.Source, e.Message)
+			lines = append(lines, style.Render(Truncate(row, max(v.width-4, 40))))
+		}
+	}
+
+	if v.note != "" {
+		lines = append(lines, "", v.note)
+	}
+	lines = append(lines, "", helpStyle.Render("[ ]: Range • /: Custom range • x: Export CSV • H: Current alerts"))
+	return infoSectionStyle.Width(v.width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+)
+
+// quietAlertStyle dims alerts that are pending, acknowledged or suppressed
+var quietAlertStyle = lipgloss.NewStyle().Foreground(PaletteMuted)
+
+// AlertListView lists the active and resolved alerts of the Alerts tab
+// with a selection, so that an alert can be acknowledged or its source
+// silenced
+type AlertListView struct {
+	width      int
+	height     int
+	cursor     int
+	scrollPos  int
+	selected   string         // source and time of the selected alert, kept across refreshes
+	alerts     []system.Alert // alerts shown by the last Render
+	silenceBar *FilterBar     // edits the duration of a silence
+	silencing  string         // source the silence bar is open for
+	note       string         // outcome of the last action
+}
+
+// NewAlertListView creates the alert list
+func NewAlertListView() *AlertListView {
+	return &AlertListView{silenceBar: NewFilterBar()}
+}
+
+// SetSize updates the view dimensions
+func (v *AlertListView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
+
+// ScrollUp selects the previous alert
+func (v *AlertListView) ScrollUp() {
+	v.moveCursor(v.cursor - 1)
+}
+
+// ScrollDown selects the next alert
+func (v *AlertListView) ScrollDown() {
+	v.moveCursor(v.cursor + 1)
+}
+
+// Selected returns the selected alert, if any
+func (v *AlertListView) Selected() (system.Alert, bool) {
+	if v.cursor < 0 || v.cursor >= len(v.alerts) {
+		return system.Alert{}, false
+	}
+	return v.alerts[v.cursor], true
+}
+
+// SilenceBar returns the bar used to enter the duration of a silence
+func (v *AlertListView) SilenceBar() *FilterBar {
+	return v.silenceBar
+}
+
+// OpenSilence opens the silence bar for an alert source, starting from a
+// duration of one hour
+func (v *AlertListView) OpenSilence(source string) {
+	v.silencing = source
+	v.silenceBar.SetHint(fmt.Sprintf("Silence %s for • Enter: Apply • Esc: Cancel • Ctrl+U: Clear • e.g. 30m, 2h, 1d", source))
+	v.silenceBar.Open("1h")
+}
+
+// SilenceSource returns the source the silence bar is open for
+func (v *AlertListView) SilenceSource() string {
+	return v.silencing
+}
+
+// SetNote shows the outcome of an action below the list
+func (v *AlertListView) SetNote(note string) {
+	v.note = note
+}
+
+// alertKey identifies an alert across refreshes
+func alertKey(a system.Alert) string {
+	if a.ID != "" {
+		return a.ID
+	}
+	// Alerts of recordings made before alerts had IDs
+	return a.Source + "@" + a.Timestamp.String()
+}
+
+// pageSize returns the number of alerts shown at once
+func (v *AlertListView) pageSize() int {
+	if v.height-8 < 5 {
+		return 5
+	}
+	return v.height - 8
+}
+
+// moveCursor selects row pos, clamped to the alerts shown
+func (v *AlertListView) moveCursor(pos int) {
+	pos = max(0, min(pos, len(v.alerts)-1))
+	v.cursor = pos
+	if pos < len(v.alerts) {
+		v.selected = alertKey(v.alerts[pos])
+	}
+}
+
+// alertState returns the lifecycle state of an alert, deriving it for
+// alerts recorded before alerts had states
+func alertState(a system.Alert) system.AlertState {
+	switch {
+	case a.State != "":
+		return a.State
+	case a.Resolved:
+		return system.AlertResolved
+	}
+	return system.AlertFiring
+}
+
+// Render draws the active alerts followed by the resolved ones, each most
+// recent first, with how long they have been pending or firing as of now,
+// and then the key hints in footer. Acknowledged and suppressed alerts are
+// dimmed and tagged.
+func (v *AlertListView) Render(alerts []system.Alert, now time.Time, footer string) string {
+	// Active alerts first; the cursor moves through both sections
+	var active, resolved []system.Alert
+	for _, alert := range alerts {
+		if alert.Resolved {
+			resolved = append(resolved, alert)
+		} else {
+			active = append(active, alert)
+		}
+	}
+	v.alerts = append(active, resolved...)
+	for i, alert := range v.alerts {
+		if alertKey(alert) == v.selected {
+			v.cursor = i
+			break
+		}
+	}
+	v.moveCursor(v.cursor)
+
+	rows := v.pageSize()
+	if v.cursor < v.scrollPos {
+		v.scrollPos = v.cursor
+	}
+	if v.cursor >= v.scrollPos+rows {
+		v.scrollPos = v.cursor - rows + 1
+	}
+	v.scrollPos = max(0, min(v.scrollPos, len(v.alerts)-rows))
+	end := min(v.scrollPos+rows, len(v.alerts))
+
+	var content []string
+	if v.scrollPos == 0 {
+		content = append(content, fmt.Sprintf("Active (%s)", FormatNumber(len(active))))
+		if len(active) == 0 {
+			content = append(content, " No active alerts")
+		}
+	}
+	for i := v.scrollPos; i < end; i++ {
+		if i == len(active) || (i == v.scrollPos && i > len(active)) {
+			if len(content) > 0 {
+				content = append(content, "")
+			}
+			content = append(content, fmt.Sprintf("Resolved (%s)", FormatNumber(len(resolved))))
+		}
+		content = append(content, v.renderAlert(v.alerts[i], i == v.cursor, now))
+	}
+
+	if v.note != "" {
+		content = append(content, "", v.note)
+	}
+	content = append(content, "", helpStyle.Render(footer))
+	return infoSectionStyle.Width(v.width).Render(lipgloss.JoinVertical(lipgloss.Left, content...))
+}
+
+// renderAlert draws one alert row
+func (v *AlertListView) renderAlert(alert system.Alert, selected bool, now time.Time) string {
+	style := normalValueStyle
+	if alert.Level == system.WarningLevel {
+		style = warnValueStyle
+	} else if alert.Level == system.CriticalLevel {
+		style = criticalValueStyle
+	}
+
+	duration := formatDuration(alert.Duration(now))
+	if alert.Resolved && alert.Duration(now) == 0 {
+		// Events such as a signal sent are resolved as they are recorded
+		duration = "-"
+	}
+	line := fmt.Sprintf("%-8s %8s  [%s] %s", alertState(alert), duration, strings.ToUpper(string(alert.Level)), alert.Message)
+	var tags []string
+	if alert.Acknowledged {
+		tags = append(tags, "acknowledged")
+	}
+	if alert.SuppressedBy != "" {
+		tags = append(tags, alert.SuppressedBy)
+	}
+	if len(tags) > 0 {
+		line += " (" + strings.Join(tags, ", ") + ")"
+	}
+	if alert.State == system.AlertPending || (len(tags) > 0 && !alert.Resolved) {
+		style = quietAlertStyle
+	}
+
+	marker := " "
+	if selected {
+		marker = HeaderStyle.Render("▶")
+		style = style.Reverse(true)
+	}
+	return marker + style.Render(Truncate(line, max(v.width-6, 40)))
+}
+
+// FormatSilence describes a silence ending at until, as seen at now
+func FormatSilence(source string, until, now time.Time) string {
+	return fmt.Sprintf("Silenced %s for %s", source, formatDuration(until.Sub(now)))
+}
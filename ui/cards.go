@@ -36,7 +36,7 @@ type CardMetrics struct {
 }
 
 // CompactSystemMetrics extracts key metrics for badges
-func CompactSystemMetrics(info *system.Collector) []CardMetrics {
+func CompactSystemMetrics(info *system.Snapshot) []CardMetrics {
 	metrics := make([]CardMetrics, 0, 6)
 
 	// CPU usage
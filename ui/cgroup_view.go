@@ -0,0 +1,280 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+)
+
+// CgroupView shows the cgroup v2 hierarchy as a tree with the usage of
+// every cgroup against its limits
+type CgroupView struct {
+	width     int
+	height    int
+	cursor    int
+	scrollPos int
+	selected  string   // path of the selected cgroup, kept across refreshes
+	paths     []string // cgroups shown by the last Render
+}
+
+// NewCgroupView creates the cgroup view
+func NewCgroupView() *CgroupView {
+	return &CgroupView{}
+}
+
+// SetSize updates the view dimensions
+func (v *CgroupView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
+
+// ScrollUp selects the previous cgroup
+func (v *CgroupView) ScrollUp() {
+	v.moveCursor(v.cursor - 1)
+}
+
+// ScrollDown selects the next cgroup
+func (v *CgroupView) ScrollDown() {
+	v.moveCursor(v.cursor + 1)
+}
+
+// PageUp moves the selection up by a page
+func (v *CgroupView) PageUp() {
+	v.moveCursor(v.cursor - v.pageSize())
+}
+
+// PageDown moves the selection down by a page
+func (v *CgroupView) PageDown() {
+	v.moveCursor(v.cursor + v.pageSize())
+}
+
+// Selected returns the path of the selected cgroup, if any
+func (v *CgroupView) Selected() (string, bool) {
+	if v.cursor < 0 || v.cursor >= len(v.paths) {
+		return "", false
+	}
+	return v.paths[v.cursor], true
+}
+
+// pageSize returns the number of rows shown at once
+func (v *CgroupView) pageSize() int {
+	if v.height-6 < 5 {
+		return 5
+	}
+	return v.height - 6
+}
+
+// moveCursor selects row pos, clamped to the rows shown
+func (v *CgroupView) moveCursor(pos int) {
+	if pos >= len(v.paths) {
+		pos = len(v.paths) - 1
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	v.cursor = pos
+	if pos < len(v.paths) {
+		v.selected = v.paths[pos]
+	}
+}
+
+// cgroupColumn is a column of the cgroup table
+type cgroupColumn struct {
+	title  string
+	width  int
+	format func(g *system.CgroupStats, procs int) string
+}
+
+// cgroupColumns follow the name column. Limits that are not set show "-".
+var cgroupColumns = []cgroupColumn{
+	{"PROCS", 6, func(g *system.CgroupStats, procs int) string {
+		return BaseStyle.Render(fmt.Sprintf("%5d", procs))
+	}},
+	{"CPU%", 7, func(g *system.CgroupStats, procs int) string {
+		style := BaseStyle
+		if g.CPUQuota > 0 {
+			// Relative to the quota, which is the most the cgroup can use
+			style = StyleValue(g.CPUPercent / g.CPUQuota)
+		}
+		return style.Render(fmt.Sprintf("%6.1f", g.CPUPercent))
+	}},
+	{"QUOTA", 6, func(g *system.CgroupStats, procs int) string {
+		if g.CPUQuota == 0 {
+			return BaseStyle.Render(fmt.Sprintf("%5s", "-"))
+		}
+		return BaseStyle.Render(fmt.Sprintf("%5.1f", g.CPUQuota))
+	}},
+	{"THR%", 6, func(g *system.CgroupStats, procs int) string {
+		// Share of the enforcement periods of the interval that were throttled
+		style := BaseStyle
+		if g.ThrottledPercent > 0 {
+			style = WarningStyle
+		}
+		return style.Render(fmt.Sprintf("%5.1f", g.ThrottledPercent))
+	}},
+	{"MEMORY", 11, func(g *system.CgroupStats, procs int) string {
+		return BaseStyle.Render(fmt.Sprintf("%10s", FormatBytes(g.MemoryCurrent)))
+	}},
+	{"LIMIT", 11, func(g *system.CgroupStats, procs int) string {
+		if g.MemoryMax == 0 {
+			return BaseStyle.Render(fmt.Sprintf("%10s", "-"))
+		}
+		return BaseStyle.Render(fmt.Sprintf("%10s", FormatBytes(g.MemoryMax)))
+	}},
+	{"MEM%", 6, func(g *system.CgroupStats, procs int) string {
+		if g.MemoryMax == 0 {
+			return BaseStyle.Render(fmt.Sprintf("%5s", "-"))
+		}
+		return StyleValue(g.MemoryPercent()).Render(fmt.Sprintf("%5.1f", g.MemoryPercent()))
+	}},
+	{"OOM", 5, func(g *system.CgroupStats, procs int) string {
+		// Processes killed by the OOM killer since the cgroup was created
+		style := BaseStyle
+		if g.OOMKills > 0 {
+			style = CriticalStyle
+		}
+		return style.Render(fmt.Sprintf("%4d", g.OOMKills))
+	}},
+	{"PIDS", 11, func(g *system.CgroupStats, procs int) string {
+		if g.PidsMax == 0 {
+			return BaseStyle.Render(fmt.Sprintf("%10d", g.PidsCurrent))
+		}
+		style := StyleValue(100 * float64(g.PidsCurrent) / float64(g.PidsMax))
+		return style.Render(fmt.Sprintf("%10s", fmt.Sprintf("%d/%d", g.PidsCurrent, g.PidsMax)))
+	}},
+	{"READ/s", 11, func(g *system.CgroupStats, procs int) string {
+		return BaseStyle.Render(fmt.Sprintf("%10s", FormatBytes(uint64(g.IOReadRate))))
+	}},
+	{"WRITE/s", 11, func(g *system.CgroupStats, procs int) string {
+		return BaseStyle.Render(fmt.Sprintf("%10s", FormatBytes(uint64(g.IOWriteRate))))
+	}},
+}
+
+// Render draws the cgroup tree. Processes are counted in the cgroup they
+// belong to directly.
+func (v *CgroupView) Render(info system.CgroupInfo, processes []system.ProcessDetail) string {
+	if !info.Available {
+		v.paths = nil
+		return CardStyle.Render("No cgroup v2 hierarchy found below /sys/fs/cgroup")
+	}
+
+	procs := make(map[string]int)
+	for _, p := range processes {
+		procs[p.Cgroup]++
+	}
+
+	v.paths = v.paths[:0]
+	for _, g := range info.Groups {
+		v.paths = append(v.paths, g.Path)
+	}
+	for i, path := range v.paths {
+		if path == v.selected {
+			v.cursor = i
+			break
+		}
+	}
+	v.moveCursor(v.cursor)
+
+	// The name column takes the width left by the others
+	fixedWidth := 0
+	for _, col := range cgroupColumns {
+		fixedWidth += col.width
+	}
+	nameWidth := v.width - fixedWidth - 5 // -4 for margins, -1 for the selection marker
+	if nameWidth < 20 {
+		nameWidth = 20
+	}
+
+	headers := []string{TableHeaderStyle.Width(nameWidth).Render("CGROUP")}
+	for _, col := range cgroupColumns {
+		headers = append(headers, TableHeaderStyle.Width(col.width).Align(lipgloss.Right).Render(col.title))
+	}
+	lines := []string{" " + lipgloss.JoinHorizontal(lipgloss.Top, headers...)}
+
+	// Scroll so that the selected row is visible
+	rows := v.pageSize()
+	if v.cursor < v.scrollPos {
+		v.scrollPos = v.cursor
+	}
+	if v.cursor >= v.scrollPos+rows {
+		v.scrollPos = v.cursor - rows + 1
+	}
+	end := min(v.scrollPos+rows, len(info.Groups))
+
+	prefixes := cgroupPrefixes(info.Groups)
+	for i := v.scrollPos; i < end; i++ {
+		g := &info.Groups[i]
+		rowStyle := TableRowStyle
+		marker := " "
+		if i == v.cursor {
+			rowStyle = rowStyle.Reverse(true)
+			marker = HeaderStyle.Render("▶")
+		}
+		cells := []string{rowStyle.Width(nameWidth).MaxHeight(1).Render(Truncate(prefixes[i]+g.Name(), nameWidth))}
+		for _, col := range cgroupColumns {
+			cells = append(cells, rowStyle.Width(col.width).MaxHeight(1).Align(lipgloss.Right).Render(col.format(g, procs[g.Path])))
+		}
+		lines = append(lines, marker+lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	// Pressure of the selected cgroup, from its *.pressure files
+	if v.cursor < len(info.Groups) {
+		g := &info.Groups[v.cursor]
+		lines = append(lines, "", fmt.Sprintf("Pressure of %s (some/full avg10): CPU %.1f/%.1f%%  Memory %.1f/%.1f%%  I/O %.1f/%.1f%%",
+			g.Name(), g.CPUPressure.Some.Avg10, g.CPUPressure.Full.Avg10,
+			g.MemoryPressure.Some.Avg10, g.MemoryPressure.Full.Avg10,
+			g.IOPressure.Some.Avg10, g.IOPressure.Full.Avg10))
+	}
+
+	title := fmt.Sprintf("Cgroups (%s) - Showing %s-%s",
+		FormatNumber(len(info.Groups)), FormatNumber(v.scrollPos+1), FormatNumber(end))
+	return CardStyle.Render(lipgloss.JoinVertical(lipgloss.Left, append([]string{title}, lines...)...))
+}
+
+// cgroupPrefixes returns the tree branches drawn before the name of every
+// cgroup of a depth-first list
+func cgroupPrefixes(groups []system.CgroupStats) []string {
+	prefixes := make([]string, len(groups))
+	var open []bool // whether the ancestor at each depth has later siblings
+	for i, g := range groups {
+		if g.Depth == 0 {
+			open = open[:0]
+			continue
+		}
+		// The cgroup is the last of its siblings when no later cgroup at
+		// the same depth follows before the list returns to its parent
+		last := true
+		for _, next := range groups[i+1:] {
+			if next.Depth < g.Depth {
+				break
+			}
+			if next.Depth == g.Depth {
+				last = false
+				break
+			}
+		}
+		if len(open) < g.Depth {
+			open = append(open, make([]bool, g.Depth-len(open))...)
+		}
+		open = open[:g.Depth]
+		open[g.Depth-1] = !last
+
+		var b strings.Builder
+		for d := 1; d < g.Depth; d++ {
+			if open[d-1] {
+				b.WriteString("│ ")
+			} else {
+				b.WriteString("  ")
+			}
+		}
+		if last {
+			b.WriteString("└─ ")
+		} else {
+			b.WriteString("├─ ")
+		}
+		prefixes[i] = b.String()
+	}
+	return prefixes
+}
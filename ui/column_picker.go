@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+)
+
+// pickerColumn is a column listed in the column picker
+type pickerColumn struct {
+	column
+	visible bool
+}
+
+// ColumnPicker chooses, orders and sizes the process table columns. Shown
+// columns are listed first in table order, followed by the hidden ones.
+type ColumnPicker struct {
+	active  bool
+	columns []pickerColumn
+	cursor  int
+}
+
+// NewColumnPicker creates a closed column picker
+func NewColumnPicker() *ColumnPicker {
+	return &ColumnPicker{}
+}
+
+// Open shows the picker for the columns currently shown
+func (c *ColumnPicker) Open(shown []ColumnSpec) {
+	cols, err := resolveColumns(shown)
+	if err != nil {
+		cols, _ = resolveColumns(nil)
+	}
+	c.columns = c.columns[:0]
+	visible := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		c.columns = append(c.columns, pickerColumn{column: col, visible: true})
+		visible[col.id] = true
+	}
+	for _, col := range columnRegistry {
+		if !visible[col.id] {
+			c.columns = append(c.columns, pickerColumn{column: col})
+		}
+	}
+	c.cursor = 0
+	c.active = true
+}
+
+// Close hides the picker
+func (c *ColumnPicker) Close() {
+	c.active = false
+}
+
+// Active reports whether the picker is shown
+func (c *ColumnPicker) Active() bool {
+	return c.active
+}
+
+// Up moves the cursor to the previous column
+func (c *ColumnPicker) Up() {
+	if c.cursor > 0 {
+		c.cursor--
+	}
+}
+
+// Down moves the cursor to the next column
+func (c *ColumnPicker) Down() {
+	if c.cursor < len(c.columns)-1 {
+		c.cursor++
+	}
+}
+
+// Toggle shows or hides the column under the cursor. The last shown column
+// cannot be hidden.
+func (c *ColumnPicker) Toggle() {
+	if c.cursor >= len(c.columns) {
+		return
+	}
+	col := &c.columns[c.cursor]
+	if col.visible && len(c.Columns()) == 1 {
+		return
+	}
+	col.visible = !col.visible
+}
+
+// MoveUp moves the column under the cursor one place towards the left of
+// the table
+func (c *ColumnPicker) MoveUp() {
+	if c.cursor > 0 && c.cursor < len(c.columns) {
+		c.columns[c.cursor-1], c.columns[c.cursor] = c.columns[c.cursor], c.columns[c.cursor-1]
+		c.cursor--
+	}
+}
+
+// MoveDown moves the column under the cursor one place towards the right
+// of the table
+func (c *ColumnPicker) MoveDown() {
+	if c.cursor < len(c.columns)-1 {
+		c.columns[c.cursor+1], c.columns[c.cursor] = c.columns[c.cursor], c.columns[c.cursor+1]
+		c.cursor++
+	}
+}
+
+// Resize changes the width of the column under the cursor by delta
+func (c *ColumnPicker) Resize(delta int) {
+	if c.cursor >= len(c.columns) {
+		return
+	}
+	col := &c.columns[c.cursor]
+	col.width = min(max(col.width+delta, minColumnWidth), maxColumnWidth)
+}
+
+// SortType returns the sort type of the column under the cursor, if it can
+// be sorted
+func (c *ColumnPicker) SortType() (system.SortType, bool) {
+	if c.cursor >= len(c.columns) || c.columns[c.cursor].sortBy == "" {
+		return "", false
+	}
+	return c.columns[c.cursor].sortBy, true
+}
+
+// Columns returns the shown columns in table order
+func (c *ColumnPicker) Columns() []ColumnSpec {
+	var specs []ColumnSpec
+	for _, col := range c.columns {
+		if col.visible {
+			specs = append(specs, ColumnSpec{ID: col.id, Width: col.width})
+		}
+	}
+	return specs
+}
+
+// Render draws the picker; the column the table is sorted by is marked
+func (c *ColumnPicker) Render(sortBy system.SortType) string {
+	if !c.active {
+		return ""
+	}
+	lines := []string{HeaderStyle.Render("Process Columns")}
+	for i, col := range c.columns {
+		check := "[ ]"
+		if col.visible {
+			check = "[x]"
+		}
+		sorted := ""
+		if col.sortBy != "" && col.sortBy == sortBy {
+			sorted = " (sorted)"
+		}
+		line := fmt.Sprintf("%s %-8s %-9s %3d%s", check, col.id, col.title, col.width, sorted)
+		if i == c.cursor {
+			line = HeaderStyle.Render("▶ " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, helpStyle.Render("↑↓: Select • Space: Show/hide • K/J: Move • +/-: Width • s: Sort • Enter/Esc: Close"))
+	return CardStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -2,10 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/shirou/gopsutil/v3/disk"
 	"go_system_monitor/system"
 )
 
@@ -68,21 +71,38 @@ type Dashboard struct {
 	help          help.Model
 	statusBar     *StatusBar
 	processTable  *ProcessTable
+	signalDialog  *SignalDialog
+	tuneDialog    *TuneDialog
+	processDetail *ProcessDetailView
+	filterBar     *FilterBar
+	columnPicker  *ColumnPicker
+	cgroupView    *CgroupView
+	alertHistory  *AlertHistoryView
+	alertList     *AlertListView
 	compactMode   bool
 	showHelp      bool
 	fullscreen    bool
 	showStatusBar bool
 	cardConfig    CardConfig
+	replayStatus  string // playback state shown while replaying a recording
 }
 
 // NewDashboard creates a new dashboard
 func NewDashboard() Dashboard {
 	return Dashboard{
-		tabs:          []string{"Overview", "CPU", "Memory", "Disk", "Network", "Processes", "Alerts"},
+		tabs:          []string{"Overview", "CPU", "Memory", "Disk", "Network", "Processes", "Alerts", "Cgroups"},
 		activeTab:     0,
 		help:          help.New(),
 		statusBar:     NewStatusBar(),
 		processTable:  NewProcessTable(),
+		signalDialog:  NewSignalDialog(),
+		tuneDialog:    NewTuneDialog(),
+		processDetail: NewProcessDetailView(),
+		filterBar:     NewFilterBar(),
+		columnPicker:  NewColumnPicker(),
+		cgroupView:    NewCgroupView(),
+		alertHistory:  NewAlertHistoryView(),
+		alertList:     NewAlertListView(),
 		showStatusBar: true,
 		compactMode:   false,
 		showHelp:      false,
@@ -91,6 +111,17 @@ func NewDashboard() Dashboard {
 	}
 }
 
+// SetClock replaces the clock used by time-dependent parts of the dashboard
+func (d *Dashboard) SetClock(now func() time.Time) {
+	d.statusBar.SetClock(now)
+}
+
+// SetReplayStatus sets the playback line shown while replaying a recording.
+// An empty status hides it.
+func (d *Dashboard) SetReplayStatus(status string) {
+	d.replayStatus = status
+}
+
 // NextTab switches to the next tab
 func (d *Dashboard) NextTab() {
 	d.activeTab = (d.activeTab + 1) % len(d.tabs)
@@ -112,6 +143,10 @@ func (d *Dashboard) SetSize(width, height int) {
 	d.height = height
 	d.statusBar.SetSize(width, 1)
 	d.processTable.SetSize(width-4, height-8) // account for margins and other elements
+	d.processDetail.SetSize(width-4, height-8)
+	d.cgroupView.SetSize(width-4, height-8)
+	d.alertHistory.SetSize(width-4, height-8)
+	d.alertList.SetSize(width-4, height-8)
 }
 
 // FormatTabs renders the tab navigation
@@ -221,7 +256,7 @@ func FormatNumber(n int) string {
 }
 
 // RenderMainContent returns the content for the currently active tab
-func (d *Dashboard) RenderMainContent(metrics *system.Collector) string {
+func (d *Dashboard) RenderMainContent(metrics *system.Snapshot) string {
 	switch d.activeTab {
 	case 0: // Overview
 		return d.renderOverview(metrics)
@@ -234,9 +269,16 @@ func (d *Dashboard) RenderMainContent(metrics *system.Collector) string {
 	case 4: // Network
 		return d.renderNetwork(metrics)
 	case 5: // Processes
+		if d.processDetail.Active() {
+			return d.processDetail.Render()
+		}
+		d.processTable.SetSortBy(metrics.Process.SortBy)
+		d.processTable.SetHistory(metrics.Process.History)
 		return d.processTable.Render(metrics.Process.Processes)
 	case 6: // Alerts
 		return d.renderAlerts(metrics)
+	case 7: // Cgroups
+		return d.cgroupView.Render(metrics.Cgroups, metrics.Process.Processes)
 	default:
 		return "Unknown tab"
 	}
@@ -304,13 +346,125 @@ func (d *Dashboard) EndProcess(maxRows int) {
 	d.processTable.End(maxRows)
 }
 
-// SetProcessFilter sets the filter text for the process table
-func (d *Dashboard) SetProcessFilter(text string) {
-	d.processTable.SetFilterText(text)
+// ToggleProcessTree switches the process table between the flat list and
+// the process tree
+func (d *Dashboard) ToggleProcessTree() {
+	d.processTable.ToggleTreeMode()
+}
+
+// ToggleProcessCollapsed collapses or expands the subtree of the selected
+// process
+func (d *Dashboard) ToggleProcessCollapsed() {
+	d.processTable.ToggleCollapsed()
+}
+
+// ExpandAllProcesses expands every subtree of the process tree
+func (d *Dashboard) ExpandAllProcesses() {
+	d.processTable.ExpandAll()
+}
+
+// CycleProcessGrouping switches the process table to the next grouping
+// mode: none, user, name, cgroup
+func (d *Dashboard) CycleProcessGrouping() {
+	d.processTable.CycleGrouping()
+}
+
+// ProcessGrouping returns the grouping mode of the process table
+func (d *Dashboard) ProcessGrouping() system.GroupBy {
+	return d.processTable.Grouping()
+}
+
+// ProcessDetail returns the detail view of the selected process
+func (d *Dashboard) ProcessDetail() *ProcessDetailView {
+	return d.processDetail
+}
+
+// SelectedProcess returns the process selected in the process table
+func (d *Dashboard) SelectedProcess() (system.ProcessDetail, bool) {
+	return d.processTable.Selected()
+}
+
+// SignalDialog returns the dialog used to send signals to processes
+func (d *Dashboard) SignalDialog() *SignalDialog {
+	return d.signalDialog
+}
+
+// TuneDialog returns the dialog used to renice processes and change their
+// CPU affinity
+func (d *Dashboard) TuneDialog() *TuneDialog {
+	return d.tuneDialog
+}
+
+// SetProcessFilter sets the filter query of the process table. A query
+// that does not parse is rejected and the current filter is kept.
+func (d *Dashboard) SetProcessFilter(text string) error {
+	err := d.processTable.SetFilterText(text)
+	d.statusBar.SetFilter(d.processTable.FilterText())
+	return err
+}
+
+// ProcessFilter returns the filter query of the process table
+func (d *Dashboard) ProcessFilter() string {
+	return d.processTable.FilterText()
+}
+
+// FilterBar returns the bar used to edit the process filter
+func (d *Dashboard) FilterBar() *FilterBar {
+	return d.filterBar
+}
+
+// SetProcessColumns selects the columns of the process table; see
+// ProcessTable.SetColumns
+func (d *Dashboard) SetProcessColumns(specs []ColumnSpec) error {
+	return d.processTable.SetColumns(specs)
+}
+
+// ProcessColumns returns the columns shown in the process table
+func (d *Dashboard) ProcessColumns() []ColumnSpec {
+	return d.processTable.Columns()
+}
+
+// ProcessSortColumn returns the sort type of the visible column dir
+// columns away from the sorted one
+func (d *Dashboard) ProcessSortColumn(dir int) system.SortType {
+	return d.processTable.SortColumn(dir)
+}
+
+// CgroupView returns the view of the cgroup hierarchy
+func (d *Dashboard) CgroupView() *CgroupView {
+	return d.cgroupView
+}
+
+// ShowCgroupProcesses switches to the Processes tab filtered to the
+// processes of the selected cgroup
+func (d *Dashboard) ShowCgroupProcesses() {
+	path, ok := d.cgroupView.Selected()
+	if !ok {
+		return
+	}
+	if err := d.SetProcessFilter(fmt.Sprintf("cgroup=%q", path)); err != nil {
+		return
+	}
+	d.activeTab = 5
+}
+
+// AlertHistory returns the alert history view of the Alerts tab
+func (d *Dashboard) AlertHistory() *AlertHistoryView {
+	return d.alertHistory
+}
+
+// AlertList returns the list of current alerts of the Alerts tab
+func (d *Dashboard) AlertList() *AlertListView {
+	return d.alertList
+}
+
+// ColumnPicker returns the picker used to choose the process table columns
+func (d *Dashboard) ColumnPicker() *ColumnPicker {
+	return d.columnPicker
 }
 
 // Render returns the complete dashboard view
-func (d *Dashboard) Render(metrics *system.Collector) string {
+func (d *Dashboard) Render(metrics *system.Snapshot) string {
 	if d.fullscreen {
 		// In fullscreen mode, only show the current view content
 		return d.RenderMainContent(metrics)
@@ -324,6 +478,11 @@ func (d *Dashboard) Render(metrics *system.Collector) string {
 		elements = append(elements, d.statusBar.Render())
 	}
 
+	// Playback state when replaying a recording
+	if d.replayStatus != "" {
+		elements = append(elements, WarningStyle.Render(d.replayStatus))
+	}
+
 	// Tabs (unless in compact mode)
 	if !d.compactMode {
 		elements = append(elements, d.FormatTabs())
@@ -340,6 +499,36 @@ func (d *Dashboard) Render(metrics *system.Collector) string {
 	}
 	elements = append(elements, content)
 
+	// Process filter query
+	if d.filterBar.Active() && d.activeTab == 5 {
+		elements = append(elements, d.filterBar.Render())
+	}
+
+	// Custom alert history range
+	if d.alertHistory.RangeBar().Active() && d.activeTab == 6 {
+		elements = append(elements, d.alertHistory.RangeBar().Render())
+	}
+
+	// Duration of an alert silence
+	if d.alertList.SilenceBar().Active() && d.activeTab == 6 {
+		elements = append(elements, d.alertList.SilenceBar().Render())
+	}
+
+	// Process table column picker
+	if d.columnPicker.Active() && d.activeTab == 5 {
+		elements = append(elements, d.columnPicker.Render(metrics.Process.SortBy))
+	}
+
+	// Signal picker and confirmation
+	if d.signalDialog.Active() {
+		elements = append(elements, d.signalDialog.Render())
+	}
+
+	// Renice and affinity editor
+	if d.tuneDialog.Active() {
+		elements = append(elements, d.tuneDialog.Render())
+	}
+
 	// Help text or help overlay
 	if d.showHelp {
 		helpText := []string{
@@ -352,19 +541,59 @@ func (d *Dashboard) Render(metrics *system.Collector) string {
 			"  s: Toggle status bar",
 			"  ?: Toggle this help",
 		}
+		if d.replayStatus != "" {
+			helpText = append(helpText, "", "Replay:",
+				"  Space: Pause/resume",
+				"  [ ]: Seek 10s back/forward",
+				"  { }: Seek 1m back/forward",
+				"  + -: Faster/slower",
+			)
+		}
+		if d.activeTab == 7 { // Cgroups tab
+			helpText = append(helpText, "", "Cgroups:",
+				"  ↑/k ↓/j: Select cgroup",
+				"  PgUp/PgDn: Page up/down",
+				"  Enter: Show the processes of the selected cgroup",
+			)
+		}
+		if d.activeTab == 6 { // Alerts tab
+			helpText = append(helpText, "", "Alerts:",
+				"  ↑/k ↓/j: Select alert",
+				"  a: Acknowledge selected alert",
+				"  m: Silence the source of selected alert for a while",
+				"  M: Lift the silence of selected alert's source",
+				"  H: Switch between current alerts and alert history",
+			)
+		}
 		if d.activeTab == 5 { // Processes tab
 			helpText = append(helpText, "", "Process Navigation:",
-				"  ↑/k: Scroll up",
-				"  ↓/j: Scroll down",
+				"  ↑/k: Select previous process",
+				"  ↓/j: Select next process",
 				"  PgUp/Ctrl+u: Page up",
 				"  PgDn/Ctrl+d: Page down",
 				"  Home/g: Jump to top",
 				"  End/G: Jump to bottom",
+				"  Enter: Show details of selected process",
+				"  x: Send signal to selected process",
+				"  n: Renice selected process",
+				"  a: Set CPU affinity of selected process",
+				"  /: Filter processes (Esc clears the filter)",
+				"  o: Choose, order and resize columns",
+				"", "Process Tree:",
+				"  t: Toggle tree view",
+				"  e: Collapse/expand selected subtree",
+				"  E: Expand all subtrees",
+				"", "Process Groups:",
+				"  b: Group by user, name, cgroup or not at all",
+				"  Enter/e: Show/hide the members of the selected group",
+				"  E: Collapse all groups",
 				"", "Process Sorting:",
-				"  1: Sort by CPU",
+				"  1: Sort by CPU (last interval)",
 				"  2: Sort by Memory",
 				"  3: Sort by PID",
 				"  4: Sort by Name",
+				"  5: Sort by lifetime CPU",
+				"  < >: Sort by the previous/next column",
 			)
 		}
 		elements = append(elements, CardStyle.Render(
@@ -373,10 +602,15 @@ func (d *Dashboard) Render(metrics *system.Collector) string {
 	} else {
 		var basicHelp string
 		if d.activeTab == 5 {
-			basicHelp = "Tab/←→: Navigate • ↑↓/jk: Scroll • PgUp/PgDn: Page • Home/End: Jump • 1-4: Sort • q: Quit • r: Refresh • ?: Help"
+			basicHelp = "Tab/←→: Navigate • ↑↓/jk: Select • PgUp/PgDn: Page • Home/End: Jump • 1-5 <>: Sort • /: Filter • o: Columns • t: Tree • b: Group • Enter: Details • x: Signal • n: Nice • a: Affinity • q: Quit • r: Refresh • ?: Help"
+		} else if d.activeTab == 7 {
+			basicHelp = "Tab/←→: Navigate • ↑↓/jk: Select • Enter: Processes • q: Quit • r: Refresh • ?: Help"
 		} else {
 			basicHelp = "Tab/←→: Navigate • q: Quit • r: Refresh • ?: Help"
 		}
+		if d.replayStatus != "" {
+			basicHelp = "Space: Pause • []{}: Seek • +-: Speed • " + basicHelp
+		}
 		elements = append(elements, helpStyle.Render(basicHelp))
 	}
 
@@ -385,18 +619,15 @@ func (d *Dashboard) Render(metrics *system.Collector) string {
 
 // Helper methods for rendering different views
 
-func (d *Dashboard) renderOverview(metrics *system.Collector) string {
+func (d *Dashboard) renderOverview(metrics *system.Snapshot) string {
 	// Use the Collector's current fields
 	cpuUsage := RenderProgress("CPU Usage", metrics.CPU.Usage, d.width-4)
 	memUsage := RenderProgress("Memory Usage", metrics.Memory.UsedPercent, d.width-4)
 
-	// Pick a representative disk usage (first partition) if available
+	// Pick a representative disk usage if available
 	var diskUsagePercent float64
-	if len(metrics.Disk.UsageStats) > 0 {
-		for _, u := range metrics.Disk.UsageStats {
-			diskUsagePercent = u.UsedPercent
-			break
-		}
+	if u := primaryDiskUsage(metrics.Disk.UsageStats); u != nil {
+		diskUsagePercent = u.UsedPercent
 	}
 	diskUsage := RenderProgress("Disk Usage", diskUsagePercent, d.width-4)
 
@@ -409,56 +640,56 @@ func (d *Dashboard) renderOverview(metrics *system.Collector) string {
 	return infoSectionStyle.Width(d.width - 4).Render(content)
 }
 
-func (d *Dashboard) renderCPU(metrics *system.Collector) string {
+func (d *Dashboard) renderCPU(metrics *system.Snapshot) string {
 	var content []string
 	content = append(content, RenderProgress("Total CPU", metrics.CPU.Usage, d.width-4))
 	for i, usage := range metrics.CPU.UsagePerCPU {
 		content = append(content, RenderProgress(fmt.Sprintf("CPU %d", i), usage, d.width-4))
 	}
+	content = append(content, d.renderPressure(metrics.Pressure, metrics.Pressure.CPU, metrics.Pressure.CPUHistory)...)
 
 	return infoSectionStyle.Width(d.width - 4).Render(
 		lipgloss.JoinVertical(lipgloss.Left, content...),
 	)
 }
 
-func (d *Dashboard) renderMemory(metrics *system.Collector) string {
+func (d *Dashboard) renderMemory(metrics *system.Snapshot) string {
 	var content []string
 	content = append(content, RenderProgress("Memory Usage", metrics.Memory.UsedPercent, d.width-4))
 	content = append(content, fmt.Sprintf("Total: %s", FormatBytes(metrics.Memory.Total)))
 	content = append(content, fmt.Sprintf("Used: %s", FormatBytes(metrics.Memory.Used)))
 	content = append(content, fmt.Sprintf("Free: %s", FormatBytes(metrics.Memory.Free)))
+	content = append(content, d.renderPressure(metrics.Pressure, metrics.Pressure.Memory, metrics.Pressure.MemoryHistory)...)
 
 	return infoSectionStyle.Width(d.width - 4).Render(
 		lipgloss.JoinVertical(lipgloss.Left, content...),
 	)
 }
 
-func (d *Dashboard) renderDisk(metrics *system.Collector) string {
+func (d *Dashboard) renderDisk(metrics *system.Snapshot) string {
 	var content []string
-	// Show the first partition's usage as a representative sample
+	// Show the primary partition's usage as a representative sample
 	var total, used, free uint64
 	var usedPercent float64
-	if len(metrics.Disk.UsageStats) > 0 {
-		for _, u := range metrics.Disk.UsageStats {
-			total = u.Total
-			used = u.Used
-			free = u.Free
-			usedPercent = u.UsedPercent
-			break
-		}
+	if u := primaryDiskUsage(metrics.Disk.UsageStats); u != nil {
+		total = u.Total
+		used = u.Used
+		free = u.Free
+		usedPercent = u.UsedPercent
 	}
 
 	content = append(content, RenderProgress("Disk Usage", usedPercent, d.width-4))
 	content = append(content, fmt.Sprintf("Total: %s", FormatBytes(total)))
 	content = append(content, fmt.Sprintf("Used: %s", FormatBytes(used)))
 	content = append(content, fmt.Sprintf("Free: %s", FormatBytes(free)))
+	content = append(content, d.renderPressure(metrics.Pressure, metrics.Pressure.IO, metrics.Pressure.IOHistory)...)
 
 	return infoSectionStyle.Width(d.width - 4).Render(
 		lipgloss.JoinVertical(lipgloss.Left, content...),
 	)
 }
 
-func (d *Dashboard) renderNetwork(metrics *system.Collector) string {
+func (d *Dashboard) renderNetwork(metrics *system.Snapshot) string {
 	var content []string
 	
 	// Show per-interface rates if available
@@ -467,10 +698,11 @@ func (d *Dashboard) renderNetwork(metrics *system.Collector) string {
 		
 		// Aggregate total rates
 		var totalRecv, totalSent float64
-		for iface, recvRate := range metrics.Network.RecvRate {
+		for _, iface := range sortedKeys(metrics.Network.RecvRate) {
 			if iface == "lo" {
 				continue // Skip loopback
 			}
+			recvRate := metrics.Network.RecvRate[iface]
 			sentRate := metrics.Network.SentRate[iface]
 			totalRecv += recvRate
 			totalSent += sentRate
@@ -507,27 +739,75 @@ func (d *Dashboard) renderNetwork(metrics *system.Collector) string {
 	)
 }
 
-func (d *Dashboard) renderAlerts(metrics *system.Collector) string {
-	if metrics.AlertManager == nil || len(metrics.AlertManager.Alerts) == 0 {
-		return infoSectionStyle.Width(d.width - 4).Render("No active alerts")
+func (d *Dashboard) renderAlerts(metrics *system.Snapshot) string {
+	if d.alertHistory.Active() {
+		return d.alertHistory.Render()
 	}
-
-	var content []string
-	for _, alert := range metrics.AlertManager.Alerts {
-		style := normalValueStyle
-		if alert.Level == system.WarningLevel {
-			style = warnValueStyle
-		} else if alert.Level == system.CriticalLevel {
-			style = criticalValueStyle
+	if len(metrics.Alerts) == 0 {
+		empty := "No active alerts"
+		if d.alertHistory.Available() {
+			empty += "\n\n" + helpStyle.Render("H: Alert history")
 		}
+		return infoSectionStyle.Width(d.width - 4).Render(empty)
+	}
 
-		content = append(content, style.Render(fmt.Sprintf("[%s] %s",
-			strings.ToUpper(string(alert.Level)),
-			alert.Message)),
-		)
+	footer := "↑↓: Select • a: Acknowledge • m: Silence source • M: Unsilence"
+	if d.alertHistory.Available() {
+		footer += " • H: Alert history"
 	}
+	return d.alertList.Render(metrics.Alerts, metrics.System.LastUpdated, footer)
+}
 
-	return infoSectionStyle.Width(d.width - 4).Render(
-		lipgloss.JoinVertical(lipgloss.Left, content...),
-	)
+// renderPressure shows the share of time tasks stalled on a resource,
+// with the history of the 10 second average of some tasks stalling
+func (d *Dashboard) renderPressure(info system.PressureInfo, p system.Pressure, history system.TimeSeries) []string {
+	if !info.Available {
+		return []string{"", "Pressure: not available (needs Linux 4.20+ with PSI)"}
+	}
+	row := func(label string, s system.PressureStats) string {
+		return fmt.Sprintf("  %-6s %7.2f%% %7.2f%% %7.2f%%", label, s.Avg10, s.Avg60, s.Avg300)
+	}
+
+	// Pressure is usually low; scale to at least 10% so noise stays flat
+	peak := 10.0
+	for _, point := range history.Points {
+		peak = max(peak, point.Value)
+	}
+	sparkWidth := max(d.width-40, 10)
+
+	return []string{
+		"",
+		fmt.Sprintf("Pressure %8s %8s %8s", "avg10", "avg60", "avg300"),
+		row("some", p.Some),
+		row("full", p.Full),
+		fmt.Sprintf("  %-6s ", "avg10") + RenderSparklineRange(history, sparkWidth, 0, peak, normalValueStyle) +
+			fmt.Sprintf(" 0-%.0f%%", peak),
+	}
+}
+
+// primaryDiskUsage returns the usage of the root filesystem, or of the first
+// mountpoint in lexical order when there is no root entry
+func primaryDiskUsage(usage map[string]*disk.UsageStat) *disk.UsageStat {
+	if u, ok := usage["/"]; ok {
+		return u
+	}
+	mounts := make([]string, 0, len(usage))
+	for mount := range usage {
+		mounts = append(mounts, mount)
+	}
+	if len(mounts) == 0 {
+		return nil
+	}
+	sort.Strings(mounts)
+	return usage[mounts[0]]
+}
+
+// sortedKeys returns the keys of a map in lexical order so rendering is stable
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"go_system_monitor/alertlog"
+	"go_system_monitor/internal/testutil"
+	"go_system_monitor/system"
+)
+
+func TestMain(m *testing.M) {
+	// Render without colors so golden files do not depend on the terminal
+	lipgloss.SetColorProfile(termenv.Ascii)
+	os.Exit(m.Run())
+}
+
+// assertGolden compares got with testdata/<name>.golden
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	testutil.AssertGolden(t, filepath.Join("testdata", name+".golden"), got)
+}
+
+func TestDashboardRender(t *testing.T) {
+	d := NewDashboard()
+	d.SetSize(100, 30)
+	d.SetClock(func() time.Time { return testutil.Time })
+	snap := testutil.Snapshot()
+
+	for tab := range d.tabs {
+		name := fmt.Sprintf("dashboard_%d_%s", tab, d.tabs[tab])
+		assertGolden(t, name, []byte(d.Render(snap)))
+		d.NextTab()
+	}
+}
+
+func TestDashboardRenderIsStable(t *testing.T) {
+	d := NewDashboard()
+	d.SetSize(100, 30)
+	d.SetClock(func() time.Time { return testutil.Time })
+	snap := testutil.Snapshot()
+
+	for tab := range d.tabs {
+		first := d.Render(snap)
+		for i := 0; i < 10; i++ {
+			if got := d.Render(snap); got != first {
+				t.Fatalf("tab %s renders differently between calls", d.tabs[tab])
+			}
+		}
+		d.NextTab()
+	}
+}
+
+func TestCgroupView(t *testing.T) {
+	d := NewDashboard()
+	d.SetSize(160, 40)
+	snap := testutil.Snapshot()
+	for d.ActiveTab() != 7 {
+		d.NextTab()
+	}
+
+	out := d.Render(snap)
+	for _, want := range []string{"Cgroups (6)", "├─ system.slice", "│ ├─ app.service", "│ └─ postgresql.service", "└─ user.slice", "2.0 GiB", "40/100"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cgroup view does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Enter on app.service lists its processes
+	view := d.CgroupView()
+	for i := 0; i < 3; i++ {
+		view.ScrollDown()
+	}
+	if out := d.Render(snap); !strings.Contains(out, "Pressure of app.service (some/full avg10): CPU 0.0/0.0%  Memory 26.9/15.1%") {
+		t.Errorf("pressure of the selected cgroup missing:\n%s", out)
+	}
+	d.ShowCgroupProcesses()
+	if d.ActiveTab() != 5 || d.ProcessFilter() != `cgroup="/system.slice/app.service"` {
+		t.Fatalf("tab %d, filter %q", d.ActiveTab(), d.ProcessFilter())
+	}
+	if out := d.Render(snap); !strings.Contains(out, "java") || strings.Contains(out, "postgres -D") || strings.Contains(out, "systemd") {
+		t.Errorf("processes of app.service:\n%s", out)
+	}
+}
+
+func TestAlertHistoryView(t *testing.T) {
+	d := NewDashboard()
+	d.SetSize(120, 30)
+	snap := testutil.Snapshot()
+	for d.ActiveTab() != 6 {
+		d.NextTab()
+	}
+
+	history := d.AlertHistory()
+	history.Toggle()
+	if history.Active() {
+		t.Fatal("history shown without an alert history")
+	}
+	history.SetAvailable(true)
+	if out := d.Render(snap); !strings.Contains(out, "H: Alert history") {
+		t.Errorf("current alerts do not offer the history:\n%s", out)
+	}
+
+	history.Toggle()
+	history.SetEntries([]alertlog.Entry{
+		{Source: "cpu_usage", Level: system.CriticalLevel, Message: "CPU usage is 97.0", Fired: testutil.Time.Add(-5 * time.Minute)},
+		{Source: "disk_usage{mount=/var}", Level: system.WarningLevel, Message: "/var is full", Fired: testutil.Time.Add(-2 * time.Hour), Resolved: testutil.Time.Add(-90 * time.Minute)},
+		{Source: "memory_usage", Level: system.WarningLevel, Message: "Memory is high", Fired: testutil.Time.Add(-3 * time.Hour), Resolved: testutil.Time.Add(-150 * time.Minute), Interrupted: true},
+	}, testutil.Time)
+	out := d.Render(snap)
+	for _, want := range []string{
+		"Alert history: last 24 hours (3 alerts)",
+		"2024-01-02 02:59:05   00:05:00  active    CRITICAL  cpu_usage: CPU usage is 97.0",
+		"2024-01-02 01:04:05   00:30:00  resolved  WARNING   disk_usage{mount=/var}: /var is full",
+		"2024-01-02 00:04:05   00:30:00  stopped   WARNING   memory_usage: Memory is high",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("history does not contain %q:\n%s", want, out)
+		}
+	}
+
+	history.NextRange()
+	if history.Range() != "7d" {
+		t.Errorf("range after ] = %q, want 7d", history.Range())
+	}
+	history.SetCustomRange("2024-01-01..2024-01-02")
+	if out := d.Render(snap); !strings.Contains(out, "Alert history: 2024-01-01..2024-01-02") {
+		t.Errorf("custom range not shown:\n%s", out)
+	}
+	history.PrevRange()
+	if history.Range() != "all" {
+		t.Errorf("range after [ from a custom range = %q, want all", history.Range())
+	}
+}
+
+func TestAlertList(t *testing.T) {
+	d := NewDashboard()
+	d.SetSize(120, 30)
+	snap := testutil.Snapshot()
+	for d.ActiveTab() != 6 {
+		d.NextTab()
+	}
+
+	list := d.AlertList()
+	out := d.Render(snap)
+	for _, want := range []string{
+		"Active (2)",
+		"firing   00:05:00  [CRITICAL] CPU usage is high (61.5%)",
+		"firing   02:00:00  [WARNING] Disk usage on /var is high (95.0%)",
+		"Resolved (1)",
+		"resolved 00:30:00  [CRITICAL] Memory usage is high (91.2%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("alerts do not contain %q:\n%s", want, out)
+		}
+	}
+	if alert, ok := list.Selected(); !ok || alert.Source != "cpu_usage" {
+		t.Fatalf("Selected() = %q, %t, want cpu_usage", alert.Source, ok)
+	}
+	list.ScrollDown()
+	if alert, _ := list.Selected(); alert.Source != "disk_usage_/var" {
+		t.Errorf("Selected() after moving down = %q, want disk_usage_/var", alert.Source)
+	}
+
+	// The selection follows its alert when a new one is raised above it
+	snap.Alerts = append([]system.Alert{{ID: "0c5b7e29a1f4d863", State: system.AlertPending, Timestamp: testutil.Time.Add(-30 * time.Second),
+		Message: "Swap usage is high", Level: system.WarningLevel, Source: "swap_usage", FirstSeen: testutil.Time.Add(-30 * time.Second)}}, snap.Alerts...)
+	snap.Alerts[1].Acknowledged = true
+	snap.Alerts[2].SuppressedBy = "maintenance backup"
+	out = d.Render(snap)
+	if alert, _ := list.Selected(); alert.Source != "disk_usage_/var" {
+		t.Errorf("Selected() after a new alert = %q, want disk_usage_/var", alert.Source)
+	}
+	for _, want := range []string{
+		"Active (3)",
+		"pending  00:00:30  [WARNING] Swap usage is high",
+		"CPU usage is high (61.5%) (acknowledged)",
+		"Disk usage on /var is high (95.0%) (maintenance backup)",
+		"a: Acknowledge",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("alerts do not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Moving past the active alerts selects the resolved ones
+	list.ScrollDown()
+	list.ScrollDown()
+	if alert, _ := list.Selected(); alert.Source != "memory_usage" {
+		t.Errorf("Selected() at the end = %q, want memory_usage", alert.Source)
+	}
+
+	list.OpenSilence("cpu_usage")
+	if out := d.Render(snap); !strings.Contains(out, "Silence cpu_usage for") {
+		t.Errorf("silence bar not shown:\n%s", out)
+	}
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SavedFilter is a named process filter query from the configuration
+type SavedFilter struct {
+	Name  string
+	Query string
+}
+
+// FilterBar edits the process filter query. The process table follows the
+// query while it is typed, as long as it parses.
+type FilterBar struct {
+	active   bool
+	input    []rune
+	previous string // filter in effect when the bar was opened
+	err      error  // why the current input does not parse
+	saved    []SavedFilter
+	savedPos int    // saved filter shown, or -1 while editing
+	hint     string // replaces the process filter examples when set
+}
+
+// NewFilterBar creates a closed filter bar
+func NewFilterBar() *FilterBar {
+	return &FilterBar{savedPos: -1}
+}
+
+// SetSaved sets the saved filters offered while editing
+func (f *FilterBar) SetSaved(saved []SavedFilter) {
+	f.saved = saved
+}
+
+// SetHint replaces the help line, for bars that edit something other than
+// a process filter
+func (f *FilterBar) SetHint(hint string) {
+	f.hint = hint
+}
+
+// Open shows the bar with the current filter query
+func (f *FilterBar) Open(current string) {
+	f.active = true
+	f.input = []rune(current)
+	f.previous = current
+	f.err = nil
+	f.savedPos = -1
+}
+
+// Close hides the bar
+func (f *FilterBar) Close() {
+	f.active = false
+}
+
+// Active reports whether the bar is shown
+func (f *FilterBar) Active() bool {
+	return f.active
+}
+
+// Value returns the query being edited
+func (f *FilterBar) Value() string {
+	return string(f.input)
+}
+
+// Previous returns the query in effect when the bar was opened, which is
+// restored when editing is cancelled
+func (f *FilterBar) Previous() string {
+	return f.previous
+}
+
+// Insert appends typed text to the query
+func (f *FilterBar) Insert(s string) {
+	f.input = append(f.input, []rune(s)...)
+	f.savedPos = -1
+}
+
+// Backspace removes the last character of the query
+func (f *FilterBar) Backspace() {
+	if len(f.input) > 0 {
+		f.input = f.input[:len(f.input)-1]
+	}
+	f.savedPos = -1
+}
+
+// Clear empties the query
+func (f *FilterBar) Clear() {
+	f.input = nil
+	f.savedPos = -1
+}
+
+// NextSaved replaces the query with the next saved filter and reports
+// whether there is one
+func (f *FilterBar) NextSaved() bool {
+	return f.selectSaved(f.savedPos + 1)
+}
+
+// PrevSaved replaces the query with the previous saved filter and reports
+// whether there is one
+func (f *FilterBar) PrevSaved() bool {
+	if f.savedPos < 0 {
+		return f.selectSaved(len(f.saved) - 1)
+	}
+	return f.selectSaved(f.savedPos - 1)
+}
+
+// selectSaved shows saved filter i, wrapping around the list
+func (f *FilterBar) selectSaved(i int) bool {
+	if len(f.saved) == 0 {
+		return false
+	}
+	f.savedPos = (i + len(f.saved)) % len(f.saved)
+	f.input = []rune(f.saved[f.savedPos].Query)
+	return true
+}
+
+// SetError records whether the query parses; nil clears the error
+func (f *FilterBar) SetError(err error) {
+	f.err = err
+}
+
+// Valid reports whether the query parses
+func (f *FilterBar) Valid() bool {
+	return f.err == nil
+}
+
+// Render draws the bar
+func (f *FilterBar) Render() string {
+	if !f.active {
+		return ""
+	}
+	prompt := "/ "
+	if f.savedPos >= 0 {
+		prompt = fmt.Sprintf("/ [%s] ", f.saved[f.savedPos].Name)
+	}
+	lines := []string{HeaderStyle.Render(prompt) + string(f.input) + "█"}
+	if f.err != nil {
+		lines = append(lines, CriticalStyle.Render(f.err.Error()))
+	}
+	hint := "Enter: Apply • Esc: Cancel • Ctrl+U: Clear • e.g. user:postgres cpu>10 state:D name~^java"
+	if len(f.saved) > 0 {
+		hint = "Enter: Apply • Esc: Cancel • Ctrl+U: Clear • ↑↓: Saved filters"
+	}
+	if f.hint != "" {
+		hint = f.hint
+	}
+	lines = append(lines, helpStyle.Render(hint))
+	return CardStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
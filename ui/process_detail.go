@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+)
+
+// ProcessDetailView shows everything known about a single process: the
+// collected fields plus an inspection loaded on demand
+type ProcessDetailView struct {
+	active     bool
+	process    system.ProcessDetail
+	history    system.ProcessHistory
+	inspection *system.ProcessInspection // nil while loading
+	note       string                    // shown instead of the inspection, e.g. in replay
+	scrollPos  int
+	width      int
+	height     int
+}
+
+// NewProcessDetailView creates a closed detail view
+func NewProcessDetailView() *ProcessDetailView {
+	return &ProcessDetailView{}
+}
+
+// Open shows the view for a process; the inspection is set once loaded
+func (v *ProcessDetailView) Open(proc system.ProcessDetail) {
+	*v = ProcessDetailView{active: true, process: proc, width: v.width, height: v.height}
+}
+
+// Close hides the view
+func (v *ProcessDetailView) Close() {
+	v.active = false
+}
+
+// Active reports whether the view is shown
+func (v *ProcessDetailView) Active() bool {
+	return v.active
+}
+
+// Process returns the process shown
+func (v *ProcessDetailView) Process() system.ProcessDetail {
+	return v.process
+}
+
+// SetProcess updates the collected fields, e.g. after a refresh
+func (v *ProcessDetailView) SetProcess(proc system.ProcessDetail) {
+	v.process = proc
+}
+
+// SetHistory updates the recent samples of the process
+func (v *ProcessDetailView) SetHistory(history system.ProcessHistory) {
+	v.history = history
+}
+
+// SetInspection shows the details read for the process. Inspections of
+// another process, which arrive after the view was reopened, are ignored.
+func (v *ProcessDetailView) SetInspection(pi *system.ProcessInspection) {
+	if pi != nil && pi.PID == v.process.PID {
+		v.inspection = pi
+	}
+}
+
+// SetNote shows a message in place of the inspection
+func (v *ProcessDetailView) SetNote(note string) {
+	v.note = note
+}
+
+// SetSize updates the view dimensions
+func (v *ProcessDetailView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
+
+// ScrollUp scrolls up by n lines
+func (v *ProcessDetailView) ScrollUp(n int) {
+	v.scrollPos -= n
+	if v.scrollPos < 0 {
+		v.scrollPos = 0
+	}
+}
+
+// ScrollDown scrolls down by n lines; Render clamps to the content
+func (v *ProcessDetailView) ScrollDown(n int) {
+	v.scrollPos += n
+}
+
+// Home scrolls to the top
+func (v *ProcessDetailView) Home() {
+	v.scrollPos = 0
+}
+
+// PageSize returns the number of lines scrolled by a page
+func (v *ProcessDetailView) PageSize() int {
+	if v.height > 8 {
+		return v.height - 6
+	}
+	return 10
+}
+
+// Render draws the view
+func (v *ProcessDetailView) Render() string {
+	lines := v.lines()
+
+	// Keep the title and key hints visible and scroll the rest
+	rows := v.height - 6
+	if rows < 5 {
+		rows = len(lines)
+	}
+	body := lines[1:]
+	if last := len(body) - rows; v.scrollPos > last {
+		v.scrollPos = last
+	}
+	if v.scrollPos < 0 {
+		v.scrollPos = 0
+	}
+	end := v.scrollPos + rows
+	if end > len(body) {
+		end = len(body)
+	}
+	shown := append([]string{lines[0]}, body[v.scrollPos:end]...)
+	shown = append(shown, "", helpStyle.Render("↑↓/PgUp/PgDn: Scroll • r: Reload • Esc/Enter: Close"))
+
+	width := v.width - 4
+	if width < 40 {
+		width = 40
+	}
+	return CardStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, shown...))
+}
+
+// lines returns the content of the view, starting with the title
+func (v *ProcessDetailView) lines() []string {
+	p := v.process
+	lines := []string{HeaderStyle.Render(fmt.Sprintf("Process %d (%s)", p.PID, p.Name))}
+
+	section := func(name string) {
+		lines = append(lines, "", TableHeaderStyle.Render(name))
+	}
+	field := func(name, value string) {
+		lines = append(lines, fmt.Sprintf("  %-18s %s", name+":", value))
+	}
+
+	section("Process")
+	field("PID / PPID", fmt.Sprintf("%d / %d", p.PID, p.PPID))
+	field("User", p.Username)
+	field("State", strings.Join(p.Status, ","))
+	field("Nice", fmt.Sprint(p.Nice))
+	field("Threads", fmt.Sprint(p.NumThreads))
+	field("CPU / Memory", fmt.Sprintf("%.1f%% / %.1f%%", p.CPUPercent, p.MemPercent))
+	field("RSS / VMS", fmt.Sprintf("%s / %s", FormatBytes(p.MemRSS), FormatBytes(p.MemVMS)))
+	started := "-"
+	if !p.CreatedAt.IsZero() {
+		started = p.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+	field("Started", started)
+	field("Command line", "")
+	lines = append(lines, v.wrap(p.CmdLine, "    ")...)
+
+	section("History")
+	h := v.history
+	lines = append(lines,
+		v.historyLine("CPU", h.CPU, 100, StyleValue(p.CPUPercent), func(x float64) string { return fmt.Sprintf("%.1f%%", x) }),
+		v.historyLine("RSS", h.RSS, seriesPeak(h.RSS), NormalStyle, func(x float64) string { return FormatBytes(uint64(x)) }),
+		v.historyLine("Read", h.ReadRate, seriesPeak(h.ReadRate), NormalStyle, formatRate),
+		v.historyLine("Write", h.WriteRate, seriesPeak(h.WriteRate), NormalStyle, formatRate),
+	)
+
+	pi := v.inspection
+	switch {
+	case v.note != "":
+		lines = append(lines, "", WarningStyle.Render(v.note))
+		return lines
+	case pi == nil:
+		lines = append(lines, "", BaseStyle.Render("Loading details..."))
+		return lines
+	}
+
+	// failed shows the error of a section that could not be read and
+	// reports whether there was one
+	failed := func(name string) bool {
+		if err, ok := pi.Errors[name]; ok {
+			lines = append(lines, "  "+WarningStyle.Render("unavailable: "+err))
+			return true
+		}
+		return false
+	}
+
+	section("Files")
+	if err, ok := pi.Errors[system.InspectExe]; ok {
+		field("Executable", WarningStyle.Render("unavailable: "+err))
+	} else {
+		field("Executable", pi.Exe)
+	}
+	if err, ok := pi.Errors[system.InspectCwd]; ok {
+		field("Working dir", WarningStyle.Render("unavailable: "+err))
+	} else {
+		field("Working dir", pi.Cwd)
+	}
+
+	section("Scheduling")
+	if !failed(system.InspectCtxSwitches) {
+		field("Voluntary ctx", FormatNumber(int(pi.CtxSwitches.Voluntary)))
+		field("Involuntary ctx", FormatNumber(int(pi.CtxSwitches.Involuntary)))
+	}
+
+	section("I/O")
+	if !failed(system.InspectIO) {
+		io := pi.IO
+		field("Read", fmt.Sprintf("%s from storage, %s total, %s syscalls",
+			FormatBytes(io.ReadBytes), FormatBytes(io.ReadChars), FormatNumber(int(io.ReadSyscalls))))
+		field("Written", fmt.Sprintf("%s to storage, %s total, %s syscalls",
+			FormatBytes(io.WriteBytes), FormatBytes(io.WriteChars), FormatNumber(int(io.WriteSyscalls))))
+		if io.CancelledWriteBytes > 0 {
+			field("Cancelled", FormatBytes(io.CancelledWriteBytes))
+		}
+	}
+
+	section("Memory Maps")
+	if !failed(system.InspectMaps) {
+		m := pi.Maps
+		field("Mappings", fmt.Sprintf("%d (%d files)", m.Mappings, m.Files))
+		field("Virtual", fmt.Sprintf("%s: files %s, anon %s, heap %s, stack %s",
+			FormatBytes(m.Size), FormatBytes(m.FileSize), FormatBytes(m.AnonSize),
+			FormatBytes(m.HeapSize), FormatBytes(m.StackSize)))
+		if m.RSS > 0 {
+			field("Resident", fmt.Sprintf("RSS %s, PSS %s, swap %s",
+				FormatBytes(m.RSS), FormatBytes(m.PSS), FormatBytes(m.Swap)))
+		}
+	}
+
+	section("Cgroups")
+	if !failed(system.InspectCgroups) {
+		for _, cg := range pi.Cgroups {
+			lines = append(lines, "  "+cg)
+		}
+	}
+
+	section("Namespaces")
+	if !failed(system.InspectNamespaces) {
+		kinds := make([]string, 0, len(pi.Namespaces))
+		for kind := range pi.Namespaces {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			field(kind, pi.Namespaces[kind])
+		}
+	}
+
+	section(fmt.Sprintf("Open Files (%d)", len(pi.FDs)))
+	if !failed(system.InspectFDs) {
+		for _, fd := range pi.FDs {
+			lines = append(lines, fmt.Sprintf("  %5d  %s", fd.FD, fd.Target))
+		}
+	}
+
+	section(fmt.Sprintf("Environment (%d)", len(pi.Environ)))
+	if !failed(system.InspectEnviron) {
+		for _, env := range pi.Environ {
+			lines = append(lines, v.wrap(env, "  ")...)
+		}
+	}
+
+	return lines
+}
+
+// historyLine renders a sparkline of ts scaled to 0..max with the latest
+// and peak values
+func (v *ProcessDetailView) historyLine(name string, ts system.TimeSeries, max float64, style lipgloss.Style, format func(float64) string) string {
+	if len(ts.Points) < 2 {
+		return fmt.Sprintf("  %-18s %s", name+":", BaseStyle.Render("collecting..."))
+	}
+	width := v.width - 60
+	if width > 60 {
+		width = 60
+	}
+	if width < 10 {
+		width = 10
+	}
+	last := ts.Points[len(ts.Points)-1].Value
+	spark := RenderSparklineRange(ts, width, 0, max, style)
+	return fmt.Sprintf("  %-18s %s  now %s, peak %s", name+":", spark, format(last), format(seriesPeak(ts)))
+}
+
+// seriesPeak returns the largest value of ts
+func seriesPeak(ts system.TimeSeries) float64 {
+	var peak float64
+	for _, p := range ts.Points {
+		if p.Value > peak {
+			peak = p.Value
+		}
+	}
+	return peak
+}
+
+// formatRate formats a byte rate
+func formatRate(bytesPerSecond float64) string {
+	return FormatBytes(uint64(bytesPerSecond)) + "/s"
+}
+
+// wrap splits s into indented lines that fit the view
+func (v *ProcessDetailView) wrap(s, indent string) []string {
+	width := v.width - 8 - len(indent)
+	if width < 20 {
+		width = 20
+	}
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return []string{indent}
+	}
+	var lines []string
+	for len(runes) > width {
+		lines = append(lines, indent+string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, indent+string(runes))
+}
@@ -10,101 +10,400 @@ import (
 
 // ProcessTable renders an enhanced process list
 type ProcessTable struct {
-	width      int
-	height     int
-	sortBy     system.SortType
-	scrollPos  int // Current scroll position
-	filterText string
+	width       int
+	height      int
+	sortBy      system.SortType
+	scrollPos   int // Current scroll position
+	filter      *system.ProcessFilter  // nil shows every process
+	cursor      int                    // index of the selected row in visible
+	selectedPID int32                  // keeps the selection on a process across refreshes
+	visible     []system.ProcessDetail // processes shown by the last Render
+	treeMode    bool                   // nest children under their parents
+	collapsed   map[int32]bool         // PIDs whose subtrees are hidden in tree mode
+	history     map[system.ProcessKey]system.ProcessHistory
+	columns     []column // columns shown, in order
+
+	groupBy       system.GroupBy
+	expanded      map[string]bool // groups whose members are listed
+	groupRows     []string        // group key of each visible row; "" for processes
+	selectedGroup string          // keeps the selection on a group across refreshes
 }
 
-// Column definitions for the process table
-var columns = []struct {
+// processRow is a row of the process table: a process and its recent samples
+type processRow struct {
+	system.ProcessDetail
+	history system.ProcessHistory
+}
+
+// column is a column of the process table
+type column struct {
+	id     string // name used in the configuration
 	title  string
 	width  int
 	align  lipgloss.Position
-	format func(p system.ProcessDetail) string
-}{
-	{"PID", 8, lipgloss.Right, func(p system.ProcessDetail) string {
+	sortBy system.SortType // "" when the column cannot be sorted
+	flex   bool            // takes the remaining width when it is the last column
+	format func(p processRow, width int) string
+}
+
+// ColumnSpec selects a process table column and its width; 0 keeps the
+// default width
+type ColumnSpec struct {
+	ID    string
+	Width int
+}
+
+// Limits for resized columns
+const (
+	minColumnWidth = 3
+	maxColumnWidth = 80
+)
+
+// columnRegistry lists every column the process table can show
+var columnRegistry = []column{
+	{"pid", "PID", 8, lipgloss.Right, system.SortByPID, false, func(p processRow, width int) string {
 		pidStyle := BaseStyle
 		// ProcessDetail doesn't include priority in this collector; highlight by CPU instead
 		if p.CPUPercent > 80 {
 			pidStyle = NormalStyle.Copy().Bold(true)
 		}
-		return pidStyle.Render(fmt.Sprintf("%7d", p.PID))
+		return pidStyle.Render(fmt.Sprintf("%*d", width-1, p.PID))
+	}},
+	{"ppid", "PPID", 8, lipgloss.Right, system.SortByPPID, false, func(p processRow, width int) string {
+		return BaseStyle.Render(fmt.Sprintf("%*d", width-1, p.PPID))
 	}},
-	{"CPU%", 7, lipgloss.Right, func(p system.ProcessDetail) string {
+	{"cpu", "CPU%", 7, lipgloss.Right, system.SortByCPU, false, func(p processRow, _ int) string {
 		style := StyleValue(p.CPUPercent)
 		if p.CPUPercent >= 1.0 {
 			return style.Bold(true).Render(fmt.Sprintf("%6.1f", p.CPUPercent))
 		}
 		return style.Render(fmt.Sprintf("%6.1f", p.CPUPercent))
 	}},
-	{"MEM%", 7, lipgloss.Right, func(p system.ProcessDetail) string {
+	{"life", "LIFE%", 7, lipgloss.Right, system.SortByCPULifetime, false, func(p processRow, _ int) string {
+		// Average over the lifetime of the process, which favours long-running
+		// daemons over what is busy now
+		return BaseStyle.Render(fmt.Sprintf("%6.1f", p.CPULifetime))
+	}},
+	{"mem", "MEM%", 7, lipgloss.Right, system.SortByMemory, false, func(p processRow, _ int) string {
 		style := StyleValue(float64(p.MemPercent))
 		if p.MemPercent >= 1.0 {
 			return style.Bold(true).Render(fmt.Sprintf("%6.1f", p.MemPercent))
 		}
 		return style.Render(fmt.Sprintf("%6.1f", p.MemPercent))
 	}},
-	{"STATE", 8, lipgloss.Left, func(p system.ProcessDetail) string {
+	{"rss", "RSS", 10, lipgloss.Right, system.SortByRSS, false, func(p processRow, _ int) string {
+		return BaseStyle.Render(FormatBytes(p.MemRSS))
+	}},
+	{"vms", "VIRT", 10, lipgloss.Right, system.SortByVMS, false, func(p processRow, _ int) string {
+		return BaseStyle.Render(FormatBytes(p.MemVMS))
+	}},
+	{"state", "STATUS", 8, lipgloss.Left, system.SortByState, false, func(p processRow, _ int) string {
 		if len(p.Status) == 0 {
 			return WarningStyle.Render("? ???")
 		}
+		// gopsutil reports states as words ("running"); accept ps letters too
 		state := p.Status[0]
 		switch state {
-		case "D": // Uninterruptible sleep
+		case "D", "blocked": // Uninterruptible sleep
 			return WarningStyle.Render("⌛ WAIT")
-		case "R": // Running
+		case "R", "running": // Running
 			return NormalStyle.Render("▶ RUN")
-		case "S": // Interruptible sleep
+		case "S", "sleep": // Interruptible sleep
 			return BaseStyle.Render("💤 slp")
-		case "T": // Stopped
+		case "T", "stop": // Stopped
 			return WarningStyle.Render("⏸ STOP")
-		case "Z": // Zombie
+		case "Z", "zombie": // Zombie
 			return CriticalStyle.Render("💀 DEAD")
 		default:
 			return WarningStyle.Render(fmt.Sprintf("? %s", state))
 		}
 	}},
-	{"USER", 12, lipgloss.Left, func(p system.ProcessDetail) string {
+	{"nice", "NI", 4, lipgloss.Right, system.SortByNice, false, func(p processRow, _ int) string {
+		// Negative nice values mean raised priority, positive ones lowered
+		style := BaseStyle
+		if p.Nice < 0 {
+			style = WarningStyle
+		} else if p.Nice > 0 {
+			style = NormalStyle.Copy().Foreground(Theme.Purple)
+		}
+		return style.Render(fmt.Sprintf("%3d", p.Nice))
+	}},
+	{"user", "USER", 12, lipgloss.Left, system.SortByUser, false, func(p processRow, width int) string {
 		style := BaseStyle
 		if p.Username == "root" {
 			style = WarningStyle.Copy().Bold(true)
 		}
-		name := p.Username
-		if len(name) > 12 {
-			name = name[:9] + "..."
-		}
-		return style.Render(name)
+		return style.Render(Truncate(p.Username, width))
 	}},
-	{"THREADS", 8, lipgloss.Right, func(p system.ProcessDetail) string {
+	{"threads", "THREADS", 8, lipgloss.Right, system.SortByThreads, false, func(p processRow, width int) string {
 		style := BaseStyle
 		if p.NumThreads > 100 {
 			style = WarningStyle
 		} else if p.NumThreads > 50 {
 			style = NormalStyle.Copy().Foreground(Theme.Warning)
 		}
-		return style.Render(fmt.Sprintf("%7d", p.NumThreads))
+		return style.Render(fmt.Sprintf("%*d", width-1, p.NumThreads))
+	}},
+	{"start", "START", 12, lipgloss.Left, system.SortByStart, false, func(p processRow, _ int) string {
+		if p.CreatedAt.IsZero() {
+			return BaseStyle.Render("-")
+		}
+		return BaseStyle.Render(p.CreatedAt.Local().Format("Jan02 15:04"))
+	}},
+	{"cpuhist", "CPU HIST", 11, lipgloss.Left, "", false, func(p processRow, width int) string {
+		// Recent CPU% of the process, to tell a steady hog from a spike
+		return RenderSparklineRange(p.history.CPU, width-1, 0, 100, StyleValue(p.CPUPercent))
+	}},
+	{"read", "READ", 10, lipgloss.Right, system.SortByRead, false, func(p processRow, _ int) string {
+		if p.IO == nil {
+			return BaseStyle.Render("-")
+		}
+		return BaseStyle.Render(FormatBytes(p.IO.ReadBytes))
 	}},
-	{"NAME", 30, lipgloss.Left, func(p system.ProcessDetail) string {
+	{"write", "WRITE", 10, lipgloss.Right, system.SortByWrite, false, func(p processRow, _ int) string {
+		if p.IO == nil {
+			return BaseStyle.Render("-")
+		}
+		return BaseStyle.Render(FormatBytes(p.IO.WriteBytes))
+	}},
+	{"cgroup", "CGROUP", 28, lipgloss.Left, system.SortByCgroup, false, func(p processRow, width int) string {
+		// The systemd unit, or the path outside systemd
+		if p.Cgroup == "" {
+			return BaseStyle.Render("-")
+		}
+		return BaseStyle.Render(Truncate(system.CgroupUnit(p.Cgroup), width-1))
+	}},
+	{"name", "NAME", 30, lipgloss.Left, system.SortByName, true, func(p processRow, width int) string {
 		style := BaseStyle
 		// Highlight system services and daemons
 		if strings.HasSuffix(p.Name, "d") || strings.HasSuffix(p.Name, "daemon") {
 			style = style.Foreground(Theme.Purple)
 		}
-		name := p.Name
-		if len(name) > 30 {
-			name = name[:27] + "..."
+		// Tree names carry their indentation, so use the whole column
+		return style.Render(Truncate(p.Name, width))
+	}},
+	{"cmd", "COMMAND", 40, lipgloss.Left, system.SortByCommand, true, func(p processRow, width int) string {
+		cmdLine := p.CmdLine
+		if cmdLine == "" {
+			cmdLine = "[" + p.Name + "]" // kernel threads have no command line
 		}
-		return style.Render(name)
+		return BaseStyle.Render(Truncate(cmdLine, width))
 	}},
 }
 
+// DefaultColumns are the process table columns shown unless configured
+var DefaultColumns = []ColumnSpec{
+	{ID: "pid"}, {ID: "cpu"}, {ID: "life"}, {ID: "mem"}, {ID: "state"}, {ID: "nice"},
+	{ID: "user"}, {ID: "threads"}, {ID: "cpuhist"}, {ID: "name"},
+}
+
+// ColumnIDs returns the IDs of every available column
+func ColumnIDs() []string {
+	ids := make([]string, len(columnRegistry))
+	for i, col := range columnRegistry {
+		ids[i] = col.id
+	}
+	return ids
+}
+
+// lookupColumn returns the registered column with the given ID
+func lookupColumn(id string) (column, bool) {
+	for _, col := range columnRegistry {
+		if col.id == id {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// resolveColumns returns the columns selected by specs. Unknown and
+// repeated IDs and out of range widths are errors.
+func resolveColumns(specs []ColumnSpec) ([]column, error) {
+	if len(specs) == 0 {
+		specs = DefaultColumns
+	}
+	cols := make([]column, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		col, ok := lookupColumn(spec.ID)
+		if !ok {
+			return nil, fmt.Errorf("unknown process column %q (available: %s)", spec.ID, strings.Join(ColumnIDs(), ", "))
+		}
+		if seen[spec.ID] {
+			return nil, fmt.Errorf("process column %q is listed twice", spec.ID)
+		}
+		seen[spec.ID] = true
+		if spec.Width != 0 {
+			if spec.Width < minColumnWidth || spec.Width > maxColumnWidth {
+				return nil, fmt.Errorf("width %d of process column %q is outside %d-%d", spec.Width, spec.ID, minColumnWidth, maxColumnWidth)
+			}
+			col.width = spec.Width
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// ValidateColumns reports whether specs select valid process table columns
+func ValidateColumns(specs []ColumnSpec) error {
+	_, err := resolveColumns(specs)
+	return err
+}
+
+// Truncate shortens s to width runes, marking the cut with an ellipsis
+func Truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(r[:width])
+	}
+	return string(r[:width-3]) + "..."
+}
+
 // NewProcessTable creates a new process table
 func NewProcessTable() *ProcessTable {
+	cols, _ := resolveColumns(nil)
 	return &ProcessTable{
-		sortBy: system.SortByCPU,
+		sortBy:    system.SortByCPU,
+		collapsed: make(map[int32]bool),
+		columns:   cols,
+		expanded:  make(map[string]bool),
+	}
+}
+
+// SetColumns selects the columns shown and their order; no specs restores
+// the default columns. Invalid specs are rejected and the current columns
+// are kept.
+func (pt *ProcessTable) SetColumns(specs []ColumnSpec) error {
+	cols, err := resolveColumns(specs)
+	if err != nil {
+		return err
+	}
+	pt.columns = cols
+	return nil
+}
+
+// Columns returns the columns shown, in order
+func (pt *ProcessTable) Columns() []ColumnSpec {
+	specs := make([]ColumnSpec, len(pt.columns))
+	for i, col := range pt.columns {
+		specs[i] = ColumnSpec{ID: col.id, Width: col.width}
 	}
+	return specs
+}
+
+// SortColumn returns the sort type of the visible sortable column dir
+// columns to the right of the sorted one, wrapping around. When the table
+// is not sorted by a visible column it starts from the first one.
+func (pt *ProcessTable) SortColumn(dir int) system.SortType {
+	var sortable []system.SortType
+	current := -1
+	for _, col := range pt.columns {
+		if col.sortBy == "" {
+			continue
+		}
+		if col.sortBy == pt.sortBy {
+			current = len(sortable)
+		}
+		sortable = append(sortable, col.sortBy)
+	}
+	if len(sortable) == 0 {
+		return pt.sortBy
+	}
+	if current < 0 {
+		return sortable[0]
+	}
+	n := len(sortable)
+	return sortable[((current+dir)%n+n)%n]
+}
+
+// ToggleTreeMode switches between the flat list and the process tree
+func (pt *ProcessTable) ToggleTreeMode() {
+	pt.treeMode = !pt.treeMode
+	if pt.treeMode {
+		pt.groupBy = system.GroupByNone
+	}
+}
+
+// groupModes is the order CycleGrouping steps through
+var groupModes = []system.GroupBy{system.GroupByNone, system.GroupByUser, system.GroupByName, system.GroupByCgroup}
+
+// CycleGrouping switches to the next grouping mode: none, user, name,
+// cgroup. Grouping replaces the tree view.
+func (pt *ProcessTable) CycleGrouping() {
+	for i, by := range groupModes {
+		if by == pt.groupBy {
+			pt.SetGrouping(groupModes[(i+1)%len(groupModes)])
+			return
+		}
+	}
+	pt.SetGrouping(system.GroupByNone)
+}
+
+// SetGrouping aggregates the rows by by; GroupByNone lists processes
+func (pt *ProcessTable) SetGrouping(by system.GroupBy) {
+	if by != pt.groupBy {
+		pt.expanded = make(map[string]bool)
+	}
+	pt.groupBy = by
+	if by != system.GroupByNone {
+		pt.treeMode = false
+	}
+}
+
+// Grouping returns the grouping mode
+func (pt *ProcessTable) Grouping() system.GroupBy {
+	return pt.groupBy
+}
+
+// SelectedGroup returns the key of the selected group row, if a group row
+// is selected
+func (pt *ProcessTable) SelectedGroup() (string, bool) {
+	key := pt.groupKey(pt.cursor)
+	return key, key != ""
+}
+
+// groupKey returns the group key of visible row i, or "" for a process
+func (pt *ProcessTable) groupKey(i int) string {
+	if i < 0 || i >= len(pt.groupRows) {
+		return ""
+	}
+	return pt.groupRows[i]
+}
+
+// TreeMode reports whether the table shows the process tree
+func (pt *ProcessTable) TreeMode() bool {
+	return pt.treeMode
+}
+
+// ToggleCollapsed hides or shows the children of the selected process in
+// tree mode, or the members of the selected group when grouping
+func (pt *ProcessTable) ToggleCollapsed() {
+	if pt.groupBy != system.GroupByNone {
+		if key, ok := pt.SelectedGroup(); ok {
+			pt.expanded[key] = !pt.expanded[key]
+		} else if proc, ok := pt.Selected(); ok {
+			// Collapse the group of a member
+			delete(pt.expanded, pt.groupBy.Key(&proc))
+			pt.selectedGroup, pt.selectedPID = pt.groupBy.Key(&proc), 0
+		}
+		return
+	}
+	if proc, ok := pt.Selected(); ok && pt.treeMode {
+		pt.collapsed[proc.PID] = !pt.collapsed[proc.PID]
+		if !pt.collapsed[proc.PID] {
+			delete(pt.collapsed, proc.PID)
+		}
+	}
+}
+
+// ExpandAll shows every subtree in tree mode, or collapses every group
+// when grouping
+func (pt *ProcessTable) ExpandAll() {
+	pt.collapsed = make(map[int32]bool)
+	pt.expanded = make(map[string]bool)
 }
 
 // SetSize updates the table dimensions
@@ -113,129 +412,188 @@ func (pt *ProcessTable) SetSize(width, height int) {
 	pt.height = height
 }
 
+// SetHistory sets the recent samples of the processes, used for the
+// history column
+func (pt *ProcessTable) SetHistory(history map[system.ProcessKey]system.ProcessHistory) {
+	pt.history = history
+}
+
 // SetSortBy updates the sort method
 func (pt *ProcessTable) SetSortBy(sortBy system.SortType) {
 	pt.sortBy = sortBy
 }
 
-// ScrollUp moves the view up
+// ScrollUp moves the selection up
 func (pt *ProcessTable) ScrollUp() {
-	if pt.scrollPos > 0 {
-		pt.scrollPos--
-	}
+	pt.moveCursor(pt.cursor-1, len(pt.visible))
 }
 
-// ScrollDown moves the view down
+// ScrollDown moves the selection down
 func (pt *ProcessTable) ScrollDown(maxRows int) {
-	if pt.scrollPos < maxRows-1 {
-		pt.scrollPos++
-	}
+	pt.moveCursor(pt.cursor+1, maxRows)
 }
 
-// PageUp moves the view up by a page
+// PageUp moves the selection up by a page
 func (pt *ProcessTable) PageUp() {
-	pageSize := (pt.height - 4)
-	if pageSize < 1 {
-		pageSize = 10
-	}
-	pt.scrollPos -= pageSize
-	if pt.scrollPos < 0 {
-		pt.scrollPos = 0
-	}
+	pt.moveCursor(pt.cursor-pt.pageSize(), len(pt.visible))
 }
 
-// PageDown moves the view down by a page
+// PageDown moves the selection down by a page
 func (pt *ProcessTable) PageDown(maxRows int) {
-	pageSize := (pt.height - 4)
-	if pageSize < 1 {
-		pageSize = 10
-	}
-	pt.scrollPos += pageSize
-	if pt.scrollPos >= maxRows {
-		pt.scrollPos = maxRows - 1
-	}
-	if pt.scrollPos < 0 {
-		pt.scrollPos = 0
-	}
+	pt.moveCursor(pt.cursor+pt.pageSize(), maxRows)
 }
 
 // Home moves to the top
 func (pt *ProcessTable) Home() {
-	pt.scrollPos = 0
+	pt.moveCursor(0, len(pt.visible))
 }
 
 // End moves to the bottom
 func (pt *ProcessTable) End(maxRows int) {
-	pt.scrollPos = maxRows - 1
-	if pt.scrollPos < 0 {
-		pt.scrollPos = 0
+	pt.moveCursor(maxRows-1, maxRows)
+}
+
+// pageSize returns the number of rows moved by PageUp and PageDown
+func (pt *ProcessTable) pageSize() int {
+	pageSize := (pt.height - 4)
+	if pageSize < 1 {
+		pageSize = 10
 	}
+	return pageSize
 }
 
-// SetFilterText updates the filter text
-func (pt *ProcessTable) SetFilterText(text string) {
-	pt.filterText = text
-	pt.scrollPos = 0 // Reset scroll when filtering
+// moveCursor selects row pos, clamped to the rows shown by the last Render
+// and to maxRows
+func (pt *ProcessTable) moveCursor(pos, maxRows int) {
+	if maxRows > len(pt.visible) {
+		maxRows = len(pt.visible)
+	}
+	if pos >= maxRows {
+		pos = maxRows - 1
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	pt.cursor = pos
+	if pos < len(pt.visible) {
+		pt.selectedPID = pt.visible[pos].PID
+		pt.selectedGroup = pt.groupKey(pos)
+	}
 }
 
-// filterProcesses filters processes based on filter text
-func (pt *ProcessTable) filterProcesses(processes []system.ProcessDetail) []system.ProcessDetail {
-	if pt.filterText == "" {
-		return processes
+// Selected returns the selected process, if any. A group row is not a
+// process.
+func (pt *ProcessTable) Selected() (system.ProcessDetail, bool) {
+	if pt.cursor < 0 || pt.cursor >= len(pt.visible) || pt.groupKey(pt.cursor) != "" {
+		return system.ProcessDetail{}, false
 	}
-	
-	filtered := make([]system.ProcessDetail, 0)
-	filterLower := strings.ToLower(pt.filterText)
-	
-	for _, proc := range processes {
-		// Check if filter matches name, username, or command line
-		if strings.Contains(strings.ToLower(proc.Name), filterLower) ||
-			strings.Contains(strings.ToLower(proc.Username), filterLower) ||
-			strings.Contains(strings.ToLower(proc.CmdLine), filterLower) {
-			filtered = append(filtered, proc)
-		}
+	return pt.visible[pt.cursor], true
+}
+
+// SetFilterText sets the filter query; see system.ProcessFilter for the
+// syntax. A query that does not parse is rejected and the current filter
+// is kept.
+func (pt *ProcessTable) SetFilterText(text string) error {
+	filter, err := system.ParseProcessFilter(text)
+	if err != nil {
+		return err
 	}
-	
-	return filtered
+	if filter.String() == pt.filter.String() {
+		return nil
+	}
+	pt.filter = filter
+	pt.scrollPos = 0 // Reset scroll when filtering
+	pt.cursor = 0
+	pt.selectedPID = 0
+	return nil
+}
+
+// FilterText returns the filter query, or "" when there is none
+func (pt *ProcessTable) FilterText() string {
+	return pt.filter.String()
 }
 
 // Render draws the process table
 func (pt *ProcessTable) Render(processes []system.ProcessDetail) string {
 	// Apply filtering
-	processes = pt.filterProcesses(processes)
+	processes = pt.filter.Filter(processes)
+	cols := append([]column(nil), pt.columns...)
+
+	// In tree mode each row shows the totals of its subtree and the name is
+	// indented below its parent
+	display := processes
+	pt.groupRows = nil
+	groups := 0
+	switch {
+	case pt.treeMode:
+		processes, display = pt.flattenTree(system.BuildProcessTree(processes, pt.sortBy))
+		cols = sumColumns(cols, treeSums)
+	case pt.groupBy != system.GroupByNone:
+		// Groups show their summed values and list their members when expanded
+		grouped := system.GroupProcesses(processes, pt.groupBy, pt.sortBy)
+		groups = len(grouped)
+		processes, display, pt.groupRows = pt.flattenGroups(grouped)
+		cols = sumColumns(cols, groupSums)
+	}
+	pt.visible = processes
+
+	// Keep the selection on the same process or group when the list is
+	// re-sorted
+	for i, proc := range processes {
+		if proc.PID == pt.selectedPID && pt.groupKey(i) == pt.selectedGroup {
+			pt.cursor = i
+			break
+		}
+	}
+	pt.moveCursor(pt.cursor, len(processes))
 	
 	if len(processes) == 0 {
 		msg := "No processes found"
-		if pt.filterText != "" {
-			msg = fmt.Sprintf("No processes match filter: %s", pt.filterText)
+		if pt.filter != nil {
+			msg = fmt.Sprintf("No processes match filter: %s", pt.filter)
 		}
 		return CardStyle.Render(msg)
 	}
 
 	// Calculate available width for columns
 	fixedWidth := 0
-	for _, col := range columns[:len(columns)-1] { // exclude NAME column
+	for _, col := range cols[:len(cols)-1] { // exclude the last column
 		fixedWidth += col.width + 2 // +2 for better spacing
 	}
 
-	// Create header
-	var headers []string
-	for _, col := range columns {
-		style := TableHeaderStyle.Width(col.width).Align(col.align)
-		if col.title == "STATE" {
-			headers = append(headers, style.Render("STATUS"))
-		} else {
-			headers = append(headers, style.Render(col.title))
+	// A name or command line in the last column takes the remaining space
+	if last := &cols[len(cols)-1]; last.flex {
+		flexWidth := pt.width - fixedWidth - 5 // -4 for margins, -1 for the selection marker
+		if flexWidth < 20 {
+			flexWidth = 20
 		}
+		last.width = flexWidth
 	}
-	header := lipgloss.JoinHorizontal(lipgloss.Top, headers...)
 
-	// Adjust name column width based on available space
-	nameColWidth := pt.width - fixedWidth - 4 // -4 for margins
-	if nameColWidth < 20 {
-		nameColWidth = 20
+	// Group rows show their label in the name column, or the last column
+	// when it is hidden, and leave the columns without a sum empty
+	labelColumn := len(cols) - 1
+	for i, col := range cols {
+		if col.id == "name" {
+			labelColumn = i
+		}
+	}
+
+	// Create header, marking the sorted column
+	var headers []string
+	for _, col := range cols {
+		style := TableHeaderStyle.Width(col.width).Align(col.align)
+		title := col.title
+		if col.sortBy != "" && col.sortBy == pt.sortBy {
+			if pt.sortBy.Descending() {
+				title += "▼"
+			} else {
+				title += "▲"
+			}
+		}
+		headers = append(headers, style.Render(title))
 	}
-	columns[len(columns)-1].width = nameColWidth
+	header := " " + lipgloss.JoinHorizontal(lipgloss.Top, headers...)
 
 	// Build rows with scrolling support
 	var rows []string
@@ -244,9 +602,12 @@ func (pt *ProcessTable) Render(processes []system.ProcessDetail) string {
 		displayRows = 5
 	}
 	
-	// Make sure scroll position is valid
-	if pt.scrollPos < 0 {
-		pt.scrollPos = 0
+	// Scroll so that the selected row is visible
+	if pt.cursor < pt.scrollPos {
+		pt.scrollPos = pt.cursor
+	}
+	if pt.cursor >= pt.scrollPos+displayRows {
+		pt.scrollPos = pt.cursor - displayRows + 1
 	}
 	if pt.scrollPos >= len(processes) {
 		pt.scrollPos = len(processes) - 1
@@ -266,7 +627,7 @@ func (pt *ProcessTable) Render(processes []system.ProcessDetail) string {
 	rows = append(rows, BaseStyle.Foreground(Theme.Border).Render(separator))
 
 	for i := pt.scrollPos; i < endPos; i++ {
-		proc := processes[i]
+		proc := processRow{ProcessDetail: display[i], history: pt.history[display[i].Key()]}
 		var cells []string
 
 		// Alternate row backgrounds for better readability
@@ -280,31 +641,57 @@ func (pt *ProcessTable) Render(processes []system.ProcessDetail) string {
 			rowStyle = rowStyle.Bold(true)
 		}
 
-		for _, col := range columns {
-			cellContent := col.format(proc)
+		// Mark the selected row
+		marker := " "
+		if i == pt.cursor {
+			rowStyle = rowStyle.Reverse(true)
+			marker = HeaderStyle.Render("▶")
+		}
+
+		isGroup := pt.groupKey(i) != ""
+		for c, col := range cols {
+			var cellContent string
+			switch {
+			case isGroup && c == labelColumn:
+				cellContent = HeaderStyle.Render(Truncate(proc.Name, col.width))
+			case isGroup && !groupSums[col.id]:
+			default:
+				cellContent = col.format(proc, col.width)
+			}
 			cell := rowStyle.
 				Width(col.width).
+				MaxHeight(1). // cells too narrow for their content are cut, not wrapped
 				Align(col.align).
 				Render(cellContent)
 			cells = append(cells, cell)
 		}
 
 		// Join cells with proper spacing
-		row := lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+		row := marker + lipgloss.JoinHorizontal(lipgloss.Top, cells...)
 
 		rows = append(rows, row)
 	}
 
 	// Show sort method, process count, and scroll position in the header
-	title := fmt.Sprintf("Processes (%s) - Sorted by %s - Showing %s-%s",
-		FormatNumber(len(processes)),
+	mode := "Processes"
+	count := FormatNumber(len(processes))
+	if pt.treeMode {
+		mode = "Process Tree"
+	}
+	if pt.groupBy != system.GroupByNone {
+		mode = "Processes by " + getGroupingName(pt.groupBy)
+		count = fmt.Sprintf("%s groups", FormatNumber(groups))
+	}
+	title := fmt.Sprintf("%s (%s) - Sorted by %s - Showing %s-%s",
+		mode,
+		count,
 		getSortMethodName(pt.sortBy),
 		FormatNumber(pt.scrollPos+1),
 		FormatNumber(endPos))
 	
 	// Show filter if active
-	if pt.filterText != "" {
-		title += fmt.Sprintf(" [Filter: %s]", pt.filterText)
+	if pt.filter != nil {
+		title += fmt.Sprintf(" [Filter: %s]", pt.filter)
 	}
 
 	// Create scrollbar if needed
@@ -363,17 +750,163 @@ func (pt *ProcessTable) Render(processes []system.ProcessDetail) string {
 	)
 }
 
+// Columns summed in tree mode, where each row totals its subtree, and in
+// group rows
+var (
+	treeSums  = map[string]bool{"cpu": true, "life": true, "mem": true, "rss": true}
+	groupSums = map[string]bool{"cpu": true, "life": true, "mem": true, "rss": true, "vms": true, "threads": true, "read": true, "write": true}
+)
+
+// sumColumns marks the summed columns with Σ and adds the summed RSS after
+// MEM% when it is not shown
+func sumColumns(cols []column, sums map[string]bool) []column {
+	rssAt, hasRSS := -1, false
+	for i := range cols {
+		if sums[cols[i].id] {
+			cols[i].title = "Σ" + cols[i].title
+		}
+		switch cols[i].id {
+		case "mem":
+			rssAt = i + 1
+		case "rss":
+			hasRSS = true
+		}
+	}
+	if rssAt < 0 || hasRSS {
+		return cols
+	}
+	rss, _ := lookupColumn("rss")
+	rss.title = "Σ" + rss.title
+	return append(cols[:rssAt], append([]column{rss}, cols[rssAt:]...)...)
+}
+
+// flattenGroups lists the groups in display order, each followed by its
+// members when expanded. It returns the rows for selection, the rows for
+// display, carrying the group sums and labels, and the group key of each
+// row, "" for members.
+func (pt *ProcessTable) flattenGroups(groups []system.ProcessGroup) (processes, display []system.ProcessDetail, keys []string) {
+	for _, g := range groups {
+		marker := "▸ "
+		if pt.expanded[g.Key] {
+			marker = "▾ "
+		}
+		row := g.Summary(pt.groupBy)
+		row.Name = fmt.Sprintf("%s%s (%d)", marker, g.Key, len(g.Members))
+		processes = append(processes, row)
+		display = append(display, row)
+		keys = append(keys, g.Key)
+		if !pt.expanded[g.Key] {
+			continue
+		}
+		for i, member := range g.Members {
+			branch := "├─ "
+			if i == len(g.Members)-1 {
+				branch = "└─ "
+			}
+			row := member
+			row.Name = "  " + branch + member.Name
+			processes = append(processes, member)
+			display = append(display, row)
+			keys = append(keys, "")
+		}
+	}
+	return processes, display, keys
+}
+
+// flattenTree lists the expanded part of the process tree in display order.
+// It returns the processes and, for display, copies of them carrying the
+// subtree totals and the indented name.
+func (pt *ProcessTable) flattenTree(roots []*system.ProcessNode) (processes, display []system.ProcessDetail) {
+	var walk func(nodes []*system.ProcessNode, indent string, root bool)
+	walk = func(nodes []*system.ProcessNode, indent string, root bool) {
+		for i, node := range nodes {
+			last := i == len(nodes)-1
+			branch, childIndent := "├─", indent+"│ "
+			if last {
+				branch, childIndent = "└─", indent+"  "
+			}
+			if root {
+				branch, childIndent = "", ""
+			}
+
+			marker := "  "
+			collapsed := pt.collapsed[node.Process.PID]
+			if len(node.Children) > 0 {
+				marker = "▾ "
+				if collapsed {
+					marker = fmt.Sprintf("▸ [+%d] ", node.TreeCount-1)
+				}
+			}
+
+			row := node.Process
+			row.CPUPercent = node.TreeCPU
+			row.CPULifetime = node.TreeCPULifetime
+			row.MemPercent = node.TreeMem
+			row.MemRSS = node.TreeRSS
+			row.Name = indent + branch + marker + row.Name
+
+			processes = append(processes, node.Process)
+			display = append(display, row)
+			if !collapsed {
+				walk(node.Children, childIndent, false)
+			}
+		}
+	}
+	walk(roots, "", true)
+	return processes, display
+}
+
+// getGroupingName returns a user-friendly name for the grouping mode
+func getGroupingName(by system.GroupBy) string {
+	switch by {
+	case system.GroupByUser:
+		return "User"
+	case system.GroupByName:
+		return "Name"
+	case system.GroupByCgroup:
+		return "Cgroup"
+	default:
+		return "None"
+	}
+}
+
 // getSortMethodName returns a user-friendly name for the sort method
 func getSortMethodName(sortBy system.SortType) string {
 	switch sortBy {
 	case system.SortByCPU:
 		return "CPU Usage"
+	case system.SortByCPULifetime:
+		return "Lifetime CPU"
 	case system.SortByMemory:
 		return "Memory Usage"
 	case system.SortByPID:
 		return "Process ID"
 	case system.SortByName:
 		return "Name"
+	case system.SortByPPID:
+		return "Parent PID"
+	case system.SortByUser:
+		return "User"
+	case system.SortByState:
+		return "State"
+	case system.SortByNice:
+		return "Nice"
+	case system.SortByThreads:
+		return "Threads"
+	case system.SortByRSS:
+		return "Resident Memory"
+	case system.SortByVMS:
+		return "Virtual Memory"
+	case system.SortByStart:
+		return "Start Time"
+	case system.SortByRead:
+		return "Bytes Read"
+	case system.SortByWrite:
+		return "Bytes Written"
+	case system.SortByCommand:
+		return "Command"
+	case system.SortByCgroup:
+		return "Cgroup"
 	default:
 		return "CPU Usage"
 	}
@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"go_system_monitor/internal/testutil"
+	"go_system_monitor/system"
+)
+
+func TestProcessTableSelectionFollowsPID(t *testing.T) {
+	pt := NewProcessTable()
+	pt.SetSize(100, 20)
+
+	procs := testutil.Snapshot().Process.Processes
+	pt.Render(procs)
+	pt.ScrollDown(len(procs))
+	selected, ok := pt.Selected()
+	if !ok || selected.PID != procs[1].PID {
+		t.Fatalf("Selected() = %d, %v; want %d", selected.PID, ok, procs[1].PID)
+	}
+
+	// Re-sorting moves the process; the selection stays on it
+	resorted := append([]system.ProcessDetail(nil), procs...)
+	system.SortProcessList(resorted, system.SortByPID)
+	pt.Render(resorted)
+	if got, _ := pt.Selected(); got.PID != selected.PID {
+		t.Errorf("after re-sort Selected() = %d, want %d", got.PID, selected.PID)
+	}
+
+	// When the process exits the selection stays at the same row
+	pt.Render(resorted[:1])
+	if got, ok := pt.Selected(); !ok || got.PID != resorted[0].PID {
+		t.Errorf("after exit Selected() = %d, %v; want %d", got.PID, ok, resorted[0].PID)
+	}
+}
+
+func TestSignalDialog(t *testing.T) {
+	target := system.ProcessDetail{PID: 4242, Name: "postgres"}
+	d := NewSignalDialog()
+	d.Open(target)
+	d.Down()
+	d.Choose()
+	if !d.Confirming() || d.Signal() != "KILL" {
+		t.Fatalf("after choosing: confirming=%v signal=%s", d.Confirming(), d.Signal())
+	}
+	if out := d.Render(); !strings.Contains(out, "Send SIGKILL to 4242 (postgres)?") {
+		t.Errorf("confirmation does not name the signal and target:\n%s", out)
+	}
+
+	d.SetResult(fmt.Errorf("sending SIGKILL to 4242: %w", os.ErrPermission))
+	if out := d.Render(); !strings.Contains(out, "Permission denied") {
+		t.Errorf("permission error is not reported inline:\n%s", out)
+	}
+}
+
+func TestTuneDialog(t *testing.T) {
+	target := system.ProcessDetail{PID: 4242, Name: "postgres", Nice: 0}
+	d := NewTuneDialog()
+	d.Open(TuneNice, target, "0")
+	d.Backspace()
+	d.Insert("10")
+	d.ToggleAllThreads()
+	if d.Value() != "10" || d.AllThreads() {
+		t.Fatalf("after editing: value=%q allThreads=%v", d.Value(), d.AllThreads())
+	}
+
+	d.SetInvalid(fmt.Errorf("%w: process 4242 belongs to uid 0", os.ErrPermission))
+	if out := d.Render(); !strings.Contains(out, "Permission denied") || d.Done() {
+		t.Errorf("validation error is not shown while editing:\n%s", out)
+	}
+
+	d.SetResult(nil)
+	if out := d.Render(); !strings.Contains(out, "Set nice 10 on 4242 (postgres) (main thread only)") {
+		t.Errorf("outcome does not describe the change:\n%s", out)
+	}
+}
+
+func TestProcessTableTree(t *testing.T) {
+	procs := []system.ProcessDetail{
+		{PID: 1, Name: "init", Username: "root", CPUPercent: 1},
+		{PID: 10, PPID: 1, Name: "nginx", Username: "www", CPUPercent: 2},
+		{PID: 11, PPID: 10, Name: "worker", Username: "www", CPUPercent: 30},
+		{PID: 12, PPID: 10, Name: "worker", Username: "www", CPUPercent: 40},
+	}
+	pt := NewProcessTable()
+	pt.SetSize(120, 20)
+	pt.ToggleTreeMode()
+
+	out := pt.Render(procs)
+	for _, want := range []string{"Process Tree (4)", "ΣCPU%", "▾ init", "└─▾ nginx", "  ├─  worker", "  └─  worker", "73.0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("tree view does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Collapse nginx: its workers are hidden and counted in the marker
+	pt.ScrollDown(len(procs))
+	pt.ToggleCollapsed()
+	out = pt.Render(procs)
+	if strings.Contains(out, "worker") || !strings.Contains(out, "▸ [+2] nginx") {
+		t.Errorf("collapsed subtree is still shown:\n%s", out)
+	}
+	if got, _ := pt.Selected(); got.PID != 10 || got.Name != "nginx" {
+		t.Errorf("Selected() = %d %q, want the undecorated nginx process", got.PID, got.Name)
+	}
+
+	pt.ExpandAll()
+	if out = pt.Render(procs); !strings.Contains(out, "worker") {
+		t.Errorf("ExpandAll did not show the workers:\n%s", out)
+	}
+}
+
+func TestProcessDetailView(t *testing.T) {
+	proc := system.ProcessDetail{PID: 4243, PPID: 4242, Name: "java", Nice: 5, CmdLine: "/usr/bin/java -jar app.jar"}
+	v := NewProcessDetailView()
+	v.SetSize(100, 200)
+	v.Open(proc)
+	// The start time of the process could not be read
+	if out := v.Render(); !strings.Contains(out, "Loading details") || !strings.Contains(out, "-jar app.jar") || !strings.Contains(out, "Started:           -") {
+		t.Errorf("view before the inspection arrives:\n%s", out)
+	}
+
+	var history system.ProcessHistory
+	for _, cpu := range []float64{90, 95, 20} {
+		history.CPU.Points = append(history.CPU.Points, system.TimeSeriesPoint{Value: cpu})
+	}
+	v.SetHistory(history)
+	if out := v.Render(); !strings.Contains(out, "now 20.0%, peak 95.0%") {
+		t.Errorf("CPU history is not shown:\n%s", out)
+	}
+
+	// An inspection for another process is ignored
+	v.SetInspection(&system.ProcessInspection{PID: 1})
+	v.SetInspection(&system.ProcessInspection{
+		PID:        4243,
+		Cwd:        "/srv/app",
+		FDs:        []system.FileDescriptor{{FD: 3, Target: "socket:[31337]"}},
+		Namespaces: map[string]string{"net": "net:[4026531840]"},
+		Errors:     map[string]string{system.InspectEnviron: "permission denied"},
+	})
+	out := v.Render()
+	for _, want := range []string{"4243 / 4242", "/srv/app", "socket:[31337]", "net:[4026531840]", "unavailable: permission denied"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("detail view does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestProcessFilter(t *testing.T) {
+	d := NewDashboard()
+	d.SetSize(160, 40)
+	snap := testutil.Snapshot()
+	for d.ActiveTab() != 5 {
+		d.NextTab()
+	}
+
+	if err := d.SetProcessFilter("user:root cpu>50"); err != nil {
+		t.Fatal(err)
+	}
+	out := d.Render(snap)
+	if !strings.Contains(out, "java") || strings.Contains(out, "systemd") || strings.Contains(out, "postgres") {
+		t.Errorf("filter user:root cpu>50 does not show only java:\n%s", out)
+	}
+	if !strings.Contains(out, "⧩ user:root cpu>50") {
+		t.Errorf("status bar does not show the filter:\n%s", out)
+	}
+
+	// A query that does not parse keeps the current filter
+	if err := d.SetProcessFilter("cpu>"); err == nil {
+		t.Error("SetProcessFilter accepted an incomplete query")
+	}
+	if got := d.ProcessFilter(); got != "user:root cpu>50" {
+		t.Errorf("filter after a rejected query = %q", got)
+	}
+
+	// Saved filters replace the query being edited
+	bar := d.FilterBar()
+	bar.SetSaved([]SavedFilter{{Name: "db", Query: "user:postgres"}, {Name: "hogs", Query: "cpu>50"}})
+	bar.Open(d.ProcessFilter())
+	if !bar.NextSaved() || bar.Value() != "user:postgres" || !bar.PrevSaved() || bar.Value() != "cpu>50" {
+		t.Errorf("cycling saved filters shows %q", bar.Value())
+	}
+	if out := bar.Render(); !strings.Contains(out, "[hogs]") {
+		t.Errorf("filter bar does not name the saved filter:\n%s", out)
+	}
+}
+
+func TestProcessTableColumns(t *testing.T) {
+	procs := []system.ProcessDetail{
+		{PID: 1, Name: "init", MemRSS: 8 << 20, CmdLine: "/sbin/init splash"},
+		{PID: 10, PPID: 1, Name: "nginx", MemRSS: 32 << 20, MemVMS: 1 << 30},
+	}
+	pt := NewProcessTable()
+	pt.SetSize(120, 20)
+
+	for _, specs := range [][]ColumnSpec{
+		{{ID: "pid"}, {ID: "bogus"}},
+		{{ID: "pid"}, {ID: "pid"}},
+		{{ID: "pid", Width: 1}},
+	} {
+		if err := pt.SetColumns(specs); err == nil {
+			t.Errorf("SetColumns(%v) accepted invalid columns", specs)
+		}
+	}
+	if got := pt.Columns(); len(got) != len(DefaultColumns) {
+		t.Errorf("rejected columns replaced the defaults: %v", got)
+	}
+
+	if err := pt.SetColumns([]ColumnSpec{{ID: "ppid"}, {ID: "pid", Width: 6}, {ID: "vms"}, {ID: "cmd"}}); err != nil {
+		t.Fatal(err)
+	}
+	pt.SetSortBy(system.SortByPID)
+	out := pt.Render(procs)
+	for _, want := range []string{"PPID", "PID▲", "VIRT", "COMMAND", "/sbin/init splash", "[nginx]", "1.0 GiB"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "CPU%") {
+		t.Errorf("hidden CPU%% column is shown:\n%s", out)
+	}
+
+	// Sorting steps through the visible sortable columns
+	if got := pt.SortColumn(1); got != system.SortByVMS {
+		t.Errorf("SortColumn(1) = %s, want vms", got)
+	}
+	if got := pt.SortColumn(-1); got != system.SortByPPID {
+		t.Errorf("SortColumn(-1) = %s, want ppid", got)
+	}
+	pt.SetSortBy(system.SortByCPU)
+	if got := pt.SortColumn(1); got != system.SortByPPID {
+		t.Errorf("SortColumn(1) from a hidden column = %s, want ppid", got)
+	}
+
+	// The tree adds the subtree RSS after MEM%
+	pt.SetColumns([]ColumnSpec{{ID: "pid"}, {ID: "mem"}, {ID: "name"}})
+	pt.ToggleTreeMode()
+	if out := pt.Render(procs); !strings.Contains(out, "ΣMEM%") || !strings.Contains(out, "ΣRSS") || !strings.Contains(out, "40.0 MiB") {
+		t.Errorf("tree does not show subtree memory:\n%s", out)
+	}
+}
+
+func TestColumnPicker(t *testing.T) {
+	c := NewColumnPicker()
+	c.Open([]ColumnSpec{{ID: "pid"}, {ID: "name"}})
+
+	// Hide PID; NAME is then the only column and cannot be hidden
+	c.Toggle()
+	c.Down()
+	c.Toggle()
+	if got := c.Columns(); len(got) != 1 || got[0].ID != "name" {
+		t.Fatalf("Columns() = %v, want only name", got)
+	}
+
+	// Show PPID, the first hidden column, and move it in front of NAME
+	c.Down()
+	c.Toggle()
+	c.MoveUp()
+	c.Resize(2)
+	if sortBy, ok := c.SortType(); !ok || sortBy != system.SortByPPID {
+		t.Errorf("SortType() = %s, %v", sortBy, ok)
+	}
+	got := c.Columns()
+	if len(got) != 2 || got[0] != (ColumnSpec{ID: "ppid", Width: 10}) || got[1].ID != "name" {
+		t.Errorf("Columns() = %v", got)
+	}
+	if out := c.Render(system.SortByPPID); !strings.Contains(out, "[x] ppid") || !strings.Contains(out, "(sorted)") {
+		t.Errorf("picker does not show the sorted column:\n%s", out)
+	}
+}
+
+func TestProcessTableGroups(t *testing.T) {
+	procs := []system.ProcessDetail{
+		{PID: 20, Name: "postgres", Username: "postgres", CPUPercent: 10, MemRSS: 200 << 20, NumThreads: 4, Cgroup: "/system.slice/postgresql.service"},
+		{PID: 21, Name: "postgres", Username: "postgres", CPUPercent: 15, MemRSS: 100 << 20, NumThreads: 2, Cgroup: "/system.slice/postgresql.service"},
+		{PID: 30, Name: "java", Username: "root", CPUPercent: 20, MemRSS: 1 << 30, NumThreads: 40, Cgroup: "/system.slice/app.service"},
+	}
+	pt := NewProcessTable()
+	pt.SetSize(140, 20)
+	pt.ToggleTreeMode()
+	pt.CycleGrouping()
+	pt.CycleGrouping()
+	if pt.Grouping() != system.GroupByName || pt.TreeMode() {
+		t.Fatalf("grouping = %q, tree = %v", pt.Grouping(), pt.TreeMode())
+	}
+
+	out := pt.Render(procs)
+	for _, want := range []string{"Processes by Name (2 groups)", "ΣCPU%", "ΣTHREADS", "ΣRSS", "▸ postgres (2)", "25.0", "300.0 MiB", "▸ java (1)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("grouped table does not contain %q:\n%s", want, out)
+		}
+	}
+	if _, ok := pt.Selected(); ok {
+		t.Error("a group row is selected as a process")
+	}
+
+	// Drill down into postgres and select its first member
+	pt.ToggleCollapsed()
+	out = pt.Render(procs)
+	if !strings.Contains(out, "▾ postgres (2)") || !strings.Contains(out, "├─ postgres") || !strings.Contains(out, "└─ postgres") {
+		t.Errorf("expanded group does not list its members:\n%s", out)
+	}
+	pt.ScrollDown(10)
+	if got, ok := pt.Selected(); !ok || got.PID != 20 || got.Name != "postgres" {
+		t.Errorf("Selected() = %d %q, %v; want member 20", got.PID, got.Name, ok)
+	}
+
+	// Collapsing from a member selects its group
+	pt.ToggleCollapsed()
+	pt.Render(procs)
+	if key, ok := pt.SelectedGroup(); !ok || key != "postgres" {
+		t.Errorf("SelectedGroup() = %q, %v after collapsing", key, ok)
+	}
+
+	pt.SetGrouping(system.GroupByCgroup)
+	if out := pt.Render(procs); !strings.Contains(out, "postgresql.service (2)") || !strings.Contains(out, "app.service (1)") {
+		t.Errorf("cgroup groups are not named by unit:\n%s", out)
+	}
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+)
+
+// signalDialogState is the step the signal dialog is at
+type signalDialogState int
+
+const (
+	signalDialogClosed signalDialogState = iota
+	signalDialogPicking
+	signalDialogConfirming
+	signalDialogDone
+)
+
+// SignalDialog lets the user pick a signal for a process, confirm it and
+// see the outcome
+type SignalDialog struct {
+	state   signalDialogState
+	target  system.ProcessDetail
+	signals []string
+	cursor  int
+	result  string
+	failed  bool
+}
+
+// NewSignalDialog creates a closed signal dialog
+func NewSignalDialog() *SignalDialog {
+	return &SignalDialog{signals: system.SignalNames}
+}
+
+// Open shows the signal picker for a process
+func (s *SignalDialog) Open(target system.ProcessDetail) {
+	s.state = signalDialogPicking
+	s.target = target
+	s.cursor = 0
+	s.result = ""
+	s.failed = false
+}
+
+// Close hides the dialog
+func (s *SignalDialog) Close() {
+	s.state = signalDialogClosed
+}
+
+// Active reports whether the dialog is shown
+func (s *SignalDialog) Active() bool {
+	return s.state != signalDialogClosed
+}
+
+// Picking reports whether the user is choosing a signal
+func (s *SignalDialog) Picking() bool {
+	return s.state == signalDialogPicking
+}
+
+// Confirming reports whether the dialog is waiting for confirmation
+func (s *SignalDialog) Confirming() bool {
+	return s.state == signalDialogConfirming
+}
+
+// Done reports whether the dialog is showing the outcome
+func (s *SignalDialog) Done() bool {
+	return s.state == signalDialogDone
+}
+
+// Up moves the picker selection up
+func (s *SignalDialog) Up() {
+	if s.cursor > 0 {
+		s.cursor--
+	}
+}
+
+// Down moves the picker selection down
+func (s *SignalDialog) Down() {
+	if s.cursor < len(s.signals)-1 {
+		s.cursor++
+	}
+}
+
+// Choose picks the selected signal and asks for confirmation
+func (s *SignalDialog) Choose() {
+	s.state = signalDialogConfirming
+}
+
+// ChooseIndex picks the signal at index i (0-based) and asks for
+// confirmation. It reports whether i is a valid choice.
+func (s *SignalDialog) ChooseIndex(i int) bool {
+	if i < 0 || i >= len(s.signals) {
+		return false
+	}
+	s.cursor = i
+	s.Choose()
+	return true
+}
+
+// Back returns from the confirmation to the picker
+func (s *SignalDialog) Back() {
+	s.state = signalDialogPicking
+}
+
+// Signal returns the name of the selected signal
+func (s *SignalDialog) Signal() string {
+	return s.signals[s.cursor]
+}
+
+// Target returns the process the dialog was opened for
+func (s *SignalDialog) Target() system.ProcessDetail {
+	return s.target
+}
+
+// SetResult shows the outcome of sending the signal
+func (s *SignalDialog) SetResult(err error) {
+	s.state = signalDialogDone
+	s.failed = err != nil
+	switch {
+	case err == nil:
+		s.result = fmt.Sprintf("Sent SIG%s to %d (%s)", s.Signal(), s.target.PID, s.target.Name)
+	case errors.Is(err, os.ErrPermission):
+		s.result = fmt.Sprintf("Permission denied: cannot signal %d (%s) as this user", s.target.PID, s.target.Name)
+	default:
+		s.result = fmt.Sprintf("Failed: %v", err)
+	}
+}
+
+// Render draws the dialog
+func (s *SignalDialog) Render() string {
+	target := fmt.Sprintf("%d (%s)", s.target.PID, s.target.Name)
+	var lines []string
+
+	switch s.state {
+	case signalDialogPicking:
+		lines = append(lines, HeaderStyle.Render("Send signal to "+target), "")
+		for i, name := range s.signals {
+			line := fmt.Sprintf("  %d  SIG%s", i+1, name)
+			if i == s.cursor {
+				line = HeaderStyle.Render(fmt.Sprintf("▶ %d  SIG%s", i+1, name))
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, "", helpStyle.Render("↑↓/1-8: Choose • Enter: Select • Esc: Cancel"))
+
+	case signalDialogConfirming:
+		lines = append(lines,
+			WarningStyle.Render(fmt.Sprintf("Send SIG%s to %s?", s.Signal(), target)),
+			"",
+			helpStyle.Render("y: Send • n/Esc: Back"),
+		)
+
+	case signalDialogDone:
+		style := NormalStyle
+		if s.failed {
+			style = CriticalStyle
+		}
+		lines = append(lines, style.Render(s.result), "", helpStyle.Render("Any key: Close"))
+
+	default:
+		return ""
+	}
+
+	return CardStyle.Render(lipgloss.JoinVertical(lipgloss.Left, strings.Join(lines, "\n")))
+}
@@ -30,7 +30,17 @@ func RenderSparkline(ts system.TimeSeries, width int, style lipgloss.Style) stri
 			max = p.Value
 		}
 	}
-	if min == max {
+	return RenderSparklineRange(ts, width, min, max, style)
+}
+
+// RenderSparklineRange creates a sparkline scaled to a fixed range, so that
+// a series staying high looks different from one staying low. Values
+// outside the range are clamped.
+func RenderSparklineRange(ts system.TimeSeries, width int, min, max float64, style lipgloss.Style) string {
+	if len(ts.Points) < 2 {
+		return style.Render(strings.Repeat("▁", width))
+	}
+	if min >= max {
 		max = min + 1 // avoid division by zero
 	}
 
@@ -63,6 +73,9 @@ func RenderSparkline(ts system.TimeSeries, width int, style lipgloss.Style) stri
 	for _, p := range points {
 		// Scale value to [0, 1]
 		scaled := (p.Value - min) / (max - min)
+		if scaled < 0 {
+			scaled = 0
+		}
 		// Map to character index
 		idx := int(scaled * float64(numLevels-1))
 		if idx >= numLevels {
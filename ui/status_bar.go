@@ -13,25 +13,39 @@ import (
 type StatusBar struct {
 	width     int
 	height    int
-	metrics   *system.Collector
+	metrics   *system.Snapshot
+	filter    string // active process filter query
 	startTime time.Time
+	now       func() time.Time
 }
 
 // NewStatusBar creates a new status bar
 func NewStatusBar() *StatusBar {
 	return &StatusBar{
 		startTime: time.Now(),
+		now:       time.Now,
 	}
 }
 
+// SetClock replaces the clock used for the uptime and time display
+func (s *StatusBar) SetClock(now func() time.Time) {
+	s.now = now
+	s.startTime = now()
+}
+
 // SetSize updates the status bar dimensions
 func (s *StatusBar) SetSize(width, height int) {
 	s.width = width
 	s.height = height
 }
 
-// Update updates the metrics reference
-func (s *StatusBar) Update(metrics *system.Collector) {
+// SetFilter sets the process filter query shown; "" hides it
+func (s *StatusBar) SetFilter(query string) {
+	s.filter = query
+}
+
+// Update updates the snapshot being displayed
+func (s *StatusBar) Update(metrics *system.Snapshot) {
 	s.metrics = metrics
 }
 
@@ -43,7 +57,7 @@ func (s *StatusBar) Render() string {
 
 	// Left section: hostname and uptime
 	hostname := s.metrics.System.Hostname
-	uptime := time.Since(s.startTime).Round(time.Second)
+	uptime := s.now().Sub(s.startTime).Round(time.Second)
 	left := fmt.Sprintf("%s | Up %s", hostname, formatDuration(uptime))
 
 	// Center section: key metrics
@@ -55,10 +69,11 @@ func (s *StatusBar) Render() string {
 	}
 	center := fmt.Sprintf("%s | %s | %s", cpu, mem, load)
 
-	// Right section: time and active alerts
+	// Right section: time and the active alerts that have been neither
+	// acknowledged nor silenced
 	activeAlerts := 0
-	for _, alert := range s.metrics.AlertManager.Alerts {
-		if !alert.Resolved {
+	for _, alert := range s.metrics.Alerts {
+		if alert.NeedsAttention() {
 			activeAlerts++
 		}
 	}
@@ -66,7 +81,19 @@ func (s *StatusBar) Render() string {
 	if activeAlerts > 0 {
 		alerts = CriticalStyle.Render(fmt.Sprintf("⚠ %d", activeAlerts))
 	}
-	clock := time.Now().Format("15:04:05")
+	// Sources that missed the last collection deadline
+	if timedOut := s.metrics.Report.TimedOut; len(timedOut) > 0 {
+		alerts += " " + WarningStyle.Render(fmt.Sprintf("⏱ %s", strings.Join(timedOut, ",")))
+	}
+	// Process filter, which also applies while other tabs are shown
+	if s.filter != "" {
+		filter := s.filter
+		if runes := []rune(filter); len(runes) > 30 {
+			filter = string(runes[:29]) + "…"
+		}
+		alerts += " " + NormalStyle.Render("⧩ "+filter)
+	}
+	clock := s.now().Format("15:04:05")
 	right := fmt.Sprintf("%s %s", alerts, clock)
 
 	// Calculate spacing
@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go_system_monitor/system"
+)
+
+// TuneKind is the process setting edited by the tune dialog
+type TuneKind int
+
+// Settings the tune dialog can edit
+const (
+	TuneNice TuneKind = iota
+	TuneAffinity
+)
+
+// TuneDialog lets the user enter a new nice value or CPU affinity for a
+// process and shows the outcome
+type TuneDialog struct {
+	active     bool
+	done       bool
+	kind       TuneKind
+	target     system.ProcessDetail
+	input      []rune
+	allThreads bool
+	message    string // validation error while editing, outcome when done
+	failed     bool
+}
+
+// NewTuneDialog creates a closed tune dialog
+func NewTuneDialog() *TuneDialog {
+	return &TuneDialog{}
+}
+
+// Open shows the dialog for a process with value pre-filled
+func (t *TuneDialog) Open(kind TuneKind, target system.ProcessDetail, value string) {
+	*t = TuneDialog{
+		active:     true,
+		kind:       kind,
+		target:     target,
+		input:      []rune(value),
+		allThreads: true,
+	}
+}
+
+// Close hides the dialog
+func (t *TuneDialog) Close() {
+	t.active = false
+}
+
+// Active reports whether the dialog is shown
+func (t *TuneDialog) Active() bool {
+	return t.active
+}
+
+// Done reports whether the dialog is showing the outcome
+func (t *TuneDialog) Done() bool {
+	return t.done
+}
+
+// Kind returns the setting being edited
+func (t *TuneDialog) Kind() TuneKind {
+	return t.kind
+}
+
+// Target returns the process the dialog was opened for
+func (t *TuneDialog) Target() system.ProcessDetail {
+	return t.target
+}
+
+// Value returns the text entered by the user
+func (t *TuneDialog) Value() string {
+	return strings.TrimSpace(string(t.input))
+}
+
+// AllThreads reports whether the change applies to every thread
+func (t *TuneDialog) AllThreads() bool {
+	return t.allThreads
+}
+
+// ToggleAllThreads switches between changing all threads and only the
+// main thread
+func (t *TuneDialog) ToggleAllThreads() {
+	t.allThreads = !t.allThreads
+}
+
+// Insert appends typed text to the input
+func (t *TuneDialog) Insert(s string) {
+	t.input = append(t.input, []rune(s)...)
+	t.message = ""
+}
+
+// Backspace removes the last character of the input
+func (t *TuneDialog) Backspace() {
+	if len(t.input) > 0 {
+		t.input = t.input[:len(t.input)-1]
+	}
+	t.message = ""
+}
+
+// SetInvalid shows why the entered value was rejected and keeps the dialog
+// open for editing
+func (t *TuneDialog) SetInvalid(err error) {
+	t.message = t.describe(err)
+	t.failed = true
+}
+
+// SetResult shows the outcome of applying the change
+func (t *TuneDialog) SetResult(err error) {
+	t.done = true
+	t.failed = err != nil
+	if err == nil {
+		t.message = t.Summary()
+		return
+	}
+	t.message = t.describe(err)
+}
+
+// Summary describes the change, e.g. "Set nice 5 on 4242 (postgres)"
+func (t *TuneDialog) Summary() string {
+	what := "nice " + t.Value()
+	if t.kind == TuneAffinity {
+		what = "CPU affinity " + t.Value()
+	}
+	scope := ""
+	if !t.allThreads {
+		scope = " (main thread only)"
+	}
+	return fmt.Sprintf("Set %s on %d (%s)%s", what, t.target.PID, t.target.Name, scope)
+}
+
+// describe turns an error into the message shown in the dialog
+func (t *TuneDialog) describe(err error) string {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Sprintf("Permission denied: %v", err)
+	}
+	return fmt.Sprintf("Failed: %v", err)
+}
+
+// Render draws the dialog
+func (t *TuneDialog) Render() string {
+	if !t.active {
+		return ""
+	}
+	target := fmt.Sprintf("%d (%s)", t.target.PID, t.target.Name)
+
+	var lines []string
+	if t.done {
+		style := NormalStyle
+		if t.failed {
+			style = CriticalStyle
+		}
+		lines = append(lines, style.Render(t.message), "", helpStyle.Render("Any key: Close"))
+		return CardStyle.Render(strings.Join(lines, "\n"))
+	}
+
+	title, prompt, hint := "Renice "+target,
+		"Nice value: ",
+		fmt.Sprintf("%d (highest priority) to %d (lowest); currently %d", system.MinNice, system.MaxNice, t.target.Nice)
+	if t.kind == TuneAffinity {
+		title, prompt, hint = "CPU affinity of "+target,
+			"CPUs: ",
+			"e.g. 0-3,6"
+	}
+	threads := "[x] all threads"
+	if !t.allThreads {
+		threads = "[ ] all threads (main thread only)"
+	}
+
+	lines = append(lines,
+		HeaderStyle.Render(title),
+		"",
+		prompt+HeaderStyle.Render(string(t.input)+"█"),
+		helpStyle.Render(hint),
+		threads,
+	)
+	if t.message != "" {
+		lines = append(lines, "", CriticalStyle.Render(t.message))
+	}
+	lines = append(lines, "", helpStyle.Render("Enter: Apply • Tab: Toggle all threads • Esc: Cancel"))
+
+	return CardStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}